@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/workspace"
+	"github.com/gagliardetto/solana-go"
+)
+
+// generateFromWorkspace generates the programs of an Anchor workspace, each in
+// the subdirectory of outputDir (with the module path under modPath) named after it.
+// The other options (i.e. -no-go-mod and -generic-options) apply to all the programs.
+func generateFromWorkspace(workspaceDir string, cluster string, options programOptions) error {
+	if options.outputDir == "" {
+		return errors.New("please provide the root directory of the generated code using the -output flag")
+	}
+	ws, err := workspace.Load(workspaceDir)
+	if err != nil {
+		return fmt.Errorf("failed to load the Anchor workspace: %w", err)
+	}
+	if len(ws.Programs) == 0 {
+		return fmt.Errorf("the Anchor workspace %s has no programs", workspaceDir)
+	}
+	modPath := options.modPath
+	if modPath == "" {
+		modPath = path.Join("github.com", "gagliardetto", "anchor-go", "generated")
+	}
+	var jobs []programJob
+	for _, program := range ws.Programs {
+		opts := options
+		opts.idlPath = program.IdlPath
+		opts.outputDir = filepath.Join(options.outputDir, program.Name)
+		opts.modPath = path.Join(modPath, program.Name)
+		opts.programName = defaultProgramName
+		job := programJob{label: program.Name}
+		opts.deployments = workspaceDeployments(program)
+		if _, err := os.Stat(program.IdlPath); err != nil {
+			job.err = fmt.Errorf("no IDL (run `anchor build` first): %w", err)
+		} else if cluster != "" {
+			opts.programID, job.err = clusterProgramID(program, cluster)
+		}
+		job.opts = opts
+		jobs = append(jobs, job)
+	}
+	return generatePrograms(jobs)
+}
+
+// workspaceDeployments returns the program IDs of Anchor.toml as metadata.deployments,
+// which generates a ProgramID<Cluster> variable per cluster (none if Anchor.toml has none).
+func workspaceDeployments(program workspace.Program) idl.Option[idl.IdlDeployments] {
+	if len(program.IDs) == 0 {
+		return idl.None[idl.IdlDeployments]()
+	}
+	var deployments idl.IdlDeployments
+	for cluster, id := range program.IDs {
+		address := idl.Some(id.String())
+		switch cluster {
+		case "mainnet":
+			deployments.Mainnet = address
+		case "testnet":
+			deployments.Testnet = address
+		case "devnet":
+			deployments.Devnet = address
+		case "localnet":
+			deployments.Localnet = address
+		default:
+			slog.Warn("Ignoring the program ID of a custom cluster", "program", program.Name, "cluster", cluster)
+		}
+	}
+	return idl.Some(deployments)
+}
+
+// clusterProgramID returns the program ID of the program on the cluster.
+func clusterProgramID(program workspace.Program, cluster string) (solana.PublicKey, error) {
+	if id, ok := program.IDs[workspace.NormalizeCluster(cluster)]; ok {
+		return id, nil
+	}
+	var clusters []string
+	for cluster := range program.IDs {
+		clusters = append(clusters, cluster)
+	}
+	slices.Sort(clusters)
+	return solana.PublicKey{}, fmt.Errorf("no program ID for cluster %q in %s (clusters: %v)", cluster, workspace.ManifestFilename, clusters)
+}
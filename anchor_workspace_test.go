@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shapesIdl is the IDL of a program with a Holder account holding a Shape, of the given type.
+func shapesIdl(name string, shapeType string) string {
+	return fmt.Sprintf(`{
+  "address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS",
+  "metadata": {"name": %q, "version": "0.1.0", "spec": "0.1.0"},
+  "instructions": [
+    {"name": "set_shape", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [],
+     "args": [{"name": "shape", "type": {"defined": {"name": "Shape"}}}]}
+  ],
+  "accounts": [{"name": "Holder", "discriminator": [8, 7, 6, 5, 4, 3, 2, 1]}],
+  "types": [
+    {"name": "Shape", "type": %s},
+    {"name": "Holder", "type": {"kind": "struct", "fields": [
+      {"name": "shape", "type": {"defined": {"name": "Shape"}}},
+      {"name": "shapes", "type": {"vec": {"defined": {"name": "Shape"}}}}
+    ]}}
+  ]
+}`, name, shapeType)
+}
+
+func TestGenerateFromWorkspace(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	if !hasCommand("go") {
+		t.Skip("go is not installed")
+	}
+	// The dependencies of the generated code are those of anchor-go: use the module cache only.
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	// The two programs define a Shape type: an enum in the first one, a struct in the second one.
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"Anchor.toml": `
+[programs.localnet]
+enum_shapes = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+struct_shapes = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+
+[programs.devnet]
+struct_shapes = "11111111111111111111111111111112"
+`,
+		"programs/enum-shapes/Cargo.toml":   "[package]\nname = \"enum-shapes\"\n",
+		"programs/struct-shapes/Cargo.toml": "[package]\nname = \"struct-shapes\"\n",
+		"target/idl/enum_shapes.json": shapesIdl("enum_shapes", `{"kind": "enum", "variants": [
+			{"name": "Empty"}, {"name": "Circle", "fields": [{"name": "radius", "type": "u64"}]}]}`),
+		"target/idl/struct_shapes.json": shapesIdl("struct_shapes", `{"kind": "struct", "fields": [{"name": "sides", "type": "u8"}]}`),
+	} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	outputDir := filepath.Join(dir, "go")
+	require.NoError(t, generateFromWorkspace(dir, "", programOptions{
+		outputDir: outputDir,
+		modPath:   "example.com/shapes",
+
+		anchorGoReplace: ".",
+	}))
+	for _, name := range []string{"enum_shapes", "struct_shapes"} {
+		// generateProgram builds the generated code; vet its tests too.
+		cmd := exec.Command("go", "vet", "./...")
+		cmd.Dir = filepath.Join(outputDir, name)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%s:\n%s", name, out)
+	}
+	programID, err := os.ReadFile(filepath.Join(outputDir, "struct_shapes", "program_id.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(programID), `ProgramIDDevnet   = solanago.MustPublicKeyFromBase58("11111111111111111111111111111112")`)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gagliardetto/anchor-go/generator"
+	"github.com/gagliardetto/solana-go"
+	"gopkg.in/yaml.v3"
+)
+
+// config is the content of a config file (anchor-go.yaml or anchor-go.json), which lists the
+// programs to generate; relative paths are relative to the directory of the config file.
+//
+//	programs:
+//	  - idl: idls/vault.json
+//	    output: generated/vault
+//	    name: vault
+//	    modPath: github.com/acme/clients/vault
+//	    programId: Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS
+//	    rename:
+//	      types: {Config: VaultConfig}
+//	    filter:
+//	      instructions: {exclude: [admin_withdraw]}
+type config struct {
+	Programs []programConfig `json:"programs" yaml:"programs"`
+}
+
+// programConfig are the options of a program, named like the flags of the generate command.
+type programConfig struct {
+	Idl             string       `json:"idl" yaml:"idl"`
+	Output          string       `json:"output" yaml:"output"`
+	Name            string       `json:"name" yaml:"name"`
+	ModPath         string       `json:"modPath" yaml:"modPath"`
+	ProgramID       string       `json:"programId" yaml:"programId"`
+	NoGoMod         bool         `json:"noGoMod" yaml:"noGoMod"`
+	GenericOptions  bool         `json:"genericOptions" yaml:"genericOptions"`
+	PreviousIdls    []string     `json:"previousIdls" yaml:"previousIdls"`
+	Rename          renameConfig `json:"rename" yaml:"rename"`
+	Filter          filterConfig `json:"filter" yaml:"filter"`
+	AnchorGoReplace string       `json:"anchorGoReplace" yaml:"anchorGoReplace"`
+}
+
+type renameConfig struct {
+	Types        map[string]string `json:"types" yaml:"types"`
+	Instructions map[string]string `json:"instructions" yaml:"instructions"`
+}
+
+type filterConfig struct {
+	Instructions nameFilterConfig `json:"instructions" yaml:"instructions"`
+	Accounts     nameFilterConfig `json:"accounts" yaml:"accounts"`
+	Events       nameFilterConfig `json:"events" yaml:"events"`
+}
+
+type nameFilterConfig struct {
+	Include []string `json:"include" yaml:"include"`
+	Exclude []string `json:"exclude" yaml:"exclude"`
+}
+
+func (f nameFilterConfig) nameFilter() generator.NameFilter {
+	return generator.NameFilter{Include: f.Include, Exclude: f.Exclude}
+}
+
+// loadConfig parses a config file: JSON if its extension is .json, else YAML.
+func loadConfig(configPath string) (*config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the config file: %w", err)
+	}
+	var cfg config
+	if strings.EqualFold(filepath.Ext(configPath), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&cfg)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the config file %s: %w", configPath, err)
+	}
+	if len(cfg.Programs) == 0 {
+		return nil, fmt.Errorf("the config file %s lists no programs", configPath)
+	}
+	return &cfg, nil
+}
+
+// programOptions returns the options of the program; relative paths are resolved against dir.
+func (p programConfig) programOptions(dir string) (programOptions, error) {
+	resolve := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+		return filepath.Join(dir, path)
+	}
+	if p.Idl == "" {
+		return programOptions{}, errors.New("missing idl")
+	}
+	if p.Output == "" {
+		return programOptions{}, errors.New("missing output")
+	}
+	opts := programOptions{
+		idlPath:         resolve(p.Idl),
+		outputDir:       resolve(p.Output),
+		programName:     p.Name,
+		modPath:         p.ModPath,
+		skipGoMod:       p.NoGoMod,
+		genericOptions:  p.GenericOptions,
+		anchorGoReplace: resolve(p.AnchorGoReplace),
+		renames: generator.Renames{
+			Types:        p.Rename.Types,
+			Instructions: p.Rename.Instructions,
+		},
+		filter: generator.Filter{
+			Instructions: p.Filter.Instructions.nameFilter(),
+			Accounts:     p.Filter.Accounts.nameFilter(),
+			Events:       p.Filter.Events.nameFilter(),
+		},
+	}
+	if opts.programName == "" {
+		opts.programName = defaultProgramName
+	}
+	if p.ProgramID != "" {
+		programID, err := solana.PublicKeyFromBase58(p.ProgramID)
+		if err != nil {
+			return programOptions{}, fmt.Errorf("invalid programId %q: %w", p.ProgramID, err)
+		}
+		opts.programID = programID
+	}
+	for _, previousIdl := range p.PreviousIdls {
+		opts.pathsToPreviousIdls = append(opts.pathsToPreviousIdls, resolve(previousIdl))
+	}
+	return opts, nil
+}
+
+// generateFromConfig generates all the programs of a config file.
+func generateFromConfig(configPath string) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(configPath)
+	var jobs []programJob
+	for i, program := range cfg.Programs {
+		opts, err := program.programOptions(dir)
+		jobs = append(jobs, programJob{
+			label: fmt.Sprintf("programs[%d] (%s)", i, program.Idl),
+			opts:  opts,
+			err:   err,
+		})
+	}
+	return generatePrograms(jobs)
+}
+
+// programJob is the generation of a program in a batch.
+type programJob struct {
+	label string
+	opts  programOptions
+	err   error // If not nil, the options are invalid, and the program is not generated.
+}
+
+// generatePrograms generates the programs of a batch: the failure of a program
+// is reported, and doesn't stop the generation of the others.
+func generatePrograms(jobs []programJob) error {
+	type failure struct {
+		program string
+		err     error
+	}
+	var failures []failure
+	for _, job := range jobs {
+		slog.Info("Generating program", "program", job.label)
+		err := job.err
+		if err == nil {
+			err = generateProgramRecovering(job.opts)
+		}
+		if err != nil {
+			slog.Error("Failed to generate program", "program", job.label, "error", err)
+			failures = append(failures, failure{program: job.label, err: err})
+		}
+	}
+	if len(failures) == 0 {
+		slog.Info("Generated all the programs", "count", len(jobs))
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Failed to generate %d of %d programs:\n", len(failures), len(jobs))
+	for _, failure := range failures {
+		fmt.Fprintf(os.Stderr, "  %s: %v\n", failure.program, failure.err)
+	}
+	return fmt.Errorf("failed to generate %d of %d programs", len(failures), len(jobs))
+}
+
+// generateProgramRecovering is like generateProgram, but it returns the panics
+// of the generator (e.g. on the features of the IDL that it doesn't support) as errors.
+func generateProgramRecovering(opts programOptions) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return generateProgram(opts)
+}
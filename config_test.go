@@ -0,0 +1,147 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/generator"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig := func(name string, content string) string {
+		configPath := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(configPath, []byte(content), 0o644))
+		return configPath
+	}
+
+	t.Run("YAML", func(t *testing.T) {
+		cfg, err := loadConfig(writeConfig("anchor-go.yaml", `
+programs:
+  - idl: idls/vault.json
+    output: generated/vault
+    name: vault
+    modPath: github.com/acme/clients/vault
+    programId: Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS
+    previousIdls: [idls/vault-v1.json]
+    rename:
+      types: {Config: VaultConfig}
+    filter:
+      instructions: {exclude: [admin_withdraw]}
+  - idl: /abs/other.json
+    output: /abs/other
+`))
+		require.NoError(t, err)
+		require.Len(t, cfg.Programs, 2)
+
+		opts, err := cfg.Programs[0].programOptions(dir)
+		require.NoError(t, err)
+		assert.Equal(t, programOptions{
+			idlPath:             filepath.Join(dir, "idls/vault.json"),
+			outputDir:           filepath.Join(dir, "generated/vault"),
+			programName:         "vault",
+			modPath:             "github.com/acme/clients/vault",
+			programID:           solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"),
+			pathsToPreviousIdls: []string{filepath.Join(dir, "idls/vault-v1.json")},
+			renames:             generator.Renames{Types: map[string]string{"Config": "VaultConfig"}},
+			filter:              generator.Filter{Instructions: generator.NameFilter{Exclude: []string{"admin_withdraw"}}},
+		}, opts)
+
+		opts, err = cfg.Programs[1].programOptions(dir)
+		require.NoError(t, err)
+		assert.Equal(t, "/abs/other.json", opts.idlPath)
+		assert.Equal(t, "/abs/other", opts.outputDir)
+		assert.Equal(t, defaultProgramName, opts.programName)
+		assert.True(t, opts.programID.IsZero())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		cfg, err := loadConfig(writeConfig("anchor-go.json", `{
+  "programs": [
+    {"idl": "vault.json", "output": "out", "noGoMod": true, "genericOptions": true,
+     "filter": {"events": {"include": ["Deposited"]}}}
+  ]
+}`))
+		require.NoError(t, err)
+		opts, err := cfg.Programs[0].programOptions(dir)
+		require.NoError(t, err)
+		assert.True(t, opts.skipGoMod)
+		assert.True(t, opts.genericOptions)
+		assert.Equal(t, []string{"Deposited"}, opts.filter.Events.Include)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"unknown.yaml": "programs:\n  - idl: a.json\n    outptu: out\n",
+			"unknown.json": `{"programs": [{"idl": "a.json", "outptu": "out"}]}`,
+			"empty.yaml":   "programs: []\n",
+			"invalid.json": `{"programs": `,
+		} {
+			_, err := loadConfig(writeConfig(name, content))
+			assert.Error(t, err, name)
+		}
+		_, err := loadConfig(filepath.Join(dir, "missing.yaml"))
+		assert.Error(t, err)
+
+		for _, program := range []programConfig{
+			{Output: "out"},
+			{Idl: "a.json"},
+			{Idl: "a.json", Output: "out", ProgramID: "not-a-key"},
+		} {
+			_, err := program.programOptions(dir)
+			assert.Error(t, err)
+		}
+	})
+}
+
+func TestGenerateFromConfig(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	if !hasCommand("go") {
+		t.Skip("go is not installed")
+	}
+	// The dependencies of the generated code are those of anchor-go: use the module cache only.
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	dir := t.TempDir()
+	// The generator panics on the accounts groups: the failure of the first program
+	// must not stop the generation of the second one.
+	grouped := `{
+  "address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS",
+  "metadata": {"name": "grouped", "version": "0.1.0", "spec": "0.1.0"},
+  "instructions": [
+    {"name": "do_it", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "args": [],
+     "accounts": [{"name": "group", "accounts": [{"name": "a"}]}]}
+  ]
+}`
+	checkout, err := filepath.Abs(".")
+	require.NoError(t, err)
+	for name, content := range map[string]string{
+		"grouped.json": grouped,
+		"shapes.json":  shapesIdl("shapes", `{"kind": "struct", "fields": [{"name": "sides", "type": "u8"}]}`),
+		"anchor-go.yaml": `
+programs:
+  - idl: grouped.json
+    output: out/grouped
+    modPath: example.com/grouped
+    anchorGoReplace: ` + checkout + `
+  - idl: shapes.json
+    output: out/shapes
+    modPath: example.com/shapes
+    anchorGoReplace: ` + checkout + `
+`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	err = generateFromConfig(filepath.Join(dir, "anchor-go.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to generate 1 of 2 programs")
+	assert.FileExists(t, filepath.Join(dir, "out", "shapes", "program_id.go"))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/anchor-go/idl"
+)
+
+func cmdConvert(args []string) error {
+	fs := newFlagSet("convert")
+	var idlFlags idlFlags
+	idlFlags.register(fs)
+	var output string
+	fs.StringVar(&output, "output", "", "Path to write the converted IDL to (optional; defaults to stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if idlFlags.path == "" {
+		return errors.New("please provide the path to the IDL file using the -idl flag")
+	}
+	data, err := os.ReadFile(idlFlags.path)
+	if err != nil {
+		return fmt.Errorf("failed to read the IDL %s: %w", idlFlags.path, err)
+	}
+	if !idl.IsOldIdl(data) {
+		return fmt.Errorf("the IDL %s is not in the legacy (pre-0.30) format", idlFlags.path)
+	}
+	converted, err := idl.ConvertLegacyToJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to convert the IDL %s: %w", idlFlags.path, err)
+	}
+	// Check that the result parses.
+	if _, err := idl.Parse(converted); err != nil {
+		return fmt.Errorf("failed to parse the converted IDL: %w", err)
+	}
+	converted = append(converted, '\n')
+	if output == "" {
+		_, err = os.Stdout.Write(converted)
+		return err
+	}
+	return os.WriteFile(output, converted, 0o644)
+}
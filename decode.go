@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	bin "github.com/gagliardetto/binary"
+	"github.com/mr-tron/base58"
+)
+
+// decodedValue is the output of the decode command.
+type decodedValue struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+func cmdDecode(args []string) error {
+	fs := newFlagSet("decode")
+	var idlFlags idlFlags
+	idlFlags.register(fs)
+	var data string
+	fs.StringVar(&data, "data", "", "Data to decode (required; - reads it from stdin)")
+	var encoding string
+	fs.StringVar(&encoding, "encoding", "base64", "Encoding of the data: base64, base58 or hex")
+	var kind string
+	fs.StringVar(&kind, "kind", "auto", "Kind of the data: account, event, instruction, or auto to match the discriminator against all of them")
+	var typeName string
+	fs.StringVar(&typeName, "type", "", "Decode the data as this type of the IDL, without a discriminator (optional)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	parsedIdl, err := idlFlags.load()
+	if err != nil {
+		return err
+	}
+	if data == "" {
+		return errors.New("please provide the data to decode using the -data flag")
+	}
+	if data == "-" {
+		stdin, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read the data from stdin: %w", err)
+		}
+		data = string(stdin)
+	}
+	buf, err := decodeData(strings.TrimSpace(data), encoding)
+	if err != nil {
+		return err
+	}
+
+	var decoded *decodedValue
+	var remaining int
+	if typeName != "" {
+		decoded, remaining, err = decodeType(parsedIdl, typeName, buf)
+	} else {
+		decoded, remaining, err = decodeWithDiscriminator(parsedIdl, kind, buf)
+	}
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d trailing bytes were not decoded.\n", remaining)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(decoded)
+}
+
+// decodeData decodes the -data flag.
+func decodeData(data string, encoding string) ([]byte, error) {
+	var buf []byte
+	var err error
+	switch encoding {
+	case "base64":
+		buf, err = base64.StdEncoding.DecodeString(data)
+	case "base58":
+		buf, err = base58.Decode(data)
+	case "hex":
+		buf, err = hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	default:
+		return nil, fmt.Errorf("unknown encoding %q (expected base64, base58 or hex)", encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the data as %s: %w", encoding, err)
+	}
+	return buf, nil
+}
+
+// decodeType decodes data as a defined type of the IDL, and returns the number of bytes left.
+func decodeType(idlObj *idl.Idl, typeName string, data []byte) (*decodedValue, int, error) {
+	decoder := bin.NewBorshDecoder(data)
+	value, err := newIdlTypes(idlObj).decode(decoder, &idltype.Defined{Name: typeName}, genericArgs{}, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode %s: %w", typeName, err)
+	}
+	return &decodedValue{Kind: "type", Name: typeName, Value: value}, decoder.Remaining(), nil
+}
+
+// decodeWithDiscriminator decodes the data of an account, event or instruction, identified by
+// the discriminator its data starts with; kind restricts the search to one of them.
+func decodeWithDiscriminator(idlObj *idl.Idl, kind string, data []byte) (*decodedValue, int, error) {
+	if kind != "auto" && kind != "account" && kind != "event" && kind != "instruction" {
+		return nil, 0, fmt.Errorf("unknown kind %q (expected auto, account, event or instruction)", kind)
+	}
+	types := newIdlTypes(idlObj)
+	matches := func(discriminator idl.IdlDiscriminator) bool {
+		return len(discriminator) > 0 && bytes.HasPrefix(data, discriminator)
+	}
+	decode := func(kind string, name string, discriminator idl.IdlDiscriminator, decodeValue func(*bin.Decoder) (any, error)) (*decodedValue, int, error) {
+		decoder := bin.NewBorshDecoder(data[len(discriminator):])
+		value, err := decodeValue(decoder)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to decode %s %s: %w", kind, name, err)
+		}
+		return &decodedValue{Kind: kind, Name: name, Value: value}, decoder.Remaining(), nil
+	}
+	decodeDefined := func(name string) func(*bin.Decoder) (any, error) {
+		return func(decoder *bin.Decoder) (any, error) {
+			// The layout of an account or event is the type of the same name.
+			return types.decode(decoder, &idltype.Defined{Name: name}, genericArgs{}, 0)
+		}
+	}
+
+	if kind == "auto" || kind == "account" {
+		for _, account := range idlObj.Accounts {
+			if matches(account.Discriminator) {
+				return decode("account", account.Name, account.Discriminator, decodeDefined(account.Name))
+			}
+		}
+	}
+	if kind == "auto" || kind == "event" {
+		for _, event := range idlObj.Events {
+			if matches(event.Discriminator) {
+				return decode("event", event.Name, event.Discriminator, decodeDefined(event.Name))
+			}
+		}
+	}
+	if kind == "auto" || kind == "instruction" {
+		for _, ix := range idlObj.Instructions {
+			if matches(ix.Discriminator) {
+				return decode("instruction", ix.Name, ix.Discriminator, func(decoder *bin.Decoder) (any, error) {
+					return types.decodeFields(decoder, idl.IdlDefinedFieldsNamed(ix.Args), genericArgs{}, 0)
+				})
+			}
+		}
+	}
+	if kind == "auto" {
+		return nil, 0, errors.New("the data doesn't start with the discriminator of an account, event or instruction of the IDL")
+	}
+	return nil, 0, fmt.Errorf("the data doesn't start with the discriminator of an %s of the IDL", kind)
+}
@@ -0,0 +1,77 @@
+// Package decode contains the options of the generated account parsers
+// (ParseAccount_*WithOptions and ParseAnyAccountWithOptions).
+//
+// Programs regularly realloc their accounts to append fields across upgrades,
+// so accounts created before an upgrade are shorter than the current layout,
+// and accounts allocated with some spare space have trailing padding.
+package decode
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/anchor-go/limits"
+	bin "github.com/gagliardetto/binary"
+)
+
+// ErrTrailingBytes is returned in Strict mode when bytes are left after the decoded fields.
+var ErrTrailingBytes = errors.New("trailing bytes after the decoded fields")
+
+// Mode selects how accounts whose size doesn't match the layout are decoded.
+type Mode int
+
+const (
+	// Default ignores the bytes left after the decoded fields,
+	// and fails if the account is shorter than the layout.
+	Default Mode = iota
+	// Strict fails if the account is shorter than the layout,
+	// or if bytes are left after the decoded fields.
+	Strict
+	// Lenient ignores the bytes left after the decoded fields,
+	// and zero-fills the trailing fields missing from the account,
+	// i.e. it decodes the account as the program does after a realloc
+	// (which zero-fills the new space).
+	Lenient
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Default:
+		return "default"
+	case Strict:
+		return "strict"
+	case Lenient:
+		return "lenient"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// Options are the options of the account parsers; the zero value is the Default mode, without limits.
+type Options struct {
+	Mode Mode
+	// Limits bounds the allocations of the decode of an account from untrusted data.
+	Limits limits.Limits
+}
+
+// Input returns the data to decode: in Lenient mode, data followed by
+// zeroSize zero bytes, where zeroSize is the size of the layout when all its
+// fields are zero (i.e. the most that the missing fields can take).
+func (o Options) Input(data []byte, zeroSize int) []byte {
+	if o.Mode != Lenient || zeroSize <= 0 {
+		return data
+	}
+	padded := make([]byte, len(data)+zeroSize)
+	copy(padded, data)
+	return padded
+}
+
+// Consumed returns the number of bytes of data (of length dataLen) consumed by the decoder,
+// which excludes the zero-filled bytes of the Lenient mode; in Strict mode, it fails
+// if bytes are left.
+func (o Options) Consumed(decoder *bin.Decoder, dataLen int) (int, error) {
+	consumed := min(decoder.Position(), uint(dataLen))
+	if o.Mode == Strict && int(consumed) < dataLen {
+		return 0, fmt.Errorf("%w: %d bytes decoded, %d bytes left", ErrTrailingBytes, consumed, dataLen-int(consumed))
+	}
+	return int(consumed), nil
+}
@@ -0,0 +1,33 @@
+package decode
+
+import (
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	data := []byte{1, 2, 3, 4}
+
+	require.Equal(t, data, Options{}.Input(data, 4))
+	require.Equal(t, data, Options{Mode: Strict}.Input(data, 4))
+	require.Equal(t, []byte{1, 2, 3, 4, 0, 0, 0, 0}, Options{Mode: Lenient}.Input(data, 4))
+
+	decoder := bin.NewBorshDecoder(data)
+	_, err := decoder.ReadUint16(bin.LE)
+	require.NoError(t, err)
+	consumed, err := Options{}.Consumed(decoder, len(data))
+	require.NoError(t, err)
+	require.Equal(t, 2, consumed)
+	_, err = Options{Mode: Strict}.Consumed(decoder, len(data))
+	require.ErrorIs(t, err, ErrTrailingBytes)
+
+	// The zero-filled bytes aren't consumed:
+	decoder = bin.NewBorshDecoder(Options{Mode: Lenient}.Input(data, 4))
+	_, err = decoder.ReadUint64(bin.LE)
+	require.NoError(t, err)
+	consumed, err = Options{Mode: Lenient}.Consumed(decoder, len(data))
+	require.NoError(t, err)
+	require.Equal(t, 4, consumed)
+}
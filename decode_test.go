@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/stretchr/testify/require"
+)
+
+const testDecodeIdl = `{
+  "address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS",
+  "metadata": {"name": "demo", "version": "0.1.0", "spec": "0.1.0"},
+  "instructions": [
+    {
+      "name": "deposit",
+      "discriminator": [1, 1, 1, 1, 1, 1, 1, 1],
+      "accounts": [{"name": "vault", "writable": true}, {"name": "group", "accounts": [{"name": "a"}, {"name": "b"}]}],
+      "args": [{"name": "amount", "type": "u64"}, {"name": "memo", "type": {"option": "string"}}]
+    }
+  ],
+  "accounts": [
+    {"name": "Vault", "discriminator": [2, 2, 2, 2, 2, 2, 2, 2]},
+    {"name": "Pair", "discriminator": [3, 3, 3, 3, 3, 3, 3, 3]}
+  ],
+  "events": [
+    {"name": "Deposited", "discriminator": [4, 4, 4, 4, 4, 4, 4, 4]}
+  ],
+  "types": [
+    {
+      "name": "Vault",
+      "type": {"kind": "struct", "fields": [
+        {"name": "big", "type": "i128"},
+        {"name": "shape", "type": {"defined": {"name": "Shape"}}},
+        {"name": "data", "type": "bytes"},
+        {"name": "keys", "type": {"vec": "pubkey"}}
+      ]}
+    },
+    {
+      "name": "Shape",
+      "type": {"kind": "enum", "variants": [
+        {"name": "Empty"},
+        {"name": "Rect", "fields": ["u8", "u8"]}
+      ]}
+    },
+    {
+      "name": "Pair",
+      "type": {"kind": "struct", "fields": [
+        {"name": "inner", "type": {"defined": {"name": "Generic", "generics": [{"kind": "type", "type": "u16"}, {"kind": "const", "value": "2"}]}}}
+      ]}
+    },
+    {
+      "name": "Generic",
+      "generics": [{"kind": "type", "name": "T"}, {"kind": "const", "name": "N", "type": "usize"}],
+      "type": {"kind": "struct", "fields": [{"name": "items", "type": {"array": [{"generic": "T"}, {"generic": "N"}]}}]}
+    },
+    {
+      "name": "Deposited",
+      "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}, {"name": "ok", "type": "bool"}]}
+    }
+  ]
+}`
+
+func parseTestDecodeIdl(t *testing.T) *idl.Idl {
+	var idlObj idl.Idl
+	require.NoError(t, json.Unmarshal([]byte(testDecodeIdl), &idlObj))
+	return &idlObj
+}
+
+func TestDecodeWithDiscriminator(t *testing.T) {
+	idlObj := parseTestDecodeIdl(t)
+
+	tests := []struct {
+		name      string
+		kind      string
+		data      []byte
+		want      string
+		remaining int
+	}{
+		{
+			name: "account",
+			kind: "auto",
+			data: concatBytes(
+				[]byte{2, 2, 2, 2, 2, 2, 2, 2},
+				[]byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, // -2
+				[]byte{1, 3, 4},    // Rect(3, 4)
+				[]byte{2, 0, 0, 0}, // bytes
+				[]byte{0xab, 0xcd},
+				[]byte{0, 0, 0, 0}, // no keys
+			),
+			want: `{"kind":"account","name":"Vault","value":{"big":"-2","shape":{"Rect":{"v0":3,"v1":4}},"data":"q80=","keys":[]}}`,
+		},
+		{
+			name: "generic account",
+			kind: "account",
+			data: []byte{3, 3, 3, 3, 3, 3, 3, 3, 1, 0, 2, 0},
+			want: `{"kind":"account","name":"Pair","value":{"inner":{"items":[1,2]}}}`,
+		},
+		{
+			name:      "event with trailing bytes",
+			kind:      "event",
+			data:      []byte{4, 4, 4, 4, 4, 4, 4, 4, 9, 0, 0, 0, 0, 0, 0, 0, 1, 0xff},
+			want:      `{"kind":"event","name":"Deposited","value":{"amount":9,"ok":true}}`,
+			remaining: 1,
+		},
+		{
+			name: "instruction",
+			kind: "auto",
+			data: []byte{1, 1, 1, 1, 1, 1, 1, 1, 5, 0, 0, 0, 0, 0, 0, 0, 1, 2, 0, 0, 0, 'h', 'i'},
+			want: `{"kind":"instruction","name":"deposit","value":{"amount":5,"memo":"hi"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, remaining, err := decodeWithDiscriminator(idlObj, tt.kind, tt.data)
+			require.NoError(t, err)
+			got, err := json.Marshal(decoded)
+			require.NoError(t, err)
+			require.JSONEq(t, tt.want, string(got))
+			require.Equal(t, tt.remaining, remaining)
+		})
+	}
+
+	t.Run("unknown discriminator", func(t *testing.T) {
+		_, _, err := decodeWithDiscriminator(idlObj, "auto", []byte{9, 9, 9, 9, 9, 9, 9, 9})
+		require.Error(t, err)
+	})
+	t.Run("wrong kind", func(t *testing.T) {
+		_, _, err := decodeWithDiscriminator(idlObj, "event", []byte{2, 2, 2, 2, 2, 2, 2, 2})
+		require.Error(t, err)
+	})
+	t.Run("declared length exceeds the input", func(t *testing.T) {
+		data := concatBytes([]byte{2, 2, 2, 2, 2, 2, 2, 2}, make([]byte, 16), []byte{0}, []byte{0xff, 0xff, 0xff, 0x7f})
+		_, _, err := decodeWithDiscriminator(idlObj, "account", data)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "data")
+	})
+}
+
+func TestDecodeType(t *testing.T) {
+	idlObj := parseTestDecodeIdl(t)
+
+	decoded, remaining, err := decodeType(idlObj, "Shape", []byte{0})
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+	require.Equal(t, "Empty", decoded.Value)
+
+	_, _, err = decodeType(idlObj, "Missing", []byte{0})
+	require.Error(t, err)
+}
+
+func TestDecodeData(t *testing.T) {
+	for _, encoding := range []struct {
+		encoding string
+		data     string
+	}{
+		{"base64", "AQID"},
+		{"base58", "Ldp"},
+		{"hex", "010203"},
+		{"hex", "0x010203"},
+	} {
+		buf, err := decodeData(encoding.data, encoding.encoding)
+		require.NoError(t, err, encoding.encoding)
+		require.Equal(t, []byte{1, 2, 3}, buf, encoding.encoding)
+	}
+	_, err := decodeData("AQID", "base32")
+	require.Error(t, err)
+}
+
+func TestInspect(t *testing.T) {
+	report := inspect(parseTestDecodeIdl(t))
+
+	require.Equal(t, "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS", report.Address)
+	require.Equal(t, []inspectInstruction{
+		{Name: "deposit", Discriminator: "0101010101010101", Args: 2, Accounts: 3},
+	}, report.Instructions)
+	require.Equal(t, []inspectLayout{
+		// The discriminator, i128, the Empty variant, and the lengths of data and keys.
+		{Name: "Vault", Discriminator: "0202020202020202", Size: 8 + 16 + 1 + 4 + 4, FixedSize: false},
+		{Name: "Pair", Discriminator: "0303030303030303", Size: 8 + 2*2, FixedSize: true},
+	}, report.Accounts)
+	require.Equal(t, []inspectLayout{
+		{Name: "Deposited", Discriminator: "0404040404040404", Size: 8 + 8 + 1, FixedSize: true},
+	}, report.Events)
+}
+
+func concatBytes(parts ...[]byte) []byte {
+	var out []byte
+	for _, part := range parts {
+		out = append(out, part...)
+	}
+	return out
+}
+
+func TestDecodeMatchesGeneratedJSON(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	if !hasCommand("go") {
+		t.Skip("go is not installed")
+	}
+	// The dependencies of the generated code are those of anchor-go: use the module cache only.
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	const shapesIdl = `{
+  "address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS",
+  "metadata": {"name": "shapes", "version": "0.1.0", "spec": "0.1.0"},
+  "instructions": [],
+  "types": [
+    {"name": "Point", "type": {"kind": "struct", "fields": ["u8", "u16"]}},
+    {"name": "Shape", "type": {"kind": "enum", "variants": [
+      {"name": "Circle", "fields": [{"name": "radius", "type": "u64"}]},
+      {"name": "Rect", "fields": ["u8", "u8"]}
+    ]}},
+    {"name": "Holder", "type": {"kind": "struct", "fields": [
+      {"name": "origin", "type": {"defined": {"name": "Point"}}},
+      {"name": "shape", "type": {"defined": {"name": "Shape"}}},
+      {"name": "shapes", "type": {"vec": {"defined": {"name": "Shape"}}}}
+    ]}}
+  ]
+}`
+	data := concatBytes(
+		[]byte{7, 8, 1},                   // origin: Point(7, 264)
+		[]byte{1, 3, 4},                   // shape: Rect(3, 4)
+		[]byte{1, 0, 0, 0},                // shapes: 1 item
+		[]byte{0, 5, 0, 0, 0, 0, 0, 0, 0}, // Circle{radius: 5}
+	)
+	var idlObj idl.Idl
+	require.NoError(t, json.Unmarshal([]byte(shapesIdl), &idlObj))
+	decoded, remaining, err := decodeType(&idlObj, "Holder", data)
+	require.NoError(t, err)
+	require.Zero(t, remaining)
+	want, err := json.Marshal(decoded.Value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"origin":{"v0":7,"v1":264},"shape":{"Rect":{"v0":3,"v1":4}},"shapes":[{"Circle":{"radius":5}}]}`, string(want))
+
+	// The generated code decodes the same data to the same JSON.
+	dir := t.TempDir()
+	idlPath := filepath.Join(dir, "shapes.json")
+	require.NoError(t, os.WriteFile(idlPath, []byte(shapesIdl), 0o644))
+	outputDir := filepath.Join(dir, "shapes")
+	require.NoError(t, generateProgram(programOptions{
+		idlPath:     idlPath,
+		outputDir:   outputDir,
+		programName: defaultProgramName,
+		modPath:     "example.com/shapes",
+
+		anchorGoReplace: ".",
+	}))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "json_test.go"), []byte(fmt.Sprintf(`package shapes
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	var holder Holder
+	if err := holder.Unmarshal(%#v); err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotValue, wantValue any
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(%q), &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("got %%s, want %%s", got, %q)
+	}
+}
+`, data, want, want)), 0o644))
+	cmd := exec.Command("go", "test", "-run", "TestJSON", ".")
+	cmd.Dir = outputDir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "%s", out)
+}
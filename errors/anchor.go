@@ -0,0 +1,155 @@
+package errors
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// AnchorError is the structured form of the error that an Anchor program
+// logs when an instruction fails, e.g.:
+//
+//	Program log: AnchorError caused by account: vault. Error Code: ConstraintSeeds. Error Number: 2006. Error Message: A seeds constraint was violated.
+//	Program log: Left:
+//	Program log: 8ZVh...
+//	Program log: Right:
+//	Program log: 3nWq...
+type AnchorError struct {
+	// ProgramID is the program that was executing when the error was logged.
+	ProgramID solana.PublicKey
+	// Account is the name of the account that caused the error, if any.
+	Account string
+	// File and Line are the source location that threw the error, if any.
+	File string
+	Line int
+	// Code is the name of the error code, e.g. "ConstraintSeeds".
+	Code string
+	// Number is the numeric error code, e.g. 2006.
+	Number int
+	// Message is the human-readable error message.
+	Message string
+	// Left and Right are the compared values (pubkeys or plain values),
+	// if the program logged them.
+	Left  string
+	Right string
+	// ProgramError is the program's typed error for Number, if known.
+	ProgramError error
+}
+
+func (e *AnchorError) Error() string {
+	var b strings.Builder
+	b.WriteString("AnchorError")
+	switch {
+	case e.Account != "":
+		b.WriteString(" caused by account " + e.Account)
+	case e.File != "":
+		b.WriteString(" thrown in " + e.File + ":" + strconv.Itoa(e.Line))
+	}
+	b.WriteString(": " + e.Code + " (" + strconv.Itoa(e.Number) + "): " + e.Message)
+	if e.Left != "" || e.Right != "" {
+		b.WriteString(" (left: " + e.Left + ", right: " + e.Right + ")")
+	}
+	return b.String()
+}
+
+func (e *AnchorError) Unwrap() error {
+	return e.ProgramError
+}
+
+var (
+	anchorErrorRegexp = regexp.MustCompile(
+		`^Program log: AnchorError (?:occurred|thrown in (.+):(\d+)|caused by account: (.+?))\. Error Code: (\w+)\. Error Number: (\d+)\. Error Message: (.*?)\.?$`,
+	)
+	programInvokeRegexp = regexp.MustCompile(`^Program (\w+) invoke \[\d+\]$`)
+	programExitRegexp   = regexp.MustCompile(`^Program (\w+) (?:success|failed)`)
+)
+
+const (
+	programLogPrefix = "Program log: "
+	leftLogPrefix    = programLogPrefix + "Left:"
+	rightLogPrefix   = programLogPrefix + "Right:"
+)
+
+// ParseAnchorError looks for an AnchorError in the logs of a failed
+// transaction and returns it in structured form.
+// It returns false if the logs don't contain an AnchorError.
+func ParseAnchorError(logs []string) (*AnchorError, bool) {
+	var programStack []string
+	for i, line := range logs {
+		if m := programInvokeRegexp.FindStringSubmatch(line); m != nil {
+			programStack = append(programStack, m[1])
+			continue
+		}
+		if m := programExitRegexp.FindStringSubmatch(line); m != nil {
+			if len(programStack) > 0 {
+				programStack = programStack[:len(programStack)-1]
+			}
+			continue
+		}
+		anchorErr, ok := ParseAnchorErrorLog(line)
+		if !ok {
+			continue
+		}
+		if len(programStack) > 0 {
+			if programID, err := solana.PublicKeyFromBase58(programStack[len(programStack)-1]); err == nil {
+				anchorErr.ProgramID = programID
+			}
+		}
+		anchorErr.Left, anchorErr.Right = parseComparedValues(logs[i+1:])
+		return anchorErr, true
+	}
+	return nil, false
+}
+
+// ParseAnchorErrorLog parses a single "AnchorError ..." log line.
+// The compared values and the program ID are not set, because they
+// are logged on other lines; use ParseAnchorError for those.
+func ParseAnchorErrorLog(line string) (*AnchorError, bool) {
+	m := anchorErrorRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	number, err := strconv.Atoi(m[5])
+	if err != nil {
+		return nil, false
+	}
+	anchorErr := &AnchorError{
+		File:    m[1],
+		Account: m[3],
+		Code:    m[4],
+		Number:  number,
+		Message: m[6],
+	}
+	if m[2] != "" {
+		anchorErr.Line, _ = strconv.Atoi(m[2])
+	}
+	return anchorErr, true
+}
+
+// parseComparedValues parses the lines that follow an AnchorError log line.
+// Pubkeys are logged on the line after "Left:"/"Right:", while other values
+// are logged on the same line, e.g. "Left: 5".
+func parseComparedValues(logs []string) (left string, right string) {
+	if len(logs) == 0 || !strings.HasPrefix(logs[0], leftLogPrefix) {
+		return "", ""
+	}
+	left, rest := parseComparedValue(logs, leftLogPrefix)
+	if len(rest) == 0 || !strings.HasPrefix(rest[0], rightLogPrefix) {
+		return left, ""
+	}
+	right, _ = parseComparedValue(rest, rightLogPrefix)
+	return left, right
+}
+
+func parseComparedValue(logs []string, prefix string) (string, []string) {
+	value := strings.TrimSpace(strings.TrimPrefix(logs[0], prefix))
+	if value != "" {
+		return value, logs[1:]
+	}
+	if len(logs) < 2 || !strings.HasPrefix(logs[1], programLogPrefix) {
+		return "", logs[1:]
+	}
+	return strings.TrimPrefix(logs[1], programLogPrefix), logs[2:]
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestParseAnchorError(t *testing.T) {
+	programID := "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+	type testCase struct {
+		name string
+		logs []string
+		want *AnchorError
+	}
+
+	cases := []testCase{
+		{
+			name: "caused by account with pubkeys",
+			logs: []string{
+				"Program " + programID + " invoke [1]",
+				"Program log: Instruction: Withdraw",
+				"Program log: AnchorError caused by account: vault. Error Code: ConstraintSeeds. Error Number: 2006. Error Message: A seeds constraint was violated.",
+				"Program log: Left:",
+				"Program log: 11111111111111111111111111111111",
+				"Program log: Right:",
+				"Program log: SysvarRent111111111111111111111111111111111",
+				"Program " + programID + " consumed 5000 of 200000 compute units",
+				"Program " + programID + " failed: custom program error: 0x7d6",
+			},
+			want: &AnchorError{
+				ProgramID: solana.MustPublicKeyFromBase58(programID),
+				Account:   "vault",
+				Code:      "ConstraintSeeds",
+				Number:    2006,
+				Message:   "A seeds constraint was violated",
+				Left:      "11111111111111111111111111111111",
+				Right:     "SysvarRent111111111111111111111111111111111",
+			},
+		},
+		{
+			name: "thrown in source with values",
+			logs: []string{
+				"Program " + programID + " invoke [1]",
+				"Program log: AnchorError thrown in programs/demo/src/lib.rs:42. Error Code: InsufficientFunds. Error Number: 6000. Error Message: Not enough funds.",
+				"Program log: Left: 5",
+				"Program log: Right: 10",
+			},
+			want: &AnchorError{
+				ProgramID: solana.MustPublicKeyFromBase58(programID),
+				File:      "programs/demo/src/lib.rs",
+				Line:      42,
+				Code:      "InsufficientFunds",
+				Number:    6000,
+				Message:   "Not enough funds",
+				Left:      "5",
+				Right:     "10",
+			},
+		},
+		{
+			name: "occurred inside CPI",
+			logs: []string{
+				"Program " + programID + " invoke [1]",
+				"Program 11111111111111111111111111111111 invoke [2]",
+				"Program 11111111111111111111111111111111 success",
+				"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA invoke [2]",
+				"Program log: AnchorError occurred. Error Code: Unauthorized. Error Number: 6001. Error Message: Unauthorized.",
+			},
+			want: &AnchorError{
+				ProgramID: solana.TokenProgramID,
+				Code:      "Unauthorized",
+				Number:    6001,
+				Message:   "Unauthorized",
+			},
+		},
+		{
+			name: "no anchor error",
+			logs: []string{
+				"Program " + programID + " invoke [1]",
+				"Program log: custom program error: 0x1",
+			},
+			want: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := ParseAnchorError(tc.logs)
+			if tc.want == nil {
+				if ok {
+					t.Fatalf("got %#v, want no error", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("got no error, want %#v", tc.want)
+			}
+			if *got != *tc.want {
+				t.Errorf("got %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAnchorErrorUnwrap(t *testing.T) {
+	programErr := errors.New("InsufficientFunds")
+	err := error(&AnchorError{Code: "InsufficientFunds", Number: 6000, ProgramError: programErr})
+	if !errors.Is(err, programErr) {
+		t.Errorf("errors.Is did not reach the program error")
+	}
+	want := "AnchorError: InsufficientFunds (6000): "
+	if got := err.Error(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -1,6 +1,13 @@
 package errors
 
-import "strconv"
+import (
+	"errors"
+	"strconv"
+)
+
+// ErrProgramIDMismatch is returned by the parsers of the generated program clients
+// for the instructions that invoke a different program.
+var ErrProgramIDMismatch = errors.New("program ID mismatch")
 
 type FieldError struct {
 	Field string
@@ -17,9 +24,21 @@ func (e *FieldError) Error() string {
 	if nested, ok := e.Err.(*OptionError); ok {
 		return e.Field + "." + nested.Error()
 	}
+	if nested, ok := e.Err.(*OffsetError); ok {
+		return e.Field + " " + nested.Error()
+	}
 	return e.Field + ": " + e.Err.Error()
 }
 
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Path returns the path from this field down to the root cause.
+func (e *FieldError) Path() Path {
+	return pathOf(e)
+}
+
 // and utility:
 func NewField(field string, err error) error {
 	if err == nil {
@@ -44,9 +63,21 @@ func (e *IndexError) Error() string {
 	if nested, ok := e.Err.(*OptionError); ok {
 		return "[" + strconv.Itoa(e.Index) + "]." + nested.Error()
 	}
+	if nested, ok := e.Err.(*OffsetError); ok {
+		return "[" + strconv.Itoa(e.Index) + "]" + " " + nested.Error()
+	}
 	return "[" + strconv.Itoa(e.Index) + "]: " + e.Err.Error()
 }
 
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
+
+// Path returns the path from this index down to the root cause.
+func (e *IndexError) Path() Path {
+	return pathOf(e)
+}
+
 func NewIndex(idx int, err error) error {
 	if err == nil {
 		return nil
@@ -69,12 +100,61 @@ func (e *OptionError) Error() string {
 	if nested, ok := e.Err.(*OptionError); ok {
 		return "?" + e.Field + "." + nested.Error()
 	}
+	if nested, ok := e.Err.(*OffsetError); ok {
+		return "?" + e.Field + " " + nested.Error()
+	}
 	return "?" + e.Field + ": " + e.Err.Error()
 }
 
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// Path returns the path from this option down to the root cause.
+func (e *OptionError) Path() Path {
+	return pathOf(e)
+}
+
 func NewOption(field string, err error) error {
 	if err == nil {
 		return nil
 	}
 	return &OptionError{Field: field, Err: err}
 }
+
+// OffsetError records the position of the decoder at the point of failure.
+type OffsetError struct {
+	Offset int
+	Err    error
+}
+
+func (e *OffsetError) Error() string {
+	return "at offset " + strconv.Itoa(e.Offset) + ": " + e.Err.Error()
+}
+
+func (e *OffsetError) Unwrap() error {
+	return e.Err
+}
+
+// NewOffset wraps err with the given decoder offset.
+// If err already carries an offset (i.e. it was recorded deeper down,
+// closer to the failure), err is returned unchanged.
+func NewOffset(offset int, err error) error {
+	if err == nil {
+		return nil
+	}
+	var offsetErr *OffsetError
+	if errors.As(err, &offsetErr) {
+		return err
+	}
+	return &OffsetError{Offset: offset, Err: err}
+}
+
+// OffsetOf returns the decoder offset recorded in the chain of err, if any.
+func OffsetOf(err error) (int, bool) {
+	var offsetErr *OffsetError
+	if errors.As(err, &offsetErr) {
+		return offsetErr.Offset, true
+	}
+	return 0, false
+}
@@ -1,7 +1,10 @@
 package errors
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -221,3 +224,161 @@ func TestComplexOptionNesting(t *testing.T) {
 		t.Errorf("got %q, want %q", got, want)
 	}
 }
+
+func TestErrorsIsAs(t *testing.T) {
+	leaf := errors.New("root")
+	err := NewField("foo", NewIndex(1, NewOption("bar", leaf)))
+	if !errors.Is(err, leaf) {
+		t.Errorf("errors.Is did not reach the leaf")
+	}
+	var optErr *OptionError
+	if !errors.As(err, &optErr) || optErr.Field != "bar" {
+		t.Errorf("errors.As did not find the option error, got %#v", optErr)
+	}
+	if got := Cause(err); got != leaf {
+		t.Errorf("Cause: got %#v, want leaf", got)
+	}
+}
+
+func TestPath(t *testing.T) {
+	leaf := errors.New("zero")
+	err := NewField("foo", NewIndex(4, NewOption("bar", NewIndex(7, NewField("baz", leaf)))))
+
+	want := Path{
+		{Kind: SegmentField, Name: "foo"},
+		{Kind: SegmentIndex, Index: 4},
+		{Kind: SegmentOption, Name: "bar"},
+		{Kind: SegmentIndex, Index: 7},
+		{Kind: SegmentField, Name: "baz"},
+	}
+	got := err.(*FieldError).Path()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+	if got.String() != "foo[4].?bar[7].baz" {
+		t.Errorf("got %q", got.String())
+	}
+
+	// PathOf looks through non-path wrappers.
+	wrapped := fmt.Errorf("decode: %w", err)
+	if got := PathOf(wrapped); !reflect.DeepEqual(got, want) {
+		t.Errorf("PathOf: got %#v, want %#v", got, want)
+	}
+	if got := PathOf(leaf); got != nil {
+		t.Errorf("PathOf(leaf): got %#v, want nil", got)
+	}
+}
+
+func TestPathJSON(t *testing.T) {
+	path := NewField("foo", NewIndex(2, NewOption("bar", errors.New("x")))).(*FieldError).Path()
+
+	data, err := json.Marshal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"field":"foo"},{"index":2},{"option":"bar"}]`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var decoded Path
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, path) {
+		t.Errorf("got %#v, want %#v", decoded, path)
+	}
+
+	if err := json.Unmarshal([]byte(`[{"field":"a","index":1}]`), &decoded); err == nil {
+		t.Errorf("expected error for segment with two keys")
+	}
+	if err := json.Unmarshal([]byte(`[{"key":"a"}]`), &decoded); err == nil {
+		t.Errorf("expected error for unknown segment kind")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	if err := Merge(nil, nil); err != nil {
+		t.Fatalf("got %#v, want nil", err)
+	}
+
+	errA := errors.New("must be positive")
+	errB := errors.New("too long")
+	err := Merge(
+		NewField("amount", errA),
+		nil,
+		Merge(NewField("items", NewIndex(1, errB)), NewField("owner", errA)),
+	)
+
+	multi, ok := err.(*MultiError)
+	if !ok {
+		t.Fatalf("got %T, want *MultiError", err)
+	}
+	if len(multi.Errors) != 3 {
+		t.Fatalf("got %d errors, want 3 (nested merges are flattened)", len(multi.Errors))
+	}
+	if want := "amount: must be positive; items[1]: too long; owner: must be positive"; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, errB) {
+		t.Errorf("errors.Is did not reach a merged error")
+	}
+
+	paths := multi.Paths()
+	if got := paths[1].String(); got != "items[1]" {
+		t.Errorf("got %q, want %q", got, "items[1]")
+	}
+
+	data, err := json.Marshal(multi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"path":[{"field":"amount"}],"error":"must be positive"},` +
+		`{"path":[{"field":"items"},{"index":1}],"error":"too long"},` +
+		`{"path":[{"field":"owner"}],"error":"must be positive"}]`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestOffsetError(t *testing.T) {
+	leaf := errors.New("unexpected EOF")
+
+	// The offset recorded closest to the failure wins.
+	inner := NewField("mint", NewOffset(311, leaf))
+	err := NewField("position", NewField("rewards", NewIndex(2, NewOffset(240, inner))))
+
+	want := "position.rewards[2].mint at offset 311: unexpected EOF"
+	if got := err.Error(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if offset, ok := OffsetOf(err); !ok || offset != 311 {
+		t.Errorf("OffsetOf: got %d, %v; want 311, true", offset, ok)
+	}
+	if got := PathOf(err).String(); got != "position.rewards[2].mint" {
+		t.Errorf("PathOf: got %q", got)
+	}
+	if !errors.Is(err, leaf) || Cause(err) != leaf {
+		t.Errorf("did not unwrap to leaf")
+	}
+
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{NewIndex(3, NewOffset(8, leaf)), "[3] at offset 8: unexpected EOF"},
+		{NewOption("note", NewOffset(8, leaf)), "?note at offset 8: unexpected EOF"},
+		{NewOffset(8, leaf), "at offset 8: unexpected EOF"},
+	} {
+		if got := tc.err.Error(); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+
+	if NewOffset(1, nil) != nil {
+		t.Errorf("NewOffset(nil) should return nil")
+	}
+	if _, ok := OffsetOf(leaf); ok {
+		t.Errorf("OffsetOf(leaf) should return false")
+	}
+}
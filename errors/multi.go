@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MultiError collects several errors, e.g. one per invalid field
+// when validating a whole struct.
+type MultiError struct {
+	Errors []error
+}
+
+// Merge merges the given errors into a single *MultiError.
+// Nil errors are dropped, and nested *MultiError values are flattened.
+// It returns nil if there are no errors left.
+func Merge(errs ...error) error {
+	var merged []error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if multi, ok := err.(*MultiError); ok {
+			merged = append(merged, multi.Errors...)
+			continue
+		}
+		merged = append(merged, err)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: merged}
+}
+
+func (e *MultiError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap allows errors.Is and errors.As to inspect each of the merged errors.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// Paths returns the path of each merged error (nil for errors without a path).
+func (e *MultiError) Paths() []Path {
+	paths := make([]Path, len(e.Errors))
+	for i, err := range e.Errors {
+		paths[i] = PathOf(err)
+	}
+	return paths
+}
+
+// MarshalJSON encodes the merged errors as a list of objects
+// with the path and the message of the root cause, e.g.
+//
+//	[{"path":[{"field":"amount"}],"error":"must be positive"}]
+func (e *MultiError) MarshalJSON() ([]byte, error) {
+	type jsonError struct {
+		Path  Path   `json:"path"`
+		Error string `json:"error"`
+	}
+	out := make([]jsonError, len(e.Errors))
+	for i, err := range e.Errors {
+		path := PathOf(err)
+		if path == nil {
+			path = Path{}
+		}
+		out[i] = jsonError{
+			Path:  path,
+			Error: Cause(pathErrorOf(err)).Error(),
+		}
+	}
+	return json.Marshal(out)
+}
+
+// pathErrorOf returns the first path error in the chain of err, or err itself.
+func pathErrorOf(err error) error {
+	for e := err; e != nil; {
+		switch e.(type) {
+		case *FieldError, *IndexError, *OptionError:
+			return e
+		}
+		unwrapper, ok := e.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		e = unwrapper.Unwrap()
+	}
+	return err
+}
@@ -0,0 +1,159 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SegmentKind is the kind of a PathSegment.
+type SegmentKind uint8
+
+const (
+	SegmentField SegmentKind = iota
+	SegmentIndex
+	SegmentOption
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case SegmentField:
+		return "field"
+	case SegmentIndex:
+		return "index"
+	case SegmentOption:
+		return "option"
+	default:
+		return "unknown"
+	}
+}
+
+// PathSegment is one step of the path to the value that failed:
+// a struct field, a slice/array index, or an optional field.
+type PathSegment struct {
+	Kind  SegmentKind
+	Name  string // Set for SegmentField and SegmentOption.
+	Index int    // Set for SegmentIndex.
+}
+
+func (s PathSegment) MarshalJSON() ([]byte, error) {
+	switch s.Kind {
+	case SegmentIndex:
+		return json.Marshal(map[string]int{s.Kind.String(): s.Index})
+	case SegmentField, SegmentOption:
+		return json.Marshal(map[string]string{s.Kind.String(): s.Name})
+	default:
+		return nil, fmt.Errorf("unknown path segment kind: %d", s.Kind)
+	}
+}
+
+func (s *PathSegment) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != 1 {
+		return fmt.Errorf("expected exactly one key in path segment, got %d", len(raw))
+	}
+	for key, value := range raw {
+		switch key {
+		case SegmentField.String():
+			s.Kind = SegmentField
+			return json.Unmarshal(value, &s.Name)
+		case SegmentOption.String():
+			s.Kind = SegmentOption
+			return json.Unmarshal(value, &s.Name)
+		case SegmentIndex.String():
+			s.Kind = SegmentIndex
+			return json.Unmarshal(value, &s.Index)
+		default:
+			return fmt.Errorf("unknown path segment kind: %q", key)
+		}
+	}
+	return nil
+}
+
+// Path is the path to the value that failed, outermost segment first.
+// It marshals to JSON as a list of single-key objects, e.g.
+//
+//	[{"field":"rewards"},{"index":2},{"option":"note"}]
+type Path []PathSegment
+
+// String formats the path the same way the errors of this package do,
+// e.g. "rewards[2].?note".
+func (p Path) String() string {
+	var b strings.Builder
+	for i, segment := range p {
+		switch segment.Kind {
+		case SegmentField:
+			if i > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(segment.Name)
+		case SegmentIndex:
+			b.WriteString("[" + strconv.Itoa(segment.Index) + "]")
+		case SegmentOption:
+			if i > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString("?" + segment.Name)
+		}
+	}
+	return b.String()
+}
+
+// PathOf returns the path carried by the first FieldError, IndexError or
+// OptionError in the chain of err, or nil if there is none.
+func PathOf(err error) Path {
+	for err != nil {
+		switch err.(type) {
+		case *FieldError, *IndexError, *OptionError:
+			return pathOf(err)
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
+func pathOf(err error) Path {
+	var path Path
+	for {
+		switch e := err.(type) {
+		case *FieldError:
+			path = append(path, PathSegment{Kind: SegmentField, Name: e.Field})
+			err = e.Err
+		case *IndexError:
+			path = append(path, PathSegment{Kind: SegmentIndex, Index: e.Index})
+			err = e.Err
+		case *OptionError:
+			path = append(path, PathSegment{Kind: SegmentOption, Name: e.Field})
+			err = e.Err
+		case *OffsetError:
+			err = e.Err
+		default:
+			return path
+		}
+	}
+}
+
+// Cause returns the root cause of a path error, i.e. the error
+// wrapped by the innermost FieldError, IndexError or OptionError
+// (and by the OffsetError, if any).
+func Cause(err error) error {
+	for {
+		switch e := err.(type) {
+		case *FieldError:
+			err = e.Err
+		case *IndexError:
+			err = e.Err
+		case *OptionError:
+			err = e.Err
+		case *OffsetError:
+			err = e.Err
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func cmdFetchIdl(args []string) error {
+	fs := newFlagSet("fetch-idl")
+	var programID solana.PublicKey
+	fs.Var(&programID, "program", "ID of the program whose IDL to fetch (required)")
+	var rpcEndpoint string
+	fs.StringVar(&rpcEndpoint, "rpc", rpc.MainNetBeta_RPC, "RPC endpoint of the cluster")
+	var output string
+	fs.StringVar(&output, "output", "", "Path to write the IDL to (optional; defaults to stdout)")
+	var timeout time.Duration
+	fs.DurationVar(&timeout, "timeout", time.Minute, "Timeout of the RPC request")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if programID.IsZero() {
+		return errors.New("please provide the program ID using the -program flag")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	onChainIdl, err := idl.FetchOnChainIdl(ctx, rpc.New(rpcEndpoint), programID)
+	if err != nil {
+		return err
+	}
+	// Check that the IDL parses (legacy IDLs are written as they are; see the convert command).
+	if _, err := idl.Parse(onChainIdl.Data); err != nil {
+		return fmt.Errorf("failed to parse the IDL of program %s: %w", programID, err)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(onChainIdl.Data)
+		return err
+	}
+	return os.WriteFile(output, onChainIdl.Data, 0o644)
+}
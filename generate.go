@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gagliardetto/anchor-go/generator"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/tools"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"golang.org/x/mod/modfile"
+)
+
+// programOptions are the options of the generation of the client of one program.
+type programOptions struct {
+	idlPath             string
+	outputDir           string
+	programName         string
+	modPath             string
+	programID           solana.PublicKey
+	skipGoMod           bool
+	genericOptions      bool
+	pathsToPreviousIdls []string
+	renames             generator.Renames
+	filter              generator.Filter
+	deployments         idl.Option[idl.IdlDeployments] // If some, replaces the metadata.deployments of the IDL.
+	anchorGoReplace     string                         // If not empty, the directory of anchor-go to use instead of the released one.
+}
+
+func cmdGenerate(args []string) error {
+	fs := newFlagSet("generate")
+	var idlFlags idlFlags
+	idlFlags.register(fs)
+	var configPath string
+	fs.StringVar(&configPath, "config", "", "Path to a config file (anchor-go.yaml or anchor-go.json) listing the programs to generate; replaces the other flags")
+	var opts programOptions
+	fs.Var(&opts.programID, "program-id", "Program ID to use in the generated code (optional; must be a valid Solana public key). If not provided, it will be derived from the IDL metadata.address field if available.")
+	fs.StringVar(&opts.outputDir, "output", "", "Directory to write the generated code to")
+	fs.StringVar(&opts.programName, "name", defaultProgramName, "Name of the program for the generated code (optional; example: myprogram). If not provided, it will be derived from the IDL metadata.name field.")
+	fs.StringVar(&opts.modPath, "mod-path", "", "Module path for the generated code (optional; example: github.com/gagliardetto/mysolana-program-go)")
+	fs.BoolVar(&opts.skipGoMod, "no-go-mod", false, "Skip generating the go.mod file (useful for testing)")
+	fs.BoolVar(&opts.genericOptions, "generic-options", false, "Generate options as option.Option[T] (from github.com/gagliardetto/anchor-go/option) instead of pointers")
+	fs.StringVar(&opts.anchorGoReplace, "anchor-go-replace", "", "Directory of a checkout of anchor-go that replaces the released anchor-go in the generated go.mod (optional; useful to develop anchor-go)")
+	var workspaceDir, cluster string
+	fs.StringVar(&workspaceDir, "workspace", "", "Path to an Anchor workspace (the directory of Anchor.toml) whose programs to generate from their IDLs in target/idl, each in a subdirectory of -output, with a module path under -mod-path; replaces -idl, -name, -program-id and -previous-idl")
+	fs.StringVar(&cluster, "cluster", "", "With -workspace, the cluster (e.g. devnet) of Anchor.toml whose program IDs are the default ones (optional; by default, the IDL address)")
+	var pathsToPreviousIdls stringSliceFlag
+	fs.Var(&pathsToPreviousIdls, "previous-idl", "Path to a previous version of the IDL file, whose account layouts are parsed by ParseAnyAccountVersioned (optional; can be repeated, oldest version first)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if !hasCommand("go") {
+		return errors.New("please install Go (https://go.dev/doc/install) and ensure it is in your PATH; " +
+			"Go is required to format the generated code, and tidy up the go.mod/go.sum files correctly")
+	}
+	setFlags := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+	if configPath != "" {
+		if err := checkFlagsCombination(setFlags, "config"); err != nil {
+			return err
+		}
+		return generateFromConfig(configPath)
+	}
+	if workspaceDir != "" {
+		if err := checkFlagsCombination(setFlags, "workspace", "cluster", "output", "mod-path", "no-go-mod", "generic-options", "anchor-go-replace"); err != nil {
+			return err
+		}
+		return generateFromWorkspace(workspaceDir, cluster, opts)
+	}
+	if cluster != "" {
+		return errors.New("the -cluster flag requires the -workspace flag")
+	}
+	opts.idlPath = idlFlags.path
+	opts.pathsToPreviousIdls = pathsToPreviousIdls
+	return generateProgram(opts)
+}
+
+// checkFlagsCombination fails if a flag other than the allowed ones is set.
+func checkFlagsCombination(setFlags map[string]bool, flag string, allowed ...string) error {
+	var otherFlags []string
+	for name := range setFlags {
+		if name != flag && !slices.Contains(allowed, name) {
+			otherFlags = append(otherFlags, "-"+name)
+		}
+	}
+	if len(otherFlags) == 0 {
+		return nil
+	}
+	slices.Sort(otherFlags)
+	return fmt.Errorf("the -%s flag can't be combined with %s", flag, strings.Join(otherFlags, ", "))
+}
+
+// generateProgram generates the client of a program.
+func generateProgram(opts programOptions) error {
+	if opts.outputDir == "" {
+		return errors.New("please provide the output directory using the -output flag")
+	}
+	outputDir := opts.outputDir
+	programName := opts.programName
+	modPath := opts.modPath
+	programIDOverride := opts.programID
+
+	if modPath == "" {
+		modPath = path.Join("github.com", "gagliardetto", "anchor-go", "generated")
+		slog.Info("Using default module path", "modPath", modPath)
+	} else {
+		slog.Info("Using provided module path", "modPath", modPath)
+	}
+	if err := os.MkdirAll(outputDir, 0o777); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	slog.Info("Starting code generation",
+		"outputDir", outputDir,
+		"modPath", modPath,
+		"pathToIdl", opts.idlPath,
+		"programID", func() string {
+			if programIDOverride.IsZero() {
+				return "not provided"
+			}
+			return programIDOverride.String()
+		}(),
+	)
+
+	options := generator.GeneratorOptions{
+		OutputDir:   outputDir,
+		Package:     programName,
+		ProgramName: programName,
+		ModPath:     modPath,
+		SkipGoMod:   opts.skipGoMod,
+
+		GenericOptions: opts.genericOptions,
+		Renames:        opts.renames,
+		Filter:         opts.filter,
+	}
+	if opts.anchorGoReplace != "" {
+		replaceDir, err := anchorGoReplacePath(outputDir, opts.anchorGoReplace)
+		if err != nil {
+			return err
+		}
+		options.AnchorGoReplace = replaceDir
+		slog.Info("Replacing anchor-go in go.mod", "dir", replaceDir)
+	}
+	for _, pathToPreviousIdl := range opts.pathsToPreviousIdls {
+		previousIdl, err := idl.ParseFromFilepath(pathToPreviousIdl)
+		if err != nil {
+			return fmt.Errorf("failed to parse previous IDL %s: %w", pathToPreviousIdl, err)
+		}
+		slog.Info("Parsed previous IDL successfully", "pathToIdl", pathToPreviousIdl, "version", previousIdl.Metadata.Version)
+		options.PreviousIdls = append(options.PreviousIdls, previousIdl)
+	}
+	if !programIDOverride.IsZero() {
+		options.ProgramId = &programIDOverride
+		slog.Info("Using provided program ID", "programID", programIDOverride.String())
+	}
+	idlFlags := idlFlags{path: opts.idlPath}
+	parsedIdl, err := idlFlags.load()
+	if err != nil {
+		return err
+	}
+	if opts.deployments.IsSome() {
+		parsedIdl.Metadata.Deployments = opts.deployments
+	}
+	if err := parsedIdl.Validate(); err != nil {
+		return fmt.Errorf("invalid IDL: %w", err)
+	}
+	{
+		{
+			if parsedIdl.Address != nil && !parsedIdl.Address.IsZero() && options.ProgramId == nil {
+				// If the IDL has an address, use it as the program ID:
+				slog.Info("Using IDL address as program ID", "address", parsedIdl.Address.String())
+				options.ProgramId = parsedIdl.Address
+			}
+		}
+		parsedIdl.Metadata.Name = bin.ToSnakeForSighash(parsedIdl.Metadata.Name)
+		{
+			// check that the name is not a reserved keyword:
+			if parsedIdl.Metadata.Name != "" {
+				if tools.IsReservedKeyword(parsedIdl.Metadata.Name) {
+					slog.Warn("The IDL metadata.name is a reserved Go keyword: adding a suffix to avoid conflicts.",
+						"name", parsedIdl.Metadata.Name,
+						"reservedKeyword", token.Lookup(parsedIdl.Metadata.Name).String(),
+					)
+					// Add a suffix to the name to avoid conflicts with Go reserved keywords:
+					parsedIdl.Metadata.Name += "_program"
+				}
+				if !tools.IsValidIdent(parsedIdl.Metadata.Name) {
+					// add a prefix to the name to avoid conflicts with Go reserved keywords:
+					parsedIdl.Metadata.Name = "my_" + parsedIdl.Metadata.Name
+				}
+			}
+			// if begins with
+		}
+		if programName == "" && parsedIdl.Metadata.Name != "" {
+			return errors.New("please provide a package name using the -name flag, or ensure the IDL has a valid metadata.name field")
+		}
+		if programName == defaultProgramName && parsedIdl.Metadata.Name != "" {
+			cleanedName := bin.ToSnakeForSighash(parsedIdl.Metadata.Name)
+			options.Package = cleanedName
+			options.ProgramName = cleanedName
+			slog.Info("Using IDL metadata.name as package name", "packageName", cleanedName)
+		}
+
+		slog.Info("Parsed IDL successfully",
+			"version", parsedIdl.Metadata.Version,
+			"name", parsedIdl.Metadata.Name,
+			"address", parsedIdl.Address,
+			"programId", func() string {
+				if parsedIdl.Address.IsZero() {
+					return "not provided"
+				}
+				return parsedIdl.Address.String()
+			}(),
+			"instructionsCount", len(parsedIdl.Instructions),
+			"accountsCount", len(parsedIdl.Accounts),
+			"eventsCount", len(parsedIdl.Events),
+			"typesCount", len(parsedIdl.Types),
+			"constantsCount", len(parsedIdl.Constants),
+			"errorsCount", len(parsedIdl.Errors),
+		)
+	}
+	gen := generator.NewGenerator(parsedIdl, &options)
+	generatedFiles, err := gen.Generate()
+	if err != nil {
+		return err
+	}
+
+	if !opts.skipGoMod {
+		goModFilepath := path.Join(options.OutputDir, "go.mod")
+		slog.Info("Writing go.mod file",
+			"filepath", goModFilepath,
+			"modPath", options.ModPath,
+		)
+
+		err = os.WriteFile(goModFilepath, []byte(generatedFiles.GoMod), 0o777)
+		if err != nil {
+			return err
+		}
+	}
+	{
+		for _, file := range generatedFiles.Files {
+			// Save assets:
+			assetFilename := file.Name
+			assetFilepath := path.Join(options.OutputDir, assetFilename)
+
+			slog.Info("Writing file",
+				"filepath", assetFilepath,
+				"name", file.Name,
+				"modPath", options.ModPath,
+			)
+			if err := file.File.Save(assetFilepath); err != nil {
+				return fmt.Errorf("failed to write %s: %w", assetFilepath, err)
+			}
+		}
+		if err := executeCmd(outputDir, "go", "mod", "tidy"); err != nil {
+			return err
+		}
+		if err := executeCmd(outputDir, "go", "fmt"); err != nil {
+			return err
+		}
+		if err := executeCmd(outputDir, "go", "build", "-o", "/dev/null"); err != nil { // Just to ensure everything compiles.
+			return err
+		}
+		slog.Info("Generation completed successfully",
+			"outputDir", options.OutputDir,
+			"modPath", options.ModPath,
+			"package", options.Package,
+			"programName", options.ProgramName,
+		)
+	}
+	return nil
+}
+
+// anchorGoReplacePath checks that dir is a checkout of anchor-go, and returns its path
+// as written in the replace directive of the go.mod in outputDir: relative to outputDir
+// when possible, so that the go.mod doesn't depend on where the checkout is.
+func anchorGoReplacePath(outputDir string, dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("the -anchor-go-replace directory is not a checkout of anchor-go: %w", err)
+	}
+	if modPath := modfile.ModulePath(data); modPath != "github.com/gagliardetto/anchor-go" {
+		return "", fmt.Errorf("the -anchor-go-replace directory %s is the module %q, not anchor-go", dir, modPath)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absOutputDir, absDir)
+	if err != nil {
+		return absDir, nil // E.g. on another volume.
+	}
+	rel = filepath.ToSlash(rel)
+	if rel != ".." && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel // A replace directive takes paths that don't start with ./ or ../ as module paths.
+	}
+	return rel, nil
+}
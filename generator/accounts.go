@@ -42,6 +42,17 @@ func (g *Generator) gen_accountParser(accountNames []string) (Code, error) {
 		code.Func().Id("ParseAnyAccount").
 			Params(Id("accountData").Index().Byte()).
 			Params(Any(), Error()).
+			BlockFunc(func(block *Group) {
+				block.List(Id("value"), Id("_"), Err()).Op(":=").Id("ParseAnyAccountWithOptions").Call(Id("accountData"), Qual(PkgAnchorGoDecode, "Options").Values())
+				block.Return(Id("value"), Err())
+			})
+		code.Line().Line()
+		code.Comment("ParseAnyAccountWithOptions parses any account of the program with the given options,")
+		code.Line().Comment("and returns the number of bytes of accountData consumed (including the discriminator).")
+		code.Line()
+		code.Func().Id("ParseAnyAccountWithOptions").
+			Params(Id("accountData").Index().Byte(), Id("options").Qual(PkgAnchorGoDecode, "Options")).
+			Params(Any(), Int(), Error()).
 			BlockFunc(func(block *Group) {
 				block.Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("accountData"))
 				block.List(Id("discriminator"), Err()).Op(":=").Id("decoder").Dot("ReadDiscriminator").Call()
@@ -49,6 +60,7 @@ func (g *Generator) gen_accountParser(accountNames []string) (Code, error) {
 				block.If(Err().Op("!=").Nil()).Block(
 					Return(
 						Nil(),
+						Lit(0),
 						Qual("fmt", "Errorf").Call(Lit("failed to peek account discriminator: %w"), Err()),
 					),
 				)
@@ -56,19 +68,15 @@ func (g *Generator) gen_accountParser(accountNames []string) (Code, error) {
 				block.Switch(Id("discriminator")).BlockFunc(func(switchBlock *Group) {
 					for _, name := range accountNames {
 						switchBlock.Case(Id(FormatAccountDiscriminatorName(name))).Block(
-							Id("value").Op(":=").New(Id(name)),
-							Err().Op(":=").Id("value").Dot("UnmarshalWithDecoder").Call(Id("decoder")),
+							List(Id("value"), Id("consumed"), Err()).Op(":=").Id("ParseAccount_"+name+"WithOptions").Call(Id("accountData"), Id("options")),
 							If(Err().Op("!=").Nil()).Block(
-								Return(
-									Nil(),
-									Qual("fmt", "Errorf").Call(Lit("failed to unmarshal account as "+name+": %w"), Err()),
-								),
+								Return(Nil(), Lit(0), Err()),
 							),
-							Return(Id("value"), Nil()),
+							Return(Id("value"), Id("consumed"), Nil()),
 						)
 					}
 					switchBlock.Default().Block(
-						Return(Nil(), Qual("fmt", "Errorf").Call(Lit("unknown discriminator: %s"), Qual(PkgBinary, "FormatDiscriminator").Call(Id("discriminator")))),
+						Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("unknown discriminator: %s"), Qual(PkgBinary, "FormatDiscriminator").Call(Id("discriminator")))),
 					)
 				})
 			})
@@ -83,31 +91,59 @@ func (g *Generator) gen_accountParser(accountNames []string) (Code, error) {
 				Params(Id("accountData").Index().Byte()).
 				Params(Op("*").Id(name), Error()).
 				BlockFunc(func(block *Group) {
-					block.Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("accountData"))
+					block.List(Id("acc"), Id("_"), Err()).Op(":=").Id("ParseAccount_"+name+"WithOptions").Call(Id("accountData"), Qual(PkgAnchorGoDecode, "Options").Values())
+					block.Return(Id("acc"), Err())
+				})
+			code.Line().Line()
+
+			code.Commentf("ParseAccount_%sWithOptions parses a %s account with the given options,", name, name)
+			code.Line().Comment("and returns the number of bytes of accountData consumed (including the discriminator).")
+			code.Line()
+			code.Func().Id("ParseAccount_"+name+"WithOptions").
+				Params(Id("accountData").Index().Byte(), Id("options").Qual(PkgAnchorGoDecode, "Options")).
+				Params(Op("*").Id(name), Int(), Error()).
+				BlockFunc(func(block *Group) {
+					// In lenient mode, the missing trailing fields are zero-filled:
+					block.Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(
+						Id("options").Dot("Input").Call(Id("accountData"), Lit(zeroEncodedSize(&idltype.Defined{Name: name}))),
+					)
 					block.List(Id("discriminator"), Err()).Op(":=").Id("decoder").Dot("ReadDiscriminator").Call()
 
 					block.If(Err().Op("!=").Nil()).Block(
 						Return(
 							Nil(),
+							Lit(0),
 							Qual("fmt", "Errorf").Call(Lit("failed to peek discriminator: %w"), Err()),
 						),
 					)
 
 					block.If(Id("discriminator").Op("!=").Id(discriminatorName)).Block(
-						Return(Nil(), Qual("fmt", "Errorf").Call(Lit("expected discriminator %v, got %s"), Id(discriminatorName), Qual(PkgBinary, "FormatDiscriminator").Call(Id("discriminator")))),
+						Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("expected discriminator %v, got %s"), Id(discriminatorName), Qual(PkgBinary, "FormatDiscriminator").Call(Id("discriminator")))),
 					)
 
 					block.Id("acc").Op(":=").New(Id(name))
-					block.Err().Op("=").Id("acc").Dot("UnmarshalWithDecoder").Call(Id("decoder"))
+					block.Err().Op("=").Id("acc").Dot("UnmarshalWithLimits").Call(
+						Id("decoder"),
+						Qual(PkgAnchorGoLimits, "NewBudget").Call(Id("options").Dot("Limits")),
+					)
 
 					block.If(Err().Op("!=").Nil()).Block(
 						Return(
 							Nil(),
+							Lit(0),
 							Qual("fmt", "Errorf").Call(Lit("failed to unmarshal account of type "+name+": %w"), Err()),
 						),
 					)
 
-					block.Return(Id("acc"), Nil())
+					block.List(Id("consumed"), Err()).Op(":=").Id("options").Dot("Consumed").Call(Id("decoder"), Len(Id("accountData")))
+					block.If(Err().Op("!=").Nil()).Block(
+						Return(
+							Nil(),
+							Lit(0),
+							Qual("fmt", "Errorf").Call(Lit("failed to unmarshal account of type "+name+": %w"), Err()),
+						),
+					)
+					block.Return(Id("acc"), Id("consumed"), Nil())
 				})
 			code.Line().Line()
 		}
@@ -144,17 +180,17 @@ func (g *Generator) gen_IDLTypeDefTyStruct(
 						fieldsGroup.Comment(doc)
 					}
 					// fieldsGroup.Line()
-					optionality := IsOption(field.Ty) || IsCOption(field.Ty)
+					optionality := isPointerOption(field.Ty)
 
 					// TODO: optionality for complex enums is a nil interface.
 					uniqueFieldName := uniqueFieldNames[field.Name]
 					fieldsGroup.Add(genFieldWithName(field, uniqueFieldName, optionality)).
 						Add(func() Code {
 							tagMap := map[string]string{}
-							if IsOption(field.Ty) {
+							if optionality && IsOption(field.Ty) {
 								tagMap["bin"] = "optional"
 							}
-							if IsCOption(field.Ty) {
+							if optionality && IsCOption(field.Ty) {
 								tagMap["bin"] = "coption"
 							}
 							// add json tag: use original field name to avoid duplicates
@@ -172,7 +208,7 @@ func (g *Generator) gen_IDLTypeDefTyStruct(
 				for fieldIndex, field := range fields {
 
 					fieldsGroup.Line()
-					optionality := IsOption(field) || IsCOption(field)
+					optionality := isPointerOption(field)
 
 					fieldsGroup.Add(genFieldNamed(
 						FormatTupleItemName(fieldIndex),
@@ -181,10 +217,10 @@ func (g *Generator) gen_IDLTypeDefTyStruct(
 					)).
 						Add(func() Code {
 							tagMap := map[string]string{}
-							if IsOption(field) {
+							if optionality && IsOption(field) {
 								tagMap["bin"] = "optional"
 							}
-							if IsCOption(field) {
+							if optionality && IsCOption(field) {
 								tagMap["bin"] = "coption"
 							}
 							// add json tag:
@@ -235,6 +271,28 @@ func (g *Generator) gen_IDLTypeDefTyStruct(
 					discriminatorName,
 					typ.Fields,
 				))
+
+			var goFields []structField
+			switch fields := typ.Fields.(type) {
+			case idl.IdlDefinedFieldsNamed:
+				uniqueFieldNames := generateUniqueFieldNames(fields)
+				goFields = namedStructFields(
+					fields,
+					func(field idl.IdlField) string {
+						return uniqueFieldNames[field.Name]
+					},
+					func(field idl.IdlField) string {
+						return field.Name
+					},
+				)
+			case idl.IdlDefinedFieldsTuple:
+				goFields = namedStructFields(fields, formatFieldGoName, formatFieldJSONName)
+			}
+			// Declare MarshalJSON/UnmarshalJSON, if the struct contains complex enums:
+			code.Add(gen_complexEnumJSON_struct(exportedAccountName, goFields))
+
+			// Declare Equal and Clone:
+			code.Add(gen_EqualClone_struct(exportedAccountName, goFields))
 		}
 		st.Add(code.Line().Line())
 	}
@@ -302,12 +360,20 @@ func genTypeName(idlTypeEnv idltype.IdlType) Code {
 	case IsOption(idlTypeEnv):
 		{
 			opt := idlTypeEnv.(*idltype.Option)
-			// TODO: optional = pointer? or that's determined upstream?
+			if genericOptions {
+				st.Add(genOptionTypeName(opt))
+				break
+			}
+			// The pointer (if any) is added upstream (see genValueTypeName).
 			st.Add(genTypeName(opt.Option))
 		}
 	case IsCOption(idlTypeEnv):
 		{
 			copt := idlTypeEnv.(*idltype.COption)
+			if genericOptions {
+				st.Add(genOptionTypeName(copt))
+				break
+			}
 			st.Add(genTypeName(copt.COption))
 		}
 	case IsVec(idlTypeEnv):
@@ -371,6 +437,10 @@ func IDLTypeKind_ToTypeDeclCode(ts idltype.IdlType) *Statement {
 		stat.Qual(PkgBinary, "Uint128")
 	case *idltype.I128:
 		stat.Qual(PkgBinary, "Int128")
+	case *idltype.U256:
+		stat.Qual(PkgAnchorGoNumeric, "Uint256")
+	case *idltype.I256:
+		stat.Qual(PkgAnchorGoNumeric, "Int256")
 	case *idltype.Bytes:
 		stat.Index().Byte()
 	case *idltype.String:
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenAccountParser(t *testing.T) {
+	idlData := &idl.Idl{
+		Accounts: []idl.IdlAccount{{Name: "PsVault"}},
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "PsVault",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "owner", Ty: &idltype.Pubkey{}},
+						{Name: "amounts", Ty: &idltype.Vec{Vec: &idltype.U64{}}},
+					},
+				},
+			},
+		},
+	}
+	registerMinEncodedSizes(idlData)
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file, err := gen.genfile_accounts()
+	require.NoError(t, err)
+	generatedCode := file.File.GoString()
+
+	for _, expected := range []string{
+		"value, _, err := ParseAnyAccountWithOptions(accountData, decode.Options{})",
+		"func ParseAnyAccountWithOptions(accountData []byte, options decode.Options) (any, int, error) {",
+		"value, consumed, err := ParseAccount_PsVaultWithOptions(accountData, options)",
+		"acc, _, err := ParseAccount_PsVaultWithOptions(accountData, decode.Options{})",
+		"func ParseAccount_PsVaultWithOptions(accountData []byte, options decode.Options) (*PsVault, int, error) {",
+		// The zero-filled bytes: the owner and the length of the amounts.
+		"decoder := binary.NewBorshDecoder(options.Input(accountData, 36))",
+		"consumed, err := options.Consumed(decoder, len(accountData))",
+		"return acc, consumed, nil",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+}
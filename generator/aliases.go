@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"fmt"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// gen_IDLTypeDefTyType generates a type alias (`{"kind": "type", "alias": ...}`).
+//
+// Most aliases become a Go named type with its own MarshalWithEncoder/UnmarshalWithLimits.
+// Aliases of options and of complex enums become Go aliases instead, because their
+// Go representation (a pointer, an interface) can't have methods; references to them
+// are inlined before generation (see inlineAliases).
+func (g *Generator) gen_IDLTypeDefTyType(name string, docs []string, typ idl.IdlTypeDefTyType) (Code, error) {
+	st := newStatement()
+	typeName := tools.ToCamelUpper(name)
+
+	code := Empty()
+	addComments(code, docs)
+	if isInlinedAlias(typ.Alias) {
+		code.Type().Id(typeName).Op("=").Add(genValueTypeName(typ.Alias))
+		st.Add(code.Line().Line())
+		return st, nil
+	}
+
+	code.Type().Id(typeName).Add(genValueTypeName(typ.Alias))
+	st.Add(code.Line())
+
+	// The value is converted to (and from) the aliased type, and then encoded
+	// like a struct field named "Value".
+	valueField := idl.IdlField{
+		Name: "Value",
+		Ty:   typ.Alias,
+	}
+	valueAccessor := func(field idl.IdlField) *Statement {
+		return Id("value")
+	}
+	{
+		code := Empty()
+		code.Line().Line()
+		code.Func().Params(Id("obj").Id(typeName)).Id("MarshalWithEncoder").
+			Params(Id("encoder").Op("*").Qual(PkgBinary, "Encoder")).
+			Params(Err().Error()).
+			BlockFunc(func(body *Group) {
+				body.Id("value").Op(":=").Add(genValueTypeName(typ.Alias)).Parens(Id("obj"))
+				gen_marshal_DefinedFieldsNamed(
+					body,
+					idl.IdlDefinedFieldsNamed{valueField},
+					true,
+					valueAccessor,
+					"encoder",
+					false, // returnNilErr
+					func(field idl.IdlField) string {
+						return field.Name
+					},
+				)
+				body.Return(Nil())
+			})
+
+		code.Line().Line()
+		code.Add(gen_UnmarshalWithDecoder("obj", typeName))
+		code.Line().Line()
+		code.Func().Params(Id("obj").Op("*").Id(typeName)).Id("UnmarshalWithLimits").
+			Params(ListFunc(decoderParams)).
+			Params(Err().Error()).
+			BlockFunc(func(body *Group) {
+				body.Var().Id("value").Add(genValueTypeName(typ.Alias))
+				gen_unmarshal_DefinedFieldsNamed(
+					body,
+					idl.IdlDefinedFieldsNamed{valueField},
+					valueAccessor,
+				)
+				body.Op("*").Id("obj").Op("=").Id(typeName).Call(Id("value"))
+				body.Return(Nil())
+			})
+		if containsComplexEnum(typ.Alias) {
+			code.Add(gen_complexEnumJSON_alias(typeName, typ.Alias))
+		}
+		code.Add(gen_EqualClone_alias(typeName, typ.Alias))
+		st.Add(code.Line().Line())
+	}
+	return st, nil
+}
+
+// isInlinedAlias tells whether references to an alias of the given type
+// are replaced with the aliased type itself.
+func isInlinedAlias(alias idltype.IdlType) bool {
+	return IsOption(alias) || IsCOption(alias) || isComplexEnum(alias)
+}
+
+// inlineAliases replaces all the references to the aliases of options and
+// complex enums with the aliased type, so that they are encoded/decoded
+// like options and complex enums.
+// Complex enums must be registered before calling this.
+func inlineAliases(idlObj *idl.Idl) {
+	aliases := make(map[string]idltype.IdlType)
+	for _, def := range idlObj.Types {
+		if alias, ok := def.Ty.(*idl.IdlTypeDefTyType); ok {
+			aliases[def.Name] = alias.Alias
+		}
+	}
+	if len(aliases) == 0 {
+		return
+	}
+	var resolve func(ty idltype.IdlType, depth int) idltype.IdlType
+	resolve = func(ty idltype.IdlType, depth int) idltype.IdlType {
+		return mapIdlType(ty, func(ty idltype.IdlType) idltype.IdlType {
+			defined, ok := ty.(*idltype.Defined)
+			if !ok {
+				return ty
+			}
+			target, ok := aliases[defined.Name]
+			if !ok || depth > len(aliases) {
+				return ty
+			}
+			// Resolve aliases of aliases first.
+			target = resolve(target, depth+1)
+			if isInlinedAlias(target) {
+				return target
+			}
+			return ty
+		})
+	}
+	mapIdlTypes(idlObj, func(ty idltype.IdlType) idltype.IdlType {
+		return resolve(ty, 0)
+	})
+}
+
+// mapIdlTypes replaces (in place) every type referenced by the types
+// and instructions of the IDL with the result of mapIdlType(ty, fn).
+func mapIdlTypes(idlObj *idl.Idl, fn func(idltype.IdlType) idltype.IdlType) {
+	mapFields := func(fields idl.IdlDefinedFields) idl.IdlDefinedFields {
+		switch fields := fields.(type) {
+		case idl.IdlDefinedFieldsNamed:
+			mapped := make(idl.IdlDefinedFieldsNamed, len(fields))
+			for i, field := range fields {
+				field.Ty = mapIdlType(field.Ty, fn)
+				mapped[i] = field
+			}
+			return mapped
+		case idl.IdlDefinedFieldsTuple:
+			mapped := make(idl.IdlDefinedFieldsTuple, len(fields))
+			for i, ty := range fields {
+				mapped[i] = mapIdlType(ty, fn)
+			}
+			return mapped
+		case nil:
+			return nil
+		default:
+			panic(fmt.Errorf("unknown fields type: %T", fields))
+		}
+	}
+	for i, def := range idlObj.Types {
+		switch ty := def.Ty.(type) {
+		case *idl.IdlTypeDefTyStruct:
+			idlObj.Types[i].Ty = &idl.IdlTypeDefTyStruct{
+				Kind:   ty.Kind,
+				Fields: mapFields(ty.Fields),
+			}
+		case *idl.IdlTypeDefTyEnum:
+			variants := make(idl.VariantSlice, len(ty.Variants))
+			for vi, variant := range ty.Variants {
+				if variant.Fields.IsSome() {
+					variant.Fields = idl.Some(mapFields(variant.Fields.Unwrap()))
+				}
+				variants[vi] = variant
+			}
+			idlObj.Types[i].Ty = &idl.IdlTypeDefTyEnum{
+				Kind:     ty.Kind,
+				Variants: variants,
+			}
+		case *idl.IdlTypeDefTyType:
+			idlObj.Types[i].Ty = &idl.IdlTypeDefTyType{
+				Kind:  ty.Kind,
+				Alias: mapIdlType(ty.Alias, fn),
+			}
+		}
+	}
+	for i, ins := range idlObj.Instructions {
+		args := make([]idl.IdlField, len(ins.Args))
+		for ai, arg := range ins.Args {
+			arg.Ty = mapIdlType(arg.Ty, fn)
+			args[ai] = arg
+		}
+		idlObj.Instructions[i].Args = args
+		if ins.Returns.IsSome() {
+			idlObj.Instructions[i].Returns = idl.Some(mapIdlType(ins.Returns.Unwrap(), fn))
+		}
+	}
+	for i, co := range idlObj.Constants {
+		idlObj.Constants[i].Ty = mapIdlType(co.Ty, fn)
+	}
+}
+
+// mapIdlType returns a copy of ty where each (nested) type has been
+// replaced with fn(type), from the innermost to the outermost.
+func mapIdlType(ty idltype.IdlType, fn func(idltype.IdlType) idltype.IdlType) idltype.IdlType {
+	switch vv := ty.(type) {
+	case *idltype.Option:
+		return fn(&idltype.Option{Option: mapIdlType(vv.Option, fn)})
+	case *idltype.COption:
+		return fn(&idltype.COption{COption: mapIdlType(vv.COption, fn)})
+	case *idltype.Vec:
+		return fn(&idltype.Vec{Vec: mapIdlType(vv.Vec, fn)})
+	case *idltype.Array:
+		return fn(&idltype.Array{Type: mapIdlType(vv.Type, fn), Size: vv.Size})
+	case *idltype.Defined:
+		generics := make([]idltype.IdlGenericArg, len(vv.Generics))
+		for i, arg := range vv.Generics {
+			if typeArg, ok := arg.(*idltype.IdlGenericArgType); ok {
+				arg = &idltype.IdlGenericArgType{Kind: typeArg.Kind, Ty: mapIdlType(typeArg.Ty, fn)}
+			}
+			generics[i] = arg
+		}
+		if len(vv.Generics) == 0 {
+			generics = vv.Generics
+		}
+		return fn(&idltype.Defined{Name: vv.Name, Generics: generics})
+	default:
+		return fn(ty)
+	}
+}
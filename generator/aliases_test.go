@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"testing"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenTypeAliases(t *testing.T) {
+	defined := func(name string) *idltype.Defined {
+		return &idltype.Defined{Name: name}
+	}
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "AliasTestShape",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind: "enum",
+					Variants: idl.VariantSlice{
+						{Name: "Empty"},
+						{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+					},
+				},
+			},
+			{Name: "Amount", Docs: []string{"Amount in lamports."}, Ty: &idl.IdlTypeDefTyType{Alias: &idltype.U64{}}},
+			{Name: "MaybeKey", Ty: &idl.IdlTypeDefTyType{Alias: &idltype.Option{Option: &idltype.Pubkey{}}}},
+			{Name: "ShapeAlias", Ty: &idl.IdlTypeDefTyType{Alias: defined("AliasTestShape")}},
+			{Name: "ShapeAliasAlias", Ty: &idl.IdlTypeDefTyType{Alias: defined("ShapeAlias")}},
+			{
+				Name: "Holder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "amount", Ty: defined("Amount")},
+						{Name: "maybe_key", Ty: defined("MaybeKey")},
+						{Name: "shapes", Ty: &idltype.Vec{Vec: defined("ShapeAliasAlias")}},
+					},
+				},
+			},
+		},
+	}
+	for _, typ := range idlData.Types {
+		registerComplexEnums(typ)
+	}
+	inlineAliases(idlData)
+
+	// References to aliases of options and complex enums are inlined;
+	// other aliases are kept as named types.
+	fields := idlData.Types[5].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)
+	assert.Equal(t, defined("Amount"), fields[0].Ty)
+	assert.Equal(t, &idltype.Option{Option: &idltype.Pubkey{}}, fields[1].Ty)
+	assert.Equal(t, &idltype.Vec{Vec: defined("AliasTestShape")}, fields[2].Ty)
+
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file := NewFile("test")
+	for _, typ := range idlData.Types[1:] {
+		code, err := gen.gen_IDLTypeDef(typ)
+		require.NoError(t, err)
+		file.Add(code)
+	}
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		"// Amount in lamports.\ntype Amount uint64",
+		"func (obj Amount) MarshalWithEncoder(encoder *binary.Encoder) (err error) {\n\tvalue := uint64(obj)",
+		"func (obj *Amount) UnmarshalWithLimits(decoder *binary.Decoder, budget *limits.Budget) (err error) {\n\tvar value uint64",
+		"*obj = Amount(value)",
+		"type MaybeKey = *solanago.PublicKey",
+		"type ShapeAlias = AliasTestShape",
+		"type ShapeAliasAlias = AliasTestShape",
+		"obj.Shapes = make([]AliasTestShape, vecLen)",
+		"obj.Shapes[i], err = DecodeAliasTestShapeWithLimits(decoder, budget)",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+}
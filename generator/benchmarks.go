@@ -0,0 +1,113 @@
+package generator
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// gen_benchmarks generates the benchmarks of the codecs of the accounts and events,
+// which are encoded and decoded on the hot paths of indexers, next to the benchmarks of
+// the reflection of bin.Encoder.Encode and bin.Decoder.Decode on the same values, as a baseline.
+// The benchmarked values are random (see newSeedFuzzer), and the same across runs.
+func (g *Generator) gen_benchmarks() (*OutputFile, error) {
+	file := NewFile(g.options.Package)
+	file.HeaderComment("Code generated by https://github.com/gagliardetto/anchor-go. DO NOT EDIT.")
+	file.HeaderComment("This file contains benchmarks.")
+	{
+		var names []string
+		for _, acc := range g.idl.Accounts {
+			names = append(names, acc.Name)
+		}
+		for _, event := range g.idl.Events {
+			names = append(names, event.Name)
+		}
+		for _, name := range names {
+			file.Add(gen_codecBenchmarks(tools.ToCamelUpper(name), g.isReflectable(name)))
+			file.Line()
+		}
+	}
+	return &OutputFile{
+		Name: "benchmarks_test.go",
+		File: file,
+	}, nil
+}
+
+// isReflectable tells whether bin encodes and decodes the fields of the struct with the given
+// name with reflection like the generated codec: complex enums are interfaces, which it can't decode.
+func (g *Generator) isReflectable(name string) bool {
+	def := g.idl.Types.ByName(name)
+	if def == nil {
+		return false
+	}
+	structType, ok := def.Ty.(*idl.IdlTypeDefTyStruct)
+	if !ok {
+		return false
+	}
+	for _, field := range namedStructFields(structType.Fields, formatFieldGoName, formatFieldJSONName) {
+		if containsComplexEnum(field.Ty) {
+			return false
+		}
+	}
+	return true
+}
+
+// gen_codecBenchmarks generates the Marshal and Unmarshal benchmarks of a struct and,
+// if reflection, the benchmarks of the reflection on a copy of the struct without its methods.
+func gen_codecBenchmarks(typeName string, reflection bool) Code {
+	code := Empty()
+	reflectionTypeName := "reflection" + typeName
+	newValue := func(body *Group, withReflection bool) {
+		body.Var().Id("value").Id(typeName)
+		body.Id("newSeedFuzzer").Call().Dot("Fuzz").Call(Op("&").Id("value"))
+		body.List(Id("data"), Err()).Op(":=").Id("value").Dot("Marshal").Call()
+		body.Qual(PkgTestifyRequire, "NoError").Call(Id("b"), Err())
+		if withReflection {
+			// Both codecs must encode the same bytes:
+			body.Id("reflectionValue").Op(":=").Id(reflectionTypeName).Call(Id("value"))
+			body.Id("buf").Op(":=").New(Qual("bytes", "Buffer"))
+			body.Qual(PkgTestifyRequire, "NoError").Call(Id("b"), Qual(PkgBinary, "NewBorshEncoder").Call(Id("buf")).Dot("Encode").Call(Op("&").Id("reflectionValue")))
+			body.Qual(PkgTestifyRequire, "Equal").Call(Id("b"), Id("data"), Id("buf").Dot("Bytes").Call())
+		}
+		body.Id("b").Dot("ReportAllocs").Call()
+		body.Id("b").Dot("SetBytes").Call(Int64().Call(Len(Id("data"))))
+		body.Id("b").Dot("ResetTimer").Call()
+	}
+	benchmark := func(name string, withReflection bool, iteration ...Code) {
+		code.Func().Id("Benchmark" + typeName + name).Params(Id("b").Op("*").Qual("testing", "B")).
+			BlockFunc(func(body *Group) {
+				newValue(body, withReflection)
+				body.For(Id("i").Op(":=").Lit(0), Id("i").Op("<").Id("b").Dot("N"), Id("i").Op("++")).Block(iteration...)
+			})
+		code.Line().Line()
+	}
+	benchmark("Marshal", false,
+		If(List(Id("_"), Err()).Op(":=").Id("value").Dot("Marshal").Call(), Err().Op("!=").Nil()).Block(
+			Id("b").Dot("Fatal").Call(Err()),
+		),
+	)
+	benchmark("Unmarshal", false,
+		If(List(Id("_"), Err()).Op(":=").Id("Unmarshal"+typeName).Call(Id("data")), Err().Op("!=").Nil()).Block(
+			Id("b").Dot("Fatal").Call(Err()),
+		),
+	)
+	if !reflection {
+		return code
+	}
+	code.Commentf("%s has the fields of %s without its methods, so that bin encodes and decodes it with reflection.", reflectionTypeName, typeName)
+	code.Line().Type().Id(reflectionTypeName).Id(typeName)
+	code.Line().Line()
+	benchmark("MarshalReflection", true,
+		Id("buf").Op(":=").New(Qual("bytes", "Buffer")),
+		If(Err().Op(":=").Qual(PkgBinary, "NewBorshEncoder").Call(Id("buf")).Dot("Encode").Call(Op("&").Id("reflectionValue")), Err().Op("!=").Nil()).Block(
+			Id("b").Dot("Fatal").Call(Err()),
+		),
+	)
+	benchmark("UnmarshalReflection", true,
+		Var().Id("got").Id(reflectionTypeName),
+		If(Err().Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("data")).Dot("Decode").Call(Op("&").Id("got")), Err().Op("!=").Nil()).Block(
+			Id("b").Dot("Fatal").Call(Err()),
+		),
+	)
+	return code
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	. "github.com/dave/jennifer/jen"
+)
+
+// genfile_client generates the Client type, which binds the instruction builders
+// and parsers to the address of one deployment of the program.
+func (g *Generator) genfile_client() (*OutputFile, error) {
+	file := NewFile(g.options.Package)
+	file.HeaderComment("Code generated by https://github.com/gagliardetto/anchor-go. DO NOT EDIT.")
+	file.HeaderComment("This file contains the program client.")
+
+	code := Empty()
+	{
+		code.Comment("Client builds and parses the instructions of the program deployed at a given address,")
+		code.Line()
+		code.Comment("e.g. one of several deployments of the same program in the same process.")
+		code.Line()
+		code.Type().Id("Client").Struct(
+			Id("programID").Qual(PkgSolanaGo, "PublicKey"),
+		)
+		code.Line().Line()
+
+		code.Comment("New returns the client of the program deployed at programID.")
+		code.Line()
+		code.Func().Id("New").Params(Id("programID").Qual(PkgSolanaGo, "PublicKey")).Op("*").Id("Client").Block(
+			Return(Op("&").Id("Client").Values(Dict{
+				Id("programID"): Id("programID"),
+			})),
+		)
+		code.Line().Line()
+
+		if g.programID() != nil {
+			code.Comment("Default returns the client of the program at GetProgramID(),")
+			code.Line()
+			code.Comment("which is used by the package-level builders and parsers.")
+			code.Line()
+			code.Func().Id("Default").Params().Op("*").Id("Client").Block(
+				Return(Id("New").Call(Id("GetProgramID").Call())),
+			)
+			code.Line().Line()
+		}
+
+		code.Comment("ProgramID returns the address of the program of the client.")
+		code.Line()
+		code.Func().Params(Id("c").Op("*").Id("Client")).Id("ProgramID").Params().Qual(PkgSolanaGo, "PublicKey").Block(
+			Return(Id("c").Dot("programID")),
+		)
+		code.Line().Line()
+
+		code.Comment("ParseInstruction parses an instruction that invokes programID (see ParseInstruction);")
+		code.Line()
+		code.Comment("it fails with errors.ErrProgramIDMismatch if programID isn't the program of the client.")
+		code.Line()
+		code.Func().Params(Id("c").Op("*").Id("Client")).Id("ParseInstruction").
+			Params(
+				Id("programID").Qual(PkgSolanaGo, "PublicKey"),
+				Id("instructionData").Index().Byte(),
+				Id("accountIndicesData").Index().Byte(),
+				Id("accountKeys").Index().Qual(PkgSolanaGo, "PublicKey"),
+			).
+			Params(Id("Instruction"), Error()).
+			Block(
+				If(Op("!").Id("programID").Dot("Equals").Call(Id("c").Dot("programID"))).Block(
+					Return(Nil(), Qual("fmt", "Errorf").Call(
+						Lit("%w: the instruction invokes %s, not %s"),
+						Qual(PkgAnchorGoErrors, "ErrProgramIDMismatch"),
+						Id("programID"),
+						Id("c").Dot("programID"),
+					)),
+				),
+				Return(Id("ParseInstruction").Call(Id("instructionData"), Id("accountIndicesData"), Id("accountKeys"))),
+			)
+		code.Line().Line()
+
+		code.Comment("ParseAnchorError parses the AnchorError logged by a failed transaction.")
+		code.Line()
+		code.Comment("If the error was thrown by the program of the client, ProgramError is set to the")
+		code.Line()
+		code.Comment("corresponding typed error, so that errors.Is works against the Err* values.")
+		code.Line()
+		code.Func().Params(Id("c").Op("*").Id("Client")).Id("ParseAnchorError").Params(Id("logs").Index().String()).Params(Op("*").Qual(PkgAnchorGoErrors, "AnchorError"), Bool()).BlockFunc(func(body *Group) {
+			gen_parseAnchorErrorBody(body, Id("c").Dot("programID"))
+		})
+		code.Line().Line()
+
+		code.Comment("FindProgramAddress finds the program derived address of the seeds,")
+		code.Line()
+		code.Comment("and its bump seed, for the program of the client.")
+		code.Line()
+		code.Func().Params(Id("c").Op("*").Id("Client")).Id("FindProgramAddress").
+			Params(Id("seeds").Index().Index().Byte()).
+			Params(Qual(PkgSolanaGo, "PublicKey"), Uint8(), Error()).
+			Block(
+				Return(Qual(PkgSolanaGo, "FindProgramAddress").Call(Id("seeds"), Id("c").Dot("programID"))),
+			)
+	}
+	file.Add(code)
+
+	return &OutputFile{
+		Name: "client.go",
+		File: file,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenClient(t *testing.T) {
+	gen := &Generator{
+		idl:     &idl.Idl{},
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file, err := gen.genfile_client()
+	require.NoError(t, err)
+	generatedCode := file.File.GoString()
+	for _, expected := range []string{
+		"func New(programID solanago.PublicKey) *Client {",
+		"func (c *Client) ParseInstruction(programID solanago.PublicKey, instructionData []byte, accountIndicesData []byte, accountKeys []solanago.PublicKey) (Instruction, error) {",
+		"errors.ErrProgramIDMismatch",
+		"anchorErr.ProgramID.Equals(c.programID)",
+		"return solanago.FindProgramAddress(seeds, c.programID)",
+	} {
+		assert.Contains(t, generatedCode, expected, "Generated code:\n%s", generatedCode)
+	}
+	// Without a program ID, there is no default client.
+	assert.NotContains(t, generatedCode, "func Default()")
+
+	programID := solana.SystemProgramID
+	gen.options.ProgramId = &programID
+	file, err = gen.genfile_client()
+	require.NoError(t, err)
+	assert.Contains(t, file.File.GoString(), "return New(GetProgramID())")
+}
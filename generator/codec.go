@@ -0,0 +1,311 @@
+package generator
+
+import (
+	"fmt"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// The functions in this file generate the borsh encoding and decoding of values
+// with the typed methods of the encoder and the decoder (WriteUint64, ReadUint64, etc.),
+// so that the generated code never goes through the reflection of encoder.Encode
+// and decoder.Decode.
+
+// primitiveCodecMethods returns the suffix of the encoder (Write*) and decoder (Read*) methods
+// of a primitive type, and whether they take a byte order.
+func primitiveCodecMethods(ty idltype.IdlType) (name string, withOrder bool, ok bool) {
+	switch ty.(type) {
+	case *idltype.Bool:
+		return "Bool", false, true
+	case *idltype.U8:
+		return "Uint8", false, true
+	case *idltype.I8:
+		return "Int8", false, true
+	case *idltype.U16:
+		return "Uint16", true, true
+	case *idltype.I16:
+		return "Int16", true, true
+	case *idltype.U32:
+		return "Uint32", true, true
+	case *idltype.I32:
+		return "Int32", true, true
+	case *idltype.U64:
+		return "Uint64", true, true
+	case *idltype.I64:
+		return "Int64", true, true
+	case *idltype.F32:
+		return "Float32", true, true
+	case *idltype.F64:
+		return "Float64", true, true
+	case *idltype.U128:
+		return "Uint128", true, true
+	case *idltype.I128:
+		return "Int128", true, true
+	}
+	return "", false, false
+}
+
+// isByteSequence tells whether ty is encoded as raw bytes (bytes, vec<u8> and [u8; N]).
+func isByteSequence(ty idltype.IdlType) bool {
+	switch vv := ty.(type) {
+	case *idltype.Bytes:
+		return true
+	case *idltype.Vec:
+		_, ok := vv.Vec.(*idltype.U8)
+		return ok
+	case *idltype.Array:
+		_, ok := vv.Type.(*idltype.U8)
+		return ok
+	}
+	return false
+}
+
+// gen_encodeValue generates the statements that write value (of type ty) to the encoder;
+// returnErr returns the given (failed) error.
+func gen_encodeValue(
+	body *Group,
+	encoderName string,
+	value Code,
+	ty idltype.IdlType,
+	depth int,
+	returnErr func(err Code) Code,
+) {
+	write := func(call Code) {
+		body.Err().Op("=").Add(call)
+		body.If(Err().Op("!=").Nil()).Block(
+			returnErr(Err()),
+		)
+	}
+	if method, withOrder, ok := primitiveCodecMethods(ty); ok {
+		write(Id(encoderName).Dot("Write" + method).CallFunc(func(args *Group) {
+			args.Add(value)
+			if withOrder {
+				args.Qual(PkgBinary, "LE")
+			}
+		}))
+		return
+	}
+	switch vv := ty.(type) {
+	case *idltype.U256, *idltype.I256:
+		write(Add(value).Dot("MarshalWithEncoder").Call(Id(encoderName)))
+	case *idltype.String:
+		write(Id(encoderName).Dot("WriteString").Call(value))
+	case *idltype.Bytes:
+		write(Id(encoderName).Dot("WriteBytes").Call(value, True()))
+	case *idltype.Pubkey:
+		write(Id(encoderName).Dot("WriteBytes").Call(Add(value).Index(Op(":")), False()))
+	case *idltype.Defined:
+		if isComplexEnum(vv) {
+			write(Id(formatEnumEncoderName(tools.ToCamelUpper(vv.Name))).Call(Id(encoderName), value))
+		} else {
+			write(Add(value).Dot("MarshalWithEncoder").Call(Id(encoderName)))
+		}
+	case *idltype.Vec:
+		if isByteSequence(vv) {
+			write(Id(encoderName).Dot("WriteBytes").Call(value, True()))
+			return
+		}
+		body.Err().Op("=").Id(encoderName).Dot("WriteLength").Call(Len(value))
+		body.If(Err().Op("!=").Nil()).Block(
+			returnErr(Qual("fmt", "Errorf").Call(Lit("error while writing vector length: %w"), Err())),
+		)
+		gen_encodeItems(body, encoderName, value, vv.Vec, depth, returnErr)
+	case *idltype.Array:
+		if isByteSequence(vv) {
+			write(Id(encoderName).Dot("WriteBytes").Call(Add(value).Index(Op(":")), False()))
+			return
+		}
+		gen_encodeItems(body, encoderName, value, vv.Type, depth, returnErr)
+	case *idltype.Option, *idltype.COption:
+		inner := optionInnerType(ty)
+		if !genericOptions {
+			// Nested pointer options are declared as their inner type (see genTypeName).
+			gen_encodeValue(body, encoderName, value, inner, depth, returnErr)
+			return
+		}
+		optionalityWriterName := "WriteOption"
+		if IsCOption(ty) {
+			optionalityWriterName = "WriteCOption"
+		}
+		valueName, okName := formatDepthName("value", depth), formatDepthName("ok", depth)
+		body.BlockFunc(func(optBody *Group) {
+			optBody.List(Id(valueName), Id(okName)).Op(":=").Add(value).Dot("Get").Call()
+			optBody.Err().Op("=").Id(encoderName).Dot(optionalityWriterName).Call(Id(okName))
+			optBody.If(Err().Op("!=").Nil()).Block(
+				returnErr(Qual("fmt", "Errorf").Call(Lit("error while encoding optionality: %w"), Err())),
+			)
+			optBody.If(Id(okName)).BlockFunc(func(someBody *Group) {
+				gen_encodeValue(someBody, encoderName, Id(valueName), inner, depth+1, returnErr)
+			})
+		})
+	default:
+		panic(fmt.Sprintf("unhandled type: %s", spew.Sdump(ty)))
+	}
+}
+
+// gen_encodeItems generates the loop that writes the items of a vector or an array.
+func gen_encodeItems(
+	body *Group,
+	encoderName string,
+	value Code,
+	itemType idltype.IdlType,
+	depth int,
+	returnErr func(err Code) Code,
+) {
+	index := formatLoopIndexName(depth)
+	body.For(
+		Id(index).Op(":=").Lit(0),
+		Id(index).Op("<").Len(value),
+		Id(index).Op("++"),
+	).BlockFunc(func(forBody *Group) {
+		gen_encodeValue(forBody, encoderName, Add(value).Index(Id(index)), itemType, depth+1, func(err Code) Code {
+			return returnErr(Qual(PkgAnchorGoErrors, "NewIndex").Call(Id(index), err))
+		})
+	})
+}
+
+// gen_decodeValue generates the statements that read a value of type ty from the decoder
+// into target (which must be addressable), with the limits of budget (a *limits.Budget);
+// returnErr returns the given (failed) error, which already carries the offset of the decoder.
+func gen_decodeValue(
+	body *Group,
+	target Code,
+	ty idltype.IdlType,
+	depth int,
+	returnErr func(err Code) Code,
+) {
+	check := func() {
+		body.If(Err().Op("!=").Nil()).Block(
+			returnErr(withDecoderOffset(Err())),
+		)
+	}
+	if method, withOrder, ok := primitiveCodecMethods(ty); ok {
+		body.List(target, Err()).Op("=").Id("decoder").Dot("Read" + method).CallFunc(func(args *Group) {
+			if withOrder {
+				args.Qual(PkgBinary, "LE")
+			}
+		})
+		check()
+		return
+	}
+	switch vv := ty.(type) {
+	case *idltype.U256, *idltype.I256:
+		body.Err().Op("=").Add(target).Dot("UnmarshalWithDecoder").Call(Id("decoder"))
+		check()
+	case *idltype.String:
+		body.List(target, Err()).Op("=").Id("budget").Dot("ReadString").Call(Id("decoder"))
+		check()
+	case *idltype.Bytes:
+		body.List(target, Err()).Op("=").Id("budget").Dot("ReadByteSlice").Call(Id("decoder"))
+		check()
+	case *idltype.Pubkey:
+		gen_decodeFixedBytes(body, target, Lit(32), depth, returnErr)
+	case *idltype.Defined:
+		switch {
+		case isComplexEnum(vv):
+			body.List(target, Err()).Op("=").Id(formatEnumLimitsParserName(tools.ToCamelUpper(vv.Name))).Call(Id("decoder"), Id("budget"))
+		case isSimpleEnum(vv):
+			body.Err().Op("=").Add(target).Dot("UnmarshalWithDecoder").Call(Id("decoder"))
+		default:
+			body.Err().Op("=").Add(target).Dot("UnmarshalWithLimits").Call(Id("decoder"), Id("budget"))
+		}
+		check()
+	case *idltype.Vec:
+		if isByteSequence(vv) {
+			body.List(target, Err()).Op("=").Id("budget").Dot("ReadByteSlice").Call(Id("decoder"))
+			check()
+			return
+		}
+		vecLenName := formatDepthName("vecLen", depth)
+		body.BlockFunc(func(vecBody *Group) {
+			vecBody.List(Id(vecLenName), Err()).Op(":=").Id("decoder").Dot("ReadLength").Call()
+			vecBody.If(Err().Op("!=").Nil()).Block(
+				returnErr(withDecoderOffset(
+					Qual("fmt", "Errorf").Call(Lit("error while reading vector length: %w"), Err()),
+				)),
+			)
+			// Check the declared length before allocating the vector:
+			vecBody.Err().Op("=").Id("budget").Dot("CheckLength").Call(
+				Id("decoder"),
+				Id(vecLenName),
+				Lit(minEncodedSize(vv.Vec)),
+				Int().Call(Qual("unsafe", "Sizeof").Call(Add(target).Index(Lit(0)))),
+			)
+			vecBody.If(Err().Op("!=").Nil()).Block(
+				returnErr(withDecoderOffset(Err())),
+			)
+			vecBody.Add(target).Op("=").Make(genTypeName(vv), Id(vecLenName))
+			gen_decodeItems(vecBody, target, vv.Vec, depth, returnErr)
+		})
+	case *idltype.Array:
+		if isByteSequence(vv) {
+			gen_decodeFixedBytes(body, target, Len(target), depth, returnErr)
+			return
+		}
+		gen_decodeItems(body, target, vv.Type, depth, returnErr)
+	case *idltype.Option, *idltype.COption:
+		inner := optionInnerType(ty)
+		if !genericOptions {
+			// Nested pointer options are declared as their inner type (see genTypeName).
+			gen_decodeValue(body, target, inner, depth, returnErr)
+			return
+		}
+		optionalityReaderName, someName, noneName := "ReadOption", "Some", "None"
+		if IsCOption(ty) {
+			optionalityReaderName, someName, noneName = "ReadCOption", "SomeC", "NoneC"
+		}
+		valueName, okName := formatDepthName("value", depth), formatDepthName("ok", depth)
+		body.BlockFunc(func(optBody *Group) {
+			optBody.List(Id(okName), Err()).Op(":=").Id("decoder").Dot(optionalityReaderName).Call()
+			optBody.If(Err().Op("!=").Nil()).Block(
+				returnErr(withDecoderOffset(
+					Qual("fmt", "Errorf").Call(Lit("error while reading optionality: %w"), Err()),
+				)),
+			)
+			optBody.If(Id(okName)).BlockFunc(func(someBody *Group) {
+				someBody.Var().Id(valueName).Add(genTypeName(inner))
+				gen_decodeValue(someBody, Id(valueName), inner, depth+1, returnErr)
+				someBody.Add(target).Op("=").Qual(PkgAnchorGoOption, someName).Call(Id(valueName))
+			}).Else().Block(
+				Add(target).Op("=").Qual(PkgAnchorGoOption, noneName).Types(genTypeName(inner)).Call(),
+			)
+		})
+	default:
+		panic(fmt.Sprintf("unhandled type: %s", spew.Sdump(ty)))
+	}
+}
+
+// gen_decodeItems generates the loop that reads the items of an (already allocated) vector or array.
+func gen_decodeItems(
+	body *Group,
+	target Code,
+	itemType idltype.IdlType,
+	depth int,
+	returnErr func(err Code) Code,
+) {
+	index := formatLoopIndexName(depth)
+	body.For(
+		Id(index).Op(":=").Lit(0),
+		Id(index).Op("<").Len(target),
+		Id(index).Op("++"),
+	).BlockFunc(func(forBody *Group) {
+		gen_decodeValue(forBody, Add(target).Index(Id(index)), itemType, depth+1, func(err Code) Code {
+			return returnErr(Qual(PkgAnchorGoErrors, "NewIndex").Call(Id(index), err))
+		})
+	})
+}
+
+// gen_decodeFixedBytes generates the statements that read size bytes into target (a byte array).
+func gen_decodeFixedBytes(body *Group, target Code, size Code, depth int, returnErr func(err Code) Code) {
+	bufName := formatDepthName("buf", depth)
+	body.BlockFunc(func(bytesBody *Group) {
+		bytesBody.List(Id(bufName), Err()).Op(":=").Id("decoder").Dot("ReadBytes").Call(size)
+		bytesBody.If(Err().Op("!=").Nil()).Block(
+			returnErr(withDecoderOffset(Err())),
+		)
+		bytesBody.Copy(Add(target).Index(Op(":")), Id(bufName))
+	})
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"testing"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenCodec(t *testing.T) {
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "CodecShape",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind: "enum",
+					Variants: idl.VariantSlice{
+						{Name: "Empty"},
+						{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+					},
+				},
+			},
+			{
+				Name: "CodecHolder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "big", Ty: &idltype.U128{}},
+						{Name: "key", Ty: &idltype.Pubkey{}},
+						{Name: "name", Ty: &idltype.String{}},
+						{Name: "seed", Ty: &idltype.Array{Type: &idltype.U8{}, Size: &idltype.IdlArrayLenValue{Value: 4}}},
+						{Name: "matrix", Ty: &idltype.Vec{Vec: &idltype.Vec{Vec: &idltype.U16{}}}},
+						{Name: "maybe", Ty: &idltype.Option{Option: &idltype.Pubkey{}}},
+						{Name: "shape", Ty: &idltype.Defined{Name: "CodecShape"}},
+					},
+				},
+			},
+		},
+	}
+	for _, typ := range idlData.Types {
+		registerComplexEnums(typ)
+	}
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file := NewFile("test")
+	for _, typ := range idlData.Types {
+		code, err := gen.gen_IDLTypeDef(typ)
+		require.NoError(t, err)
+		file.Add(code)
+	}
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		// Encoding:
+		"err = encoder.WriteUint128(obj.Big, binary.LE)",
+		"err = encoder.WriteBytes(obj.Key[:], false)",
+		"err = encoder.WriteString(obj.Name)",
+		"err = encoder.WriteBytes(obj.Seed[:], false)",
+		"err = encoder.WriteLength(len(obj.Matrix))",
+		"err = encoder.WriteUint16(obj.Matrix[i][j], binary.LE)",
+		"err = encoder.WriteBytes((*obj.Maybe)[:], false)",
+		"err = EncodeCodecShape(encoder, obj.Shape)",
+		// Decoding:
+		"obj.Big, err = decoder.ReadUint128(binary.LE)",
+		"buf, err := decoder.ReadBytes(32)",
+		"copy(obj.Key[:], buf)",
+		"obj.Name, err = budget.ReadString(decoder)",
+		"buf, err := decoder.ReadBytes(len(obj.Seed))",
+		"vecLen1, err := decoder.ReadLength()",
+		"err = budget.CheckLength(decoder, vecLen, 4, int(unsafe.Sizeof(obj.Matrix[0])))",
+		"err = budget.CheckLength(decoder, vecLen1, 2, int(unsafe.Sizeof(obj.Matrix[i][0])))",
+		"obj.Matrix[i] = make([]uint16, vecLen1)",
+		"obj.Matrix[i][j], err = decoder.ReadUint16(binary.LE)",
+		"errors.NewField(\"matrix\", errors.NewIndex(i, errors.NewIndex(j, errors.NewOffset(int(decoder.Position()), err))))",
+		"var value solanago.PublicKey",
+		"obj.Shape, err = DecodeCodecShapeWithLimits(decoder, budget)",
+		// Complex enums:
+		"variantIndex, err := decoder.ReadUint8()",
+		"value := new(CodecShape_Circle)\n\t\tif err := value.UnmarshalWithLimits(decoder, budget); err != nil {",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+	// No reflection:
+	assert.NotContains(t, generatedCode, ".Decode(")
+	assert.NotContains(t, generatedCode, ".Encode(")
+	assert.NotContains(t, generatedCode, "EnumContainer")
+}
@@ -21,17 +21,33 @@ func register_TypeName_as_ComplexEnum(name string) {
 	typeRegistryComplexEnum[name] = struct{}{}
 }
 
+// typeRegistrySimpleEnum contains all types that are a simple enum (and thus implemented as a uint8).
+var typeRegistrySimpleEnum = make(map[string]struct{})
+
+func isSimpleEnum(envel idltype.IdlType) bool {
+	switch vv := envel.(type) {
+	case *idltype.Defined:
+		_, ok := typeRegistrySimpleEnum[vv.Name]
+		return ok
+	}
+	return false
+}
+
 func registerComplexEnums(def idl.IdlTypeDef) {
 	switch vv := def.Ty.(type) {
 	case *idl.IdlTypeDefTyEnum:
 		enumTypeName := def.Name
 		if !vv.IsAllSimple() {
 			register_TypeName_as_ComplexEnum(enumTypeName)
+		} else {
+			typeRegistrySimpleEnum[enumTypeName] = struct{}{}
 		}
 	case idl.IdlTypeDefTyEnum:
 		enumTypeName := def.Name
 		if !vv.IsAllSimple() {
 			register_TypeName_as_ComplexEnum(enumTypeName)
+		} else {
+			typeRegistrySimpleEnum[enumTypeName] = struct{}{}
 		}
 	}
 }
@@ -0,0 +1,483 @@
+package generator
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The values of the constants are the Rust expressions of the constants, as printed
+// by the IDL builder (e.g. `MyStruct { a : 1 , b : [1 , 2] }`, `b"seed"`,
+// `Status :: Active`, `pubkey ! ("...")`); parseRustValue parses the subset of
+// Rust literal syntax that can be converted to Go values.
+
+type rustValueKind int
+
+const (
+	rustNumber rustValueKind = iota // 42, -1_000u64, 0xff, 3.14
+	rustString                      // "text"
+	rustBytes                       // b"text"
+	rustIdent                       // true, None, Active, Status::Active, base58 strings
+	rustArray                       // [a, b], [a; 3]
+	rustTuple                       // (a, b), Some(a), Point(a, b)
+	rustStruct                      // Point { x: a, y: b }
+)
+
+type rustValue struct {
+	kind   rustValueKind
+	text   string // The number, the string, or the last segment of the identifier.
+	bytes  []byte // The content of byte strings.
+	name   string // The last segment of the path of tuple structs and structs (empty for plain tuples).
+	items  []*rustValue
+	fields []rustField
+}
+
+type rustField struct {
+	name  string
+	value *rustValue
+}
+
+func (v *rustValue) String() string {
+	switch v.kind {
+	case rustString:
+		return strconv.Quote(v.text)
+	case rustBytes:
+		return "b" + strconv.Quote(string(v.bytes))
+	case rustArray:
+		return fmt.Sprintf("array of %d items", len(v.items))
+	case rustTuple:
+		return fmt.Sprintf("%s(%d items)", v.name, len(v.items))
+	case rustStruct:
+		return fmt.Sprintf("%s { %d fields }", v.name, len(v.fields))
+	}
+	return v.text
+}
+
+// parseRustValue parses the Rust expression of a constant value.
+func parseRustValue(s string) (*rustValue, error) {
+	p := &rustValueParser{src: s}
+	v, err := p.parseValue(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q after the value", p.src[p.pos:])
+	}
+	return v, nil
+}
+
+const (
+	// maxRustValueDepth bounds the nesting of the parsed values.
+	maxRustValueDepth = 64
+	// maxRustArrayRepeat bounds the length of repeat expressions (e.g. `[0; 32]`).
+	maxRustArrayRepeat = 1 << 20
+)
+
+type rustValueParser struct {
+	src string
+	pos int
+}
+
+func (p *rustValueParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *rustValueParser) skipSpace() {
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		p.pos += size
+	}
+}
+
+// peek returns the next non-space byte (0 at the end of the input).
+func (p *rustValueParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *rustValueParser) expect(c byte) error {
+	if p.peek() != c {
+		if p.pos >= len(p.src) {
+			return p.errorf("expected %q, got the end of the value", c)
+		}
+		return p.errorf("expected %q, got %q", c, p.src[p.pos])
+	}
+	p.pos++
+	return nil
+}
+
+func (p *rustValueParser) parseValue(depth int) (*rustValue, error) {
+	if depth > maxRustValueDepth {
+		return nil, p.errorf("value nested too deeply")
+	}
+	switch c := p.peek(); {
+	case c == 0:
+		return nil, p.errorf("expected a value, got the end of the value")
+	case c == '&':
+		// References to slices and arrays (e.g. `&[1, 2]`).
+		p.pos++
+		return p.parseValue(depth)
+	case c == '[':
+		p.pos++
+		return p.parseArray(depth)
+	case c == '(':
+		p.pos++
+		items, trailingComma, err := p.parseList(')', depth)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 1 && !trailingComma {
+			// Parenthesized expression.
+			return items[0], nil
+		}
+		return &rustValue{kind: rustTuple, items: items}, nil
+	case c == '"':
+		text, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &rustValue{kind: rustString, text: text}, nil
+	case c == 'b' && strings.HasPrefix(p.src[p.pos:], `b"`):
+		p.pos++
+		text, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return &rustValue{kind: rustBytes, bytes: []byte(text)}, nil
+	case c == '-' || isDigit(c):
+		return &rustValue{kind: rustNumber, text: p.parseWord()}, nil
+	case isIdentStart(c):
+		return p.parsePath(depth)
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *rustValueParser) parseArray(depth int) (*rustValue, error) {
+	if p.peek() == ']' {
+		p.pos++
+		return &rustValue{kind: rustArray}, nil
+	}
+	first, err := p.parseValue(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == ';' {
+		// Repeat expression: `[value; count]`.
+		p.pos++
+		p.skipSpace()
+		count, err := strconv.ParseUint(trimIntegerSuffix(strings.ReplaceAll(p.parseWord(), "_", "")), 0, 32)
+		if err != nil {
+			return nil, p.errorf("invalid array length: %v", err)
+		}
+		if count > maxRustArrayRepeat {
+			return nil, p.errorf("array length %d exceeds %d", count, maxRustArrayRepeat)
+		}
+		if err := p.expect(']'); err != nil {
+			return nil, err
+		}
+		items := make([]*rustValue, count)
+		for i := range items {
+			items[i] = first
+		}
+		return &rustValue{kind: rustArray, items: items}, nil
+	}
+	items := []*rustValue{first}
+	if p.peek() == ',' {
+		p.pos++
+		rest, _, err := p.parseList(']', depth)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, rest...)
+	} else if err := p.expect(']'); err != nil {
+		return nil, err
+	}
+	return &rustValue{kind: rustArray, items: items}, nil
+}
+
+// parseList parses comma-separated values up to the closing delimiter;
+// trailingComma reports whether the last value is followed by a comma.
+func (p *rustValueParser) parseList(closing byte, depth int) (items []*rustValue, trailingComma bool, err error) {
+	for {
+		if p.peek() == closing {
+			p.pos++
+			return items, len(items) > 0, nil
+		}
+		item, err := p.parseValue(depth + 1)
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(items, item)
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		if err := p.expect(closing); err != nil {
+			return nil, false, err
+		}
+		return items, false, nil
+	}
+}
+
+// parsePath parses identifiers (e.g. `Status :: Active`), tuple structs and variants
+// (e.g. `Some (1)`), structs and variants (e.g. `Point { x : 1 }`) and macros (e.g. `pubkey ! ("...")`).
+func (p *rustValueParser) parsePath(depth int) (*rustValue, error) {
+	name := p.parseWord()
+	for {
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], "::") {
+			break
+		}
+		p.pos += 2
+		p.skipSpace()
+		if p.pos >= len(p.src) || !isIdentStart(p.src[p.pos]) {
+			return nil, p.errorf("expected an identifier after '::'")
+		}
+		name = p.parseWord()
+	}
+	switch p.peek() {
+	case '!':
+		// Macros: the value is the argument (e.g. `pubkey!("...")`).
+		p.pos++
+		if c := p.peek(); c != '(' && c != '[' {
+			return nil, p.errorf("expected the arguments of the %s! macro", name)
+		}
+		closing := byte(')')
+		if p.src[p.pos] == '[' {
+			closing = ']'
+		}
+		p.pos++
+		items, _, err := p.parseList(closing, depth)
+		if err != nil {
+			return nil, err
+		}
+		if closing == ']' {
+			// vec![...]
+			return &rustValue{kind: rustArray, items: items}, nil
+		}
+		if len(items) != 1 {
+			return nil, p.errorf("expected one argument for the %s! macro, got %d", name, len(items))
+		}
+		return items[0], nil
+	case '(':
+		p.pos++
+		items, _, err := p.parseList(')', depth)
+		if err != nil {
+			return nil, err
+		}
+		return &rustValue{kind: rustTuple, name: name, items: items}, nil
+	case '{':
+		p.pos++
+		var fields []rustField
+		for {
+			if p.peek() == '}' {
+				p.pos++
+				break
+			}
+			if !isIdentStart(p.peek()) {
+				return nil, p.errorf("expected a field name in %s", name)
+			}
+			fieldName := p.parseWord()
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, rustField{name: fieldName, value: value})
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if err := p.expect('}'); err != nil {
+				return nil, err
+			}
+			break
+		}
+		return &rustValue{kind: rustStruct, name: name, fields: fields}, nil
+	}
+	return &rustValue{kind: rustIdent, text: name}, nil
+}
+
+// parseWord parses a number or an identifier: a run of identifier characters
+// (plus the sign, the decimal point and the exponent of numbers).
+func (p *rustValueParser) parseWord() string {
+	var sb strings.Builder
+	if p.pos < len(p.src) && p.src[p.pos] == '-' {
+		sb.WriteByte('-')
+		p.pos++
+		p.skipSpace()
+	}
+	number := p.pos < len(p.src) && isDigit(p.src[p.pos])
+	hex := number && strings.HasPrefix(p.src[p.pos:], "0x")
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		last := sb.String()[max(sb.Len()-1, 0):]
+		isExponentSign := number && !hex && (c == '-' || c == '+') && (last == "e" || last == "E")
+		if !isIdentChar(c) && !(number && c == '.') && !isExponentSign {
+			break
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return sb.String()
+}
+
+// parseString parses a double-quoted string with Rust escapes.
+func (p *rustValueParser) parseString() (string, error) {
+	if err := p.expect('"'); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.src) {
+				return "", p.errorf("unterminated escape")
+			}
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '0':
+				sb.WriteByte(0)
+			case '\\', '"', '\'':
+				sb.WriteByte(e)
+			case 'x':
+				if p.pos+2 > len(p.src) {
+					return "", p.errorf("invalid \\x escape")
+				}
+				b, err := strconv.ParseUint(p.src[p.pos:p.pos+2], 16, 8)
+				if err != nil {
+					return "", p.errorf("invalid \\x escape: %v", err)
+				}
+				sb.WriteByte(byte(b))
+				p.pos += 2
+			case 'u':
+				end := strings.IndexByte(p.src[p.pos:], '}')
+				if !strings.HasPrefix(p.src[p.pos:], "{") || end < 0 {
+					return "", p.errorf("invalid \\u escape")
+				}
+				r, err := strconv.ParseUint(strings.ReplaceAll(p.src[p.pos+1:p.pos+end], "_", ""), 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid \\u escape: %v", err)
+				}
+				sb.WriteRune(rune(r))
+				p.pos += end + 1
+			case '\n':
+				// Line continuation: skip the leading whitespace of the next line.
+				p.skipSpace()
+			default:
+				return "", p.errorf("unknown escape \\%c", e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+var rustIntegerSuffixes = []string{"u128", "i128", "usize", "isize", "u64", "i64", "u32", "i32", "u16", "i16", "u8", "i8"}
+
+// trimIntegerSuffix removes the type suffix of an integer literal (e.g. 42u64).
+func trimIntegerSuffix(s string) string {
+	if strings.HasPrefix(strings.TrimPrefix(s, "-"), "0x") {
+		// The suffixes of hexadecimal literals are ambiguous with their digits,
+		// except for the ones that don't only contain hexadecimal digits.
+		for _, suffix := range rustIntegerSuffixes {
+			if strings.ContainsAny(suffix, "usiz") && strings.HasSuffix(s, suffix) {
+				return strings.TrimSuffix(s, suffix)
+			}
+		}
+		return s
+	}
+	for _, suffix := range rustIntegerSuffixes {
+		if strings.HasSuffix(s, suffix) {
+			return strings.TrimSuffix(s, suffix)
+		}
+	}
+	return s
+}
+
+// parseRustInteger parses an integer literal (with underscores, type suffixes
+// and 0x/0o/0b prefixes) of the given bit size.
+func parseRustInteger(v *rustValue, bits int, signed bool) (*big.Int, error) {
+	if v.kind == rustIdent && (v.text == "MAX" || v.text == "MIN") {
+		// e.g. u64::MAX
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		if signed {
+			limit.Rsh(limit, 1)
+			if v.text == "MIN" {
+				return limit.Neg(limit), nil
+			}
+		} else if v.text == "MIN" {
+			return new(big.Int), nil
+		}
+		return limit.Sub(limit, big.NewInt(1)), nil
+	}
+	if v.kind != rustNumber {
+		return nil, fmt.Errorf("expected an integer, got %s", v)
+	}
+	text := trimIntegerSuffix(strings.ReplaceAll(v.text, "_", ""))
+	// Unlike in Go, a leading zero doesn't make a Rust literal octal.
+	base := 10
+	if digits := strings.TrimPrefix(text, "-"); len(digits) > 1 && digits[0] == '0' && strings.ContainsRune("xob", rune(digits[1])) {
+		base = 0
+	}
+	n, ok := new(big.Int).SetString(text, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", v.text)
+	}
+	min, max := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	if signed {
+		max.Rsh(max, 1)
+		min.Neg(max)
+	}
+	if n.Cmp(min) < 0 || n.Cmp(max) >= 0 {
+		return nil, fmt.Errorf("integer %s overflows %d bits", v.text, bits)
+	}
+	return n, nil
+}
+
+// parseRustFloat parses a float literal (with underscores and type suffixes).
+func parseRustFloat(v *rustValue, bits int) (float64, error) {
+	if v.kind != rustNumber {
+		return 0, fmt.Errorf("expected a float, got %s", v)
+	}
+	text := strings.ReplaceAll(v.text, "_", "")
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "f32"), "f64")
+	return strconv.ParseFloat(text, bits)
+}
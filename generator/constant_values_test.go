@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRustValue(t *testing.T) {
+	t.Run("numbers", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"42":        "42",
+			"- 1_000":   "-1_000",
+			"1_000u64":  "1_000u64",
+			"0xffu8":    "0xffu8",
+			"4e-6":      "4e-6",
+			"3.14f32":   "3.14f32",
+			"(7)":       "7",
+			"& [1][0]":  "",
+			"[1 ; 2][":  "",
+			"1 2":       "",
+			"[1 , 2":    "",
+			"\"unterm":  "",
+			"\"\\q\"":   "",
+			"Some (1":   "",
+			"pubkey ! ": "",
+		} {
+			v, err := parseRustValue(input)
+			if expected == "" {
+				require.Error(t, err, input)
+				continue
+			}
+			require.NoError(t, err, input)
+			require.Equal(t, rustNumber, v.kind, input)
+			require.Equal(t, expected, v.text, input)
+		}
+	})
+	t.Run("strings", func(t *testing.T) {
+		v, err := parseRustValue(`"a\"b\n\x41\u{1F600}"`)
+		require.NoError(t, err)
+		require.Equal(t, rustString, v.kind)
+		require.Equal(t, "a\"b\nA\U0001F600", v.text)
+
+		v, err = parseRustValue(`b"seed\x00"`)
+		require.NoError(t, err)
+		require.Equal(t, rustBytes, v.kind)
+		require.Equal(t, []byte("seed\x00"), v.bytes)
+	})
+	t.Run("paths and macros", func(t *testing.T) {
+		v, err := parseRustValue(`crate :: state :: Status :: Active`)
+		require.NoError(t, err)
+		require.Equal(t, rustIdent, v.kind)
+		require.Equal(t, "Active", v.text)
+
+		v, err = parseRustValue(`pubkey ! ("11111111111111111111111111111111")`)
+		require.NoError(t, err)
+		require.Equal(t, rustString, v.kind)
+		require.Equal(t, "11111111111111111111111111111111", v.text)
+
+		v, err = parseRustValue(`vec ! [1 , 2 ,]`)
+		require.NoError(t, err)
+		require.Equal(t, rustArray, v.kind)
+		require.Len(t, v.items, 2)
+	})
+	t.Run("composites", func(t *testing.T) {
+		v, err := parseRustValue(`Reward { mint : Pubkey :: default () , amounts : [[0u8 ; 2] ; 3] , note : Some (Note (1 , "x")) , }`)
+		require.NoError(t, err)
+		require.Equal(t, rustStruct, v.kind)
+		require.Equal(t, "Reward", v.name)
+		require.Len(t, v.fields, 3)
+
+		mint := v.fields[0]
+		require.Equal(t, "mint", mint.name)
+		require.Equal(t, rustTuple, mint.value.kind)
+		require.Equal(t, "default", mint.value.name)
+		require.Empty(t, mint.value.items)
+
+		amounts := v.fields[1].value
+		require.Equal(t, rustArray, amounts.kind)
+		require.Len(t, amounts.items, 3)
+		require.Len(t, amounts.items[2].items, 2)
+		require.Equal(t, "0u8", amounts.items[2].items[1].text)
+
+		note := v.fields[2].value
+		require.Equal(t, "Some", note.name)
+		require.Equal(t, "Note", note.items[0].name)
+		require.Equal(t, "x", note.items[0].items[1].text)
+
+		v, err = parseRustValue(`(1 ,)`)
+		require.NoError(t, err)
+		require.Equal(t, rustTuple, v.kind)
+		require.Len(t, v.items, 1)
+	})
+	t.Run("limits", func(t *testing.T) {
+		_, err := parseRustValue("[0 ; 4294967295]")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds")
+
+		deep := ""
+		for range maxRustValueDepth + 2 {
+			deep += "["
+		}
+		_, err = parseRustValue(deep)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "nested too deeply")
+	})
+}
+
+func TestParseRustInteger(t *testing.T) {
+	n, err := parseRustInteger(&rustValue{kind: rustIdent, text: "MAX"}, 64, false)
+	require.NoError(t, err)
+	require.Equal(t, "18446744073709551615", n.String())
+
+	n, err = parseRustInteger(&rustValue{kind: rustIdent, text: "MIN"}, 8, true)
+	require.NoError(t, err)
+	require.Equal(t, "-128", n.String())
+
+	n, err = parseRustInteger(&rustValue{kind: rustNumber, text: "0xff_u8"}, 8, false)
+	require.NoError(t, err)
+	require.Equal(t, "255", n.String())
+
+	n, err = parseRustInteger(&rustValue{kind: rustNumber, text: "0o17"}, 8, false)
+	require.NoError(t, err)
+	require.Equal(t, "15", n.String())
+
+	n, err = parseRustInteger(&rustValue{kind: rustNumber, text: "010"}, 8, false)
+	require.NoError(t, err)
+	require.Equal(t, "10", n.String())
+
+	_, err = parseRustInteger(&rustValue{kind: rustNumber, text: "256"}, 8, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "overflows")
+
+	_, err = parseRustInteger(&rustValue{kind: rustNumber, text: "-1"}, 64, false)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "overflows")
+}
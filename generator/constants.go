@@ -9,7 +9,10 @@ import (
 
 	. "github.com/dave/jennifer/jen"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/gagliardetto/anchor-go/idl"
 	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/numeric"
+	"github.com/gagliardetto/anchor-go/tools"
 	"github.com/gagliardetto/solana-go"
 )
 
@@ -49,6 +52,10 @@ func (g *Generator) gen_constants() (*OutputFile, error) {
 				var b []byte
 				err := json.Unmarshal([]byte(co.Value), &b)
 				if err != nil {
+					// "value":"b\"seed\""
+					if g.gen_constantVar(code, coi, co) == nil {
+						break
+					}
 					return nil, fmt.Errorf("failed to unmarshal bytes constants[%d] %s: %w", coi, spew.Sdump(co), err)
 				}
 				code.Var().Id(co.Name).Op("=").Index().Byte().Op("{").ListFunc(func(byteGroup *Group) {
@@ -191,6 +198,24 @@ func (g *Generator) gen_constants() (*OutputFile, error) {
 					Return(Id("val")),
 				).Call()
 				code.Line()
+			case *idltype.U256:
+				_ = ty
+				// "value":"115_792_089_237_316_195_423_570_985_008_687_907_853_269_984_665_640_564_039_457_584_007_913_129_639_935"
+				v, err := numeric.ParseUint256(co.Value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse u256 constants[%d] %s: %w", coi, spew.Sdump(co), err)
+				}
+				code.Var().Id(co.Name).Op("=").Qual(PkgAnchorGoNumeric, "MustParseUint256").Call(Lit(v.String()))
+				code.Line()
+			case *idltype.I256:
+				_ = ty
+				// "value":"-100_000_000"
+				v, err := numeric.ParseInt256(co.Value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse i256 constants[%d] %s: %w", coi, spew.Sdump(co), err)
+				}
+				code.Var().Id(co.Name).Op("=").Qual(PkgAnchorGoNumeric, "MustParseInt256").Call(Lit(v.String()))
+				code.Line()
 			case *idltype.F32:
 				_ = ty
 				// "value":"3.14"
@@ -216,9 +241,13 @@ func (g *Generator) gen_constants() (*OutputFile, error) {
 				_ = ty
 				// "type":{"array":["u8",23]},"value":"[115, 101, 110, 100, 95, 119, 105, 116, 104, 95, 115, 119, 97, 112, 95, 100, 101, 108, 101, 103, 97, 116, 101]"
 				var b []any
-				err := json.Unmarshal([]byte(co.Value), &b)
-				if err != nil {
-					return nil, fmt.Errorf("failed to unmarshal array constants[%d] %s: %w", coi, spew.Sdump(co), err)
+				if !isIntegerConstantArrayItem(ty.Type) || json.Unmarshal([]byte(co.Value), &b) != nil {
+					// Arrays of other types (e.g. nested arrays, pubkeys and defined types),
+					// and values that aren't JSON (e.g. b"seed" or [0; 32]):
+					if err := g.gen_constantVar(code, coi, co); err != nil {
+						return nil, err
+					}
+					break
 				}
 				size, ok := ty.Size.(*idltype.IdlArrayLenValue)
 				if !ok {
@@ -333,9 +362,14 @@ func (g *Generator) gen_constants() (*OutputFile, error) {
 					code.Const().Id(co.Name).Op("=").Lit(int64(v))
 					code.Line()
 				default:
-					// For other defined types, we could try to resolve them,
-					// but for now, we'll return an error with more specific information
-					return nil, fmt.Errorf("unsupported defined type '%s' for constants[%d] %s: %T", ty.Name, coi, spew.Sdump(co), ty)
+					// Structs, enums and aliases defined in the IDL:
+					if err := g.gen_constantVar(code, coi, co); err != nil {
+						return nil, err
+					}
+				}
+			case *idltype.Vec, *idltype.Option, *idltype.COption:
+				if err := g.gen_constantVar(code, coi, co); err != nil {
+					return nil, err
 				}
 
 			default:
@@ -349,3 +383,382 @@ func (g *Generator) gen_constants() (*OutputFile, error) {
 		File: file,
 	}, nil
 }
+
+// isIntegerConstantArrayItem reports whether arrays of ty are declared from a JSON value.
+func isIntegerConstantArrayItem(ty idltype.IdlType) bool {
+	switch ty.(type) {
+	case *idltype.U8, *idltype.I8, *idltype.U16, *idltype.I16, *idltype.U32, *idltype.I32, *idltype.U64, *idltype.I64:
+		return true
+	}
+	return false
+}
+
+// gen_constantVar declares the constant co, whose value is a Rust expression
+// (e.g. a struct, a nested array or a byte string), as a typed var.
+func (g *Generator) gen_constantVar(code *Statement, coi int, co idl.IdlConst) error {
+	v, err := parseRustValue(co.Value)
+	if err != nil {
+		return fmt.Errorf("failed to parse constants[%d] %s: %w", coi, spew.Sdump(co), err)
+	}
+	value, err := g.gen_constantValue(co.Ty, v, true)
+	if err != nil {
+		return fmt.Errorf("unsupported value for constants[%d] %s: %w", coi, spew.Sdump(co), err)
+	}
+	code.Var().Id(co.Name).Add(genValueTypeName(co.Ty)).Op("=").Add(value)
+	code.Line()
+	return nil
+}
+
+// gen_constantValue returns the Go expression of the value v of type ty;
+// pointer is true if ty is the type of a constant or of a field, where
+// options are declared as pointers (see genValueTypeName).
+func (g *Generator) gen_constantValue(ty idltype.IdlType, v *rustValue, pointer bool) (Code, error) {
+	switch ty := ty.(type) {
+	case *idltype.Bool:
+		if v.kind != rustIdent || (v.text != "true" && v.text != "false") {
+			return nil, fmt.Errorf("expected a bool, got %s", v)
+		}
+		return Lit(v.text == "true"), nil
+	case *idltype.U8:
+		return gen_constantInteger(v, 8, false)
+	case *idltype.I8:
+		return gen_constantInteger(v, 8, true)
+	case *idltype.U16:
+		return gen_constantInteger(v, 16, false)
+	case *idltype.I16:
+		return gen_constantInteger(v, 16, true)
+	case *idltype.U32:
+		return gen_constantInteger(v, 32, false)
+	case *idltype.I32:
+		return gen_constantInteger(v, 32, true)
+	case *idltype.U64:
+		return gen_constantInteger(v, 64, false)
+	case *idltype.I64:
+		return gen_constantInteger(v, 64, true)
+	case *idltype.U128, *idltype.I128:
+		_, signed := ty.(*idltype.I128)
+		n, err := parseRustInteger(v, 128, signed)
+		if err != nil {
+			return nil, err
+		}
+		// Two's complement of negative values.
+		n.And(n, new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1)))
+		typeName := "Uint128"
+		if signed {
+			typeName = "Int128"
+		}
+		return Qual(PkgBinary, typeName).Values(Dict{
+			Id("Lo"): Lit(new(big.Int).And(n, new(big.Int).SetUint64(^uint64(0))).Uint64()),
+			Id("Hi"): Lit(new(big.Int).Rsh(n, 64).Uint64()),
+		}), nil
+	case *idltype.U256:
+		if v.kind != rustNumber {
+			return nil, fmt.Errorf("expected an integer, got %s", v)
+		}
+		n, err := numeric.ParseUint256(v.text)
+		if err != nil {
+			return nil, err
+		}
+		return Qual(PkgAnchorGoNumeric, "MustParseUint256").Call(Lit(n.String())), nil
+	case *idltype.I256:
+		if v.kind != rustNumber {
+			return nil, fmt.Errorf("expected an integer, got %s", v)
+		}
+		n, err := numeric.ParseInt256(v.text)
+		if err != nil {
+			return nil, err
+		}
+		return Qual(PkgAnchorGoNumeric, "MustParseInt256").Call(Lit(n.String())), nil
+	case *idltype.F32:
+		f, err := parseRustFloat(v, 32)
+		if err != nil {
+			return nil, err
+		}
+		return Id(strconv.FormatFloat(f, 'g', -1, 32)), nil
+	case *idltype.F64:
+		f, err := parseRustFloat(v, 64)
+		if err != nil {
+			return nil, err
+		}
+		return Id(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	case *idltype.String:
+		if v.kind != rustString {
+			return nil, fmt.Errorf("expected a string, got %s", v)
+		}
+		return Lit(v.text), nil
+	case *idltype.Bytes:
+		b, err := constantBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		return Index().Byte().ValuesFunc(func(group *Group) {
+			for _, byteVal := range b {
+				group.Lit(int(byteVal))
+			}
+		}), nil
+	case *idltype.Pubkey:
+		pk, err := constantPubkey(v)
+		if err != nil {
+			return nil, err
+		}
+		return Qual(PkgSolanaGo, "MustPublicKeyFromBase58").Call(Lit(pk.String())), nil
+	case *idltype.Array:
+		size, ok := ty.Size.(*idltype.IdlArrayLenValue)
+		if !ok {
+			return nil, fmt.Errorf("expected IdlArrayLenValue, got %T", ty.Size)
+		}
+		items, err := constantItems(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) != size.Value {
+			return nil, fmt.Errorf("expected %d elements in the array, got %d", size.Value, len(items))
+		}
+		return g.gen_constantItems(genTypeName(ty), ty.Type, items)
+	case *idltype.Vec:
+		items, err := constantItems(v)
+		if err != nil {
+			return nil, err
+		}
+		return g.gen_constantItems(genTypeName(ty), ty.Vec, items)
+	case *idltype.Option, *idltype.COption:
+		return g.gen_constantOption(ty, v, pointer)
+	case *idltype.Defined:
+		return g.gen_constantDefined(ty, v)
+	}
+	return nil, fmt.Errorf("unsupported type %T", ty)
+}
+
+func gen_constantInteger(v *rustValue, bits int, signed bool) (Code, error) {
+	n, err := parseRustInteger(v, bits, signed)
+	if err != nil {
+		return nil, err
+	}
+	return Id(n.String()), nil
+}
+
+func (g *Generator) gen_constantItems(typeName Code, itemType idltype.IdlType, items []*rustValue) (Code, error) {
+	values := make([]Code, len(items))
+	for i, item := range items {
+		value, err := g.gen_constantValue(itemType, item, false)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		values[i] = value
+	}
+	return Add(typeName).Values(values...), nil
+}
+
+func (g *Generator) gen_constantOption(ty idltype.IdlType, v *rustValue, pointer bool) (Code, error) {
+	inner := optionInnerType(ty)
+	var some *rustValue
+	switch {
+	case v.kind == rustIdent && v.text == "None":
+	case v.kind == rustTuple && v.name == "Some" && len(v.items) == 1:
+		some = v.items[0]
+	default:
+		return nil, fmt.Errorf("expected None or Some(value), got %s", v)
+	}
+	var value Code
+	if some != nil {
+		var err error
+		if value, err = g.gen_constantValue(inner, some, false); err != nil {
+			return nil, err
+		}
+	}
+	if genericOptions {
+		someFunc, noneFunc := "Some", "None"
+		if IsCOption(ty) {
+			someFunc, noneFunc = "SomeC", "NoneC"
+		}
+		if some == nil {
+			return Qual(PkgAnchorGoOption, noneFunc).Types(genTypeName(inner)).Call(), nil
+		}
+		return Qual(PkgAnchorGoOption, someFunc).Types(genTypeName(inner)).Call(value), nil
+	}
+	if !pointer {
+		// Nested options are declared as their inner type.
+		if some == nil {
+			return Op("*").New(genTypeName(inner)), nil
+		}
+		return value, nil
+	}
+	if some == nil {
+		return Nil(), nil
+	}
+	return Func().Params().Op("*").Add(genTypeName(inner)).Block(
+		Var().Id("value").Add(genTypeName(inner)).Op("=").Add(value),
+		Return(Op("&").Id("value")),
+	).Call(), nil
+}
+
+func (g *Generator) gen_constantDefined(ty *idltype.Defined, v *rustValue) (Code, error) {
+	switch ty.Name {
+	case "usize":
+		return gen_constantInteger(v, 64, false)
+	case "isize":
+		return gen_constantInteger(v, 64, true)
+	}
+	var def *idl.IdlTypeDef
+	for i := range g.idl.Types {
+		if g.idl.Types[i].Name == ty.Name {
+			def = &g.idl.Types[i]
+			break
+		}
+	}
+	if def == nil {
+		return nil, fmt.Errorf("unsupported defined type '%s'", ty.Name)
+	}
+	typeName := tools.ToCamelUpper(def.Name)
+	switch defTy := def.Ty.(type) {
+	case *idl.IdlTypeDefTyStruct:
+		return g.gen_constantFields(typeName, defTy.Fields, v)
+	case *idl.IdlTypeDefTyType:
+		value, err := g.gen_constantValue(defTy.Alias, v, false)
+		if err != nil {
+			return nil, err
+		}
+		return Id(typeName).Call(value), nil
+	case *idl.IdlTypeDefTyEnum:
+		variantName := v.name
+		if v.kind == rustIdent {
+			variantName = v.text
+		}
+		var variant *idl.IdlEnumVariant
+		for i := range defTy.Variants {
+			if defTy.Variants[i].Name == variantName {
+				variant = &defTy.Variants[i]
+				break
+			}
+		}
+		if variant == nil || (v.kind != rustIdent && v.kind != rustTuple && v.kind != rustStruct) {
+			return nil, fmt.Errorf("expected a variant of %s, got %s", def.Name, v)
+		}
+		if defTy.IsAllSimple() {
+			if v.kind != rustIdent {
+				return nil, fmt.Errorf("variant %s of %s has no fields", variant.Name, def.Name)
+			}
+			return Id(formatSimpleEnumVariantName(variant.Name, def.Name)), nil
+		}
+		variantTypeName := formatComplexEnumVariantTypeName(def.Name, variant.Name)
+		if !variant.Fields.IsSome() {
+			if v.kind != rustIdent {
+				return nil, fmt.Errorf("variant %s of %s has no fields", variant.Name, def.Name)
+			}
+			return New(Id(variantTypeName)), nil
+		}
+		value, err := g.gen_constantFields(variantTypeName, variant.Fields.Unwrap(), v)
+		if err != nil {
+			return nil, err
+		}
+		return Op("&").Add(value), nil
+	}
+	return nil, fmt.Errorf("unsupported defined type '%s' of kind %T", ty.Name, def.Ty)
+}
+
+// gen_constantFields returns the composite literal of the struct typeName with the given fields.
+func (g *Generator) gen_constantFields(typeName string, fields idl.IdlDefinedFields, v *rustValue) (Code, error) {
+	values := Dict{}
+	switch fields := fields.(type) {
+	case idl.IdlDefinedFieldsNamed:
+		if v.kind != rustStruct {
+			return nil, fmt.Errorf("expected the fields of %s, got %s", typeName, v)
+		}
+		fieldValues := make(map[string]*rustValue, len(v.fields))
+		for _, field := range v.fields {
+			fieldValues[field.name] = field.value
+		}
+		if len(fieldValues) != len(v.fields) || len(fieldValues) != len(fields) {
+			return nil, fmt.Errorf("expected the %d fields of %s, got %d", len(fields), typeName, len(v.fields))
+		}
+		fieldNames := generateUniqueFieldNames(fields)
+		for _, field := range fields {
+			fieldValue, ok := fieldValues[field.Name]
+			if !ok {
+				return nil, fmt.Errorf("missing field %s of %s", field.Name, typeName)
+			}
+			value, err := g.gen_constantValue(field.Ty, fieldValue, true)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of %s: %w", field.Name, typeName, err)
+			}
+			values[Id(fieldNames[field.Name])] = value
+		}
+	case idl.IdlDefinedFieldsTuple:
+		if v.kind != rustTuple || len(v.items) != len(fields) {
+			return nil, fmt.Errorf("expected the %d fields of %s, got %s", len(fields), typeName, v)
+		}
+		for i, fieldType := range fields {
+			value, err := g.gen_constantValue(fieldType, v.items[i], true)
+			if err != nil {
+				return nil, fmt.Errorf("field %d of %s: %w", i, typeName, err)
+			}
+			values[Id(FormatTupleItemName(i))] = value
+		}
+	case nil:
+		if v.kind != rustIdent {
+			return nil, fmt.Errorf("expected the unit struct %s, got %s", typeName, v)
+		}
+	}
+	return Id(typeName).Values(values), nil
+}
+
+// constantItems returns the items of an array (or of a byte string).
+func constantItems(v *rustValue) ([]*rustValue, error) {
+	switch v.kind {
+	case rustArray:
+		return v.items, nil
+	case rustBytes:
+		items := make([]*rustValue, len(v.bytes))
+		for i, b := range v.bytes {
+			items[i] = &rustValue{kind: rustNumber, text: strconv.Itoa(int(b))}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("expected an array, got %s", v)
+}
+
+// constantBytes returns the bytes of a byte string (or of an array of bytes).
+func constantBytes(v *rustValue) ([]byte, error) {
+	if v.kind == rustBytes {
+		return v.bytes, nil
+	}
+	items, err := constantItems(v)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, len(items))
+	for i, item := range items {
+		n, err := parseRustInteger(item, 8, false)
+		if err != nil {
+			return nil, fmt.Errorf("item %d: %w", i, err)
+		}
+		b[i] = byte(n.Uint64())
+	}
+	return b, nil
+}
+
+// constantPubkey parses a public key written as a base58 string (e.g. `pubkey!("...")`),
+// as its bytes (e.g. `Pubkey::new_from_array([...])`), or as `Pubkey::default()`.
+func constantPubkey(v *rustValue) (solana.PublicKey, error) {
+	switch v.kind {
+	case rustString, rustIdent, rustNumber:
+		return solana.PublicKeyFromBase58(v.text)
+	case rustTuple:
+		if v.name == "default" && len(v.items) == 0 {
+			return solana.PublicKey{}, nil
+		}
+		if len(v.items) == 1 {
+			return constantPubkey(v.items[0])
+		}
+	case rustArray, rustBytes:
+		b, err := constantBytes(v)
+		if err != nil {
+			return solana.PublicKey{}, err
+		}
+		if len(b) != solana.PublicKeyLength {
+			return solana.PublicKey{}, fmt.Errorf("expected %d bytes in the public key, got %d", solana.PublicKeyLength, len(b))
+		}
+		return solana.PublicKeyFromBytes(b), nil
+	}
+	return solana.PublicKey{}, fmt.Errorf("expected a public key, got %s", v)
+}
@@ -208,6 +208,32 @@ func TestGenConstants(t *testing.T) {
 				".SetString(\"-1000000000000\", 10)",
 			},
 		},
+		{
+			name: "u256 constant",
+			constants: []idl.IdlConst{
+				{
+					Name:  "MAX_SUPPLY",
+					Ty:    &idltype.U256{},
+					Value: "1_000_000_000_000_000_000_000_000_000_000_000_000_000",
+				},
+			},
+			expectCode: []string{
+				"var MAX_SUPPLY = numeric.MustParseUint256(\"1000000000000000000000000000000000000000\")",
+			},
+		},
+		{
+			name: "i256 constant",
+			constants: []idl.IdlConst{
+				{
+					Name:  "MIN_DELTA",
+					Ty:    &idltype.I256{},
+					Value: "-1_000_000",
+				},
+			},
+			expectCode: []string{
+				"var MIN_DELTA = numeric.MustParseInt256(\"-1000000\")",
+			},
+		},
 		{
 			name: "Bytes constant",
 			constants: []idl.IdlConst{
@@ -316,6 +342,17 @@ func TestGenConstants(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "Negative u256",
+			constants: []idl.IdlConst{
+				{
+					Name:  "INVALID_U256",
+					Ty:    &idltype.U256{},
+					Value: "-1",
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -806,3 +843,115 @@ func TestGenConstantsRealWorldExamples(t *testing.T) {
 		assert.Contains(t, generatedCode, "var SIGNATURE_SEED = [8]byte{uint8(0x73), uint8(0x69), uint8(0x67), uint8(0x6e), uint8(0x61), uint8(0x74), uint8(0x75), uint8(0x72)}")
 	})
 }
+
+func TestGenConstantsOfDefinedAndNestedTypes(t *testing.T) {
+	types := idl.IdTypeDef_slice{
+		{
+			Name: "Config",
+			Ty: &idl.IdlTypeDefTyStruct{
+				Fields: idl.IdlDefinedFieldsNamed{
+					{Name: "admin", Ty: &idltype.Pubkey{}},
+					{Name: "fee_bps", Ty: &idltype.U16{}},
+					{Name: "limit", Ty: &idltype.Option{Option: &idltype.U64{}}},
+					{Name: "mode", Ty: &idltype.Defined{Name: "Mode"}},
+				},
+			},
+		},
+		{
+			Name: "Pair",
+			Ty: &idl.IdlTypeDefTyStruct{
+				Fields: idl.IdlDefinedFieldsTuple{&idltype.U8{}, &idltype.String{}},
+			},
+		},
+		{
+			Name: "Mode",
+			Ty: &idl.IdlTypeDefTyEnum{
+				Variants: idl.VariantSlice{{Name: "Open"}, {Name: "Closed"}},
+			},
+		},
+	}
+	constants := []idl.IdlConst{
+		{
+			Name:  "DEFAULT_CONFIG",
+			Ty:    &idltype.Defined{Name: "Config"},
+			Value: `Config { admin : pubkey ! ("11111111111111111111111111111111") , fee_bps : 30 , limit : Some (1_000u64) , mode : Mode :: Closed }`,
+		},
+		{
+			Name:  "PAIR",
+			Ty:    &idltype.Defined{Name: "Pair"},
+			Value: `Pair (1 , "one")`,
+		},
+		{
+			Name:  "DEFAULT_MODE",
+			Ty:    &idltype.Defined{Name: "Mode"},
+			Value: `Mode :: Open`,
+		},
+		{
+			Name: "MATRIX",
+			Ty: &idltype.Array{
+				Type: &idltype.Array{Type: &idltype.U8{}, Size: &idltype.IdlArrayLenValue{Value: 2}},
+				Size: &idltype.IdlArrayLenValue{Value: 2},
+			},
+			Value: "[[1 , 2] , [3 , 4]]",
+		},
+		{
+			Name: "ADMINS",
+			Ty: &idltype.Array{
+				Type: &idltype.Pubkey{},
+				Size: &idltype.IdlArrayLenValue{Value: 1},
+			},
+			Value: `[pubkey ! ("SysvarRent111111111111111111111111111111111")]`,
+		},
+		{
+			Name:  "SEED",
+			Ty:    &idltype.Bytes{},
+			Value: `b"seed"`,
+		},
+		{
+			Name:  "FEES",
+			Ty:    &idltype.Vec{Vec: &idltype.U16{}},
+			Value: "vec ! [1 , 2]",
+		},
+	}
+	gen := &Generator{
+		idl:     &idl.Idl{Types: types, Constants: constants},
+		options: &GeneratorOptions{Package: "test"},
+	}
+	outputFile, err := gen.gen_constants()
+	require.NoError(t, err)
+	generatedCode := outputFile.File.GoString()
+
+	for _, expectedCode := range []string{
+		"var DEFAULT_CONFIG Config = Config{",
+		`Admin:  solanago.MustPublicKeyFromBase58("11111111111111111111111111111111"),`,
+		"FeeBps: 30,",
+		"var value uint64 = 1000",
+		"Mode: Mode_Closed,",
+		"var PAIR Pair = Pair{",
+		`V1: "one",`,
+		"var DEFAULT_MODE Mode = Mode_Open",
+		"var MATRIX [2][2]uint8 = [2][2]uint8{[2]uint8{1, 2}, [2]uint8{3, 4}}",
+		`var ADMINS [1]solanago.PublicKey = [1]solanago.PublicKey{solanago.MustPublicKeyFromBase58("SysvarRent111111111111111111111111111111111")}`,
+		"var SEED []byte = []byte{115, 101, 101, 100}",
+		"var FEES []uint16 = []uint16{1, 2}",
+	} {
+		assert.Contains(t, generatedCode, expectedCode, "Generated code:\n%s", generatedCode)
+	}
+
+	t.Run("Invalid values", func(t *testing.T) {
+		for _, co := range []idl.IdlConst{
+			{Name: "MISSING_FIELD", Ty: &idltype.Defined{Name: "Config"}, Value: `Config { admin : Pubkey :: default () }`},
+			{Name: "UNKNOWN_VARIANT", Ty: &idltype.Defined{Name: "Mode"}, Value: `Mode :: Unknown`},
+			{Name: "TUPLE_ARITY", Ty: &idltype.Defined{Name: "Pair"}, Value: `Pair (1)`},
+			{Name: "SHORT_PUBKEY", Ty: &idltype.Pubkey{}, Value: `Pubkey :: new_from_array ([1 ; 31])`},
+			{Name: "OVERFLOW", Ty: &idltype.Vec{Vec: &idltype.U8{}}, Value: "[1 , 256]"},
+		} {
+			gen := &Generator{
+				idl:     &idl.Idl{Types: types, Constants: []idl.IdlConst{co}},
+				options: &GeneratorOptions{Package: "test"},
+			}
+			_, err := gen.gen_constants()
+			assert.Error(t, err, co.Name)
+		}
+	})
+}
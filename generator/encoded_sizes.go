@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+)
+
+// typeRegistryMinEncodedSize contains the minimum number of bytes taken by the borsh
+// encoding of each defined type; the decoders check the declared length of vectors against it.
+var typeRegistryMinEncodedSize = make(map[string]int)
+
+// typeRegistryZeroEncodedSize contains the number of bytes taken by the borsh encoding
+// of the all-zero value of each defined type (e.g. empty vectors, None options and
+// the first variant of enums); the lenient account parsers zero-fill that many bytes.
+var typeRegistryZeroEncodedSize = make(map[string]int)
+
+// registerMinEncodedSizes computes the minimum and the zero encoded size of all the defined types.
+func registerMinEncodedSizes(idlObj *idl.Idl) {
+	// Types can contain types declared after them: iterate until the sizes settle
+	// (types can't contain themselves other than through a vector or an option).
+	for range len(idlObj.Types) + 1 {
+		changed := false
+		for _, def := range idlObj.Types {
+			minSize, zeroSize := 1, 1
+			switch vv := def.Ty.(type) {
+			case *idl.IdlTypeDefTyStruct:
+				minSize = fieldsEncodedSize(vv.Fields, minEncodedSize)
+				zeroSize = fieldsEncodedSize(vv.Fields, zeroEncodedSize)
+			case *idl.IdlTypeDefTyType:
+				minSize = minEncodedSize(vv.Alias)
+				zeroSize = zeroEncodedSize(vv.Alias)
+			case *idl.IdlTypeDefTyEnum:
+				if len(vv.Variants) > 0 && vv.Variants[0].Fields.IsSome() {
+					zeroSize += fieldsEncodedSize(vv.Variants[0].Fields.Unwrap(), zeroEncodedSize)
+				}
+			}
+			registeredMinSize, registered := typeRegistryMinEncodedSize[def.Name]
+			if !registered || registeredMinSize != minSize || typeRegistryZeroEncodedSize[def.Name] != zeroSize {
+				typeRegistryMinEncodedSize[def.Name] = minSize
+				typeRegistryZeroEncodedSize[def.Name] = zeroSize
+				changed = true
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+func fieldsEncodedSize(fields idl.IdlDefinedFields, encodedSize func(ty idltype.IdlType) int) int {
+	size := 0
+	switch fields := fields.(type) {
+	case idl.IdlDefinedFieldsNamed:
+		for _, field := range fields {
+			size += encodedSize(field.Ty)
+		}
+	case idl.IdlDefinedFieldsTuple:
+		for _, ty := range fields {
+			size += encodedSize(ty)
+		}
+	}
+	return size
+}
+
+// minEncodedSize returns the minimum number of bytes taken by the borsh encoding of a value of type ty.
+func minEncodedSize(ty idltype.IdlType) int {
+	switch vv := ty.(type) {
+	case *idltype.Bool, *idltype.U8, *idltype.I8:
+		return 1
+	case *idltype.U16, *idltype.I16:
+		return 2
+	case *idltype.U32, *idltype.I32, *idltype.F32:
+		return 4
+	case *idltype.U64, *idltype.I64, *idltype.F64:
+		return 8
+	case *idltype.U128, *idltype.I128:
+		return 16
+	case *idltype.U256, *idltype.I256, *idltype.Pubkey:
+		return 32
+	case *idltype.String, *idltype.Bytes, *idltype.Vec:
+		// The length prefix.
+		return 4
+	case *idltype.Option:
+		return 1
+	case *idltype.COption:
+		return 4
+	case *idltype.Array:
+		if size, ok := vv.Size.(*idltype.IdlArrayLenValue); ok {
+			return size.Value * minEncodedSize(vv.Type)
+		}
+	case *idltype.Defined:
+		if size, ok := typeRegistryMinEncodedSize[vv.Name]; ok {
+			return size
+		}
+	}
+	// Enums (the variant index) and unknown types take at least one byte.
+	return 1
+}
+
+// zeroEncodedSize returns the number of bytes taken by the borsh encoding of the all-zero value of type ty.
+func zeroEncodedSize(ty idltype.IdlType) int {
+	switch vv := ty.(type) {
+	case *idltype.Array:
+		if size, ok := vv.Size.(*idltype.IdlArrayLenValue); ok {
+			return size.Value * zeroEncodedSize(vv.Type)
+		}
+	case *idltype.Defined:
+		if size, ok := typeRegistryZeroEncodedSize[vv.Name]; ok {
+			return size
+		}
+		return 1
+	}
+	// The zero value of the other types is as small as it gets.
+	return minEncodedSize(ty)
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinEncodedSize(t *testing.T) {
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				// Declared before the types it contains:
+				Name: "SizeOuter",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "inner", Ty: &idltype.Array{Type: &idltype.Defined{Name: "SizeInner"}, Size: &idltype.IdlArrayLenValue{Value: 2}}},
+						{Name: "alias", Ty: &idltype.Defined{Name: "SizeAlias"}},
+						{Name: "kind", Ty: &idltype.Defined{Name: "SizeKind"}},
+						{Name: "children", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "SizeOuter"}}},
+					},
+				},
+			},
+			{
+				Name: "SizeAlias",
+				Ty: &idl.IdlTypeDefTyType{
+					Kind:  "type",
+					Alias: &idltype.Defined{Name: "SizeInner"},
+				},
+			},
+			{
+				Name: "SizeInner",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsTuple{
+						&idltype.Pubkey{},
+						&idltype.U64{},
+						&idltype.Option{Option: &idltype.U128{}},
+						&idltype.COption{COption: &idltype.U128{}},
+					},
+				},
+			},
+			{
+				Name: "SizeKind",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind: "enum",
+					Variants: idl.VariantSlice{
+						{Name: "A", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsTuple{&idltype.Defined{Name: "SizeInner"}})},
+						{Name: "B"},
+					},
+				},
+			},
+		},
+	}
+	registerMinEncodedSizes(idlData)
+
+	assert.Equal(t, 45, minEncodedSize(&idltype.Defined{Name: "SizeInner"}))
+	assert.Equal(t, 45, minEncodedSize(&idltype.Defined{Name: "SizeAlias"}))
+	assert.Equal(t, 1, minEncodedSize(&idltype.Defined{Name: "SizeKind"}))
+	assert.Equal(t, 2*45+45+1+4, minEncodedSize(&idltype.Defined{Name: "SizeOuter"}))
+	assert.Equal(t, 1, minEncodedSize(&idltype.Defined{Name: "SizeUnknown"}))
+	assert.Equal(t, 4, minEncodedSize(&idltype.String{}))
+
+	// The zero value of enums is the first variant:
+	assert.Equal(t, 1+45, zeroEncodedSize(&idltype.Defined{Name: "SizeKind"}))
+	assert.Equal(t, 2*45+45+(1+45)+4, zeroEncodedSize(&idltype.Defined{Name: "SizeOuter"}))
+}
@@ -0,0 +1,228 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+func formatEnumKindName(enumTypeName string) string {
+	return enumTypeName + "Kind"
+}
+
+// enumKindName returns the name of the <Enum>Kind simple enum of a complex enum:
+// <Enum>VariantKind if the IDL has a type named <Enum>Kind, or an error if that's taken too.
+func (g *Generator) enumKindName(enumTypeName string) (string, error) {
+	goNames := make(map[string]string)
+	for _, def := range g.idl.Types {
+		goNames[tools.ToCamelUpper(def.Name)] = def.Name
+	}
+	kindName := formatEnumKindName(enumTypeName)
+	if _, ok := goNames[kindName]; !ok {
+		return kindName, nil
+	}
+	variantKindName := enumTypeName + "VariantKind"
+	if _, ok := goNames[variantKindName]; !ok {
+		return variantKindName, nil
+	}
+	return "", fmt.Errorf("the kind of the variants of %s would be generated as %s or %s, which are already the names of types %q and %q (rename one of them)",
+		enumTypeName, kindName, variantKindName, goNames[kindName], goNames[variantKindName])
+}
+
+// formatEnumKindMethodName returns the name of the method that returns the kind of a variant:
+// "Kind", unless a variant has a field with that name.
+func formatEnumKindMethodName(typ idl.IdlTypeDefTyEnum) string {
+	for _, variant := range typ.Variants {
+		if !variant.Fields.IsSome() {
+			continue
+		}
+		if fields, ok := variant.Fields.Unwrap().(idl.IdlDefinedFieldsNamed); ok {
+			for _, field := range fields {
+				if tools.ToCamelUpper(field.Name) == "Kind" {
+					return "VariantKind"
+				}
+			}
+		}
+	}
+	return "Kind"
+}
+
+// hasEnumStringMethod tells whether the variants of a complex enum have a String method,
+// which they can't have if a variant has a field named String.
+func hasEnumStringMethod(typ idl.IdlTypeDefTyEnum) bool {
+	for _, variant := range typ.Variants {
+		if !variant.Fields.IsSome() {
+			continue
+		}
+		if fields, ok := variant.Fields.Unwrap().(idl.IdlDefinedFieldsNamed); ok {
+			for _, field := range fields {
+				if tools.ToCamelUpper(field.Name) == "String" {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func formatEnumVisitorName(enumTypeName string) string {
+	return enumTypeName + "Visitor"
+}
+
+func formatEnumVisitFuncName(enumTypeName string) string {
+	return "Visit" + enumTypeName
+}
+
+func formatEnumVisitorMethodName(variantName string) string {
+	return "Visit" + tools.ToCamelUpper(variantName)
+}
+
+func formatEnumVariantConstructorName(enumTypeName string, variantName string) string {
+	return "New" + enumTypeName + tools.ToCamelUpper(variantName)
+}
+
+// gen_complexEnumAPI generates the helpers to work with the variants of a complex enum:
+//   - the <Enum>Kind simple enum (<Enum>VariantKind if the IDL has a type named <Enum>Kind),
+//     returned by the Kind() method of each variant (VariantKind() if a variant has a field named Kind);
+//   - a String method per variant, e.g. `Circle{radius: 5}`, `Rect(3, 4)`, or `Empty`
+//     (unless a variant has a field named String);
+//   - a New<Enum><Variant> constructor per variant;
+//   - the <Enum>Visitor interface (one method per variant) and the Visit<Enum> function,
+//     so that adding a variant to the IDL breaks the build of the visitors that don't handle it.
+func (g *Generator) gen_complexEnumAPI(enumTypeName string, kindName string, typ idl.IdlTypeDefTyEnum) (Code, error) {
+	code := Empty()
+
+	// The kind of the variants:
+	kindCode, err := g.gen_simpleEnum(
+		kindName,
+		[]string{fmt.Sprintf("%s identifies the variant of a %s.", kindName, enumTypeName)},
+		idl.IdlTypeDefTyEnum{Kind: "enum", Variants: typ.Variants},
+	)
+	if err != nil {
+		return nil, err
+	}
+	code.Add(kindCode).Line()
+	for _, variant := range typ.Variants {
+		code.Line().Func().Params(Id("_").Op("*").Id(formatComplexEnumVariantTypeName(enumTypeName, variant.Name))).Id(formatEnumKindMethodName(typ)).
+			Params().
+			Params(Id(kindName)).
+			Block(
+				Return(Id(formatSimpleEnumVariantName(variant.Name, kindName))),
+			).Line()
+	}
+
+	// The stringers:
+	if hasEnumStringMethod(typ) {
+		for _, variant := range typ.Variants {
+			code.Line().Add(gen_complexEnumVariantString(enumTypeName, kindName, variant)).Line()
+		}
+	}
+
+	// The constructors:
+	for _, variant := range typ.Variants {
+		variantTypeName := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
+		constructorName := formatEnumVariantConstructorName(enumTypeName, variant.Name)
+
+		var fields idl.IdlDefinedFieldsNamed
+		if variant.Fields.IsSome() {
+			switch vv := variant.Fields.Unwrap().(type) {
+			case idl.IdlDefinedFieldsNamed:
+				fields = vv
+			case idl.IdlDefinedFieldsTuple:
+				fields = tupleToFieldsNamed(vv)
+			}
+		}
+		code.Line().Commentf("%s returns the %q variant of %s.", constructorName, variant.Name, enumTypeName)
+		code.Line().Func().Id(constructorName).
+			ParamsFunc(func(params *Group) {
+				for _, field := range fields {
+					params.Id(formatParamName(field.Name)).Add(genValueTypeName(field.Ty))
+				}
+			}).
+			Params(Id(enumTypeName)).
+			BlockFunc(func(body *Group) {
+				if variant.IsSimple() {
+					body.Return(New(Id(variantTypeName)))
+					return
+				}
+				body.Return(Op("&").Id(variantTypeName).ValuesFunc(func(values *Group) {
+					for _, field := range fields {
+						values.Line().Id(tools.ToCamelUpper(field.Name)).Op(":").Id(formatParamName(field.Name))
+					}
+					values.Line()
+				}))
+			}).Line()
+	}
+
+	// The visitor:
+	visitorName := formatEnumVisitorName(enumTypeName)
+	code.Line().Commentf("%s has one method per variant of %s; see %s.", visitorName, enumTypeName, formatEnumVisitFuncName(enumTypeName))
+	code.Line().Type().Id(visitorName).InterfaceFunc(func(group *Group) {
+		for _, variant := range typ.Variants {
+			group.Id(formatEnumVisitorMethodName(variant.Name)).
+				Params(Id("value").Op("*").Id(formatComplexEnumVariantTypeName(enumTypeName, variant.Name))).
+				Error()
+		}
+	}).Line()
+
+	code.Line().Commentf("%s calls the method of the visitor that corresponds to the variant of value.", formatEnumVisitFuncName(enumTypeName))
+	code.Line().Func().Id(formatEnumVisitFuncName(enumTypeName)).
+		Params(Id("value").Id(enumTypeName), Id("visitor").Id(visitorName)).
+		Error().
+		Block(
+			Switch(Id("realvalue").Op(":=").Id("value").Op(".").Parens(Type())).BlockFunc(func(switchGroup *Group) {
+				for _, variant := range typ.Variants {
+					switchGroup.Case(Op("*").Id(formatComplexEnumVariantTypeName(enumTypeName, variant.Name))).Line().
+						Return(Id("visitor").Dot(formatEnumVisitorMethodName(variant.Name)).Call(Id("realvalue")))
+				}
+				switchGroup.Default().Line().Return(Qual("fmt", "Errorf").Call(Lit(enumTypeName+": unknown variant type %T"), Id("value")))
+			}),
+		).Line()
+	return code, nil
+}
+
+// gen_complexEnumVariantString generates the String method of a variant of a complex enum,
+// which formats it like Rust's Debug: `Circle{radius: 5}`, `Rect(3, 4)`, or `Empty`.
+func gen_complexEnumVariantString(enumTypeName string, kindName string, variant idl.IdlEnumVariant) Code {
+	variantTypeName := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
+	if variant.IsSimple() {
+		return Func().Params(Id("_").Op("*").Id(variantTypeName)).Id("String").Params().String().Block(
+			Return(Id(formatSimpleEnumVariantName(variant.Name, kindName)).Dot("String").Call()),
+		)
+	}
+	var format string
+	var args []Code
+	fieldArg := func(fieldName string, ty idltype.IdlType) Code {
+		if !isPointerOption(ty) {
+			return Id("value").Dot(fieldName)
+		}
+		// Like option.Option.String:
+		return Func().Params().String().Block(
+			If(Id("value").Dot(fieldName).Op("==").Nil()).Block(Return(Lit("None"))),
+			Return(Qual("fmt", "Sprintf").Call(Lit("Some(%v)"), Op("*").Id("value").Dot(fieldName))),
+		).Call()
+	}
+	switch fields := variant.Fields.Unwrap().(type) {
+	case idl.IdlDefinedFieldsNamed:
+		parts := make([]string, len(fields))
+		for i, field := range fields {
+			parts[i] = field.Name + ": %v"
+			args = append(args, fieldArg(tools.ToCamelUpper(field.Name), field.Ty))
+		}
+		format = variant.Name + "{" + strings.Join(parts, ", ") + "}"
+	case idl.IdlDefinedFieldsTuple:
+		parts := make([]string, len(fields))
+		for i, ty := range fields {
+			parts[i] = "%v"
+			args = append(args, fieldArg(FormatTupleItemName(i), ty))
+		}
+		format = variant.Name + "(" + strings.Join(parts, ", ") + ")"
+	}
+	return Func().Params(Id("value").Op("*").Id(variantTypeName)).Id("String").Params().String().Block(
+		Return(Qual("fmt", "Sprintf").Call(append([]Code{Lit(format)}, args...)...)),
+	)
+}
@@ -0,0 +1,379 @@
+package generator
+
+import (
+	"strconv"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// Complex enums are encoded to JSON as tagged unions, like in the Anchor TypeScript client:
+//
+//	{"VariantName": {...}}
+//
+// Unit variants are encoded as `{"VariantName": {}}`, and a nil enum as `null`.
+// Since the Go value of a complex enum is an interface, which encoding/json can't
+// decode, the structs (and named types) that contain complex enums get their own
+// MarshalJSON/UnmarshalJSON methods that use the enum's JSON marshaler/unmarshaler.
+
+// gen_complexEnumJSON generates the JSON marshaler and unmarshaler functions of a complex enum.
+func gen_complexEnumJSON(enumTypeName string, variants idl.VariantSlice) Code {
+	code := Empty()
+
+	code.Commentf("%s encodes the given %s as `{\"VariantName\": {...}}`.", formatEnumJSONMarshalerName(enumTypeName), enumTypeName)
+	code.Line().Func().Id(formatEnumJSONMarshalerName(enumTypeName)).
+		Params(Id("value").Id(enumTypeName)).
+		Params(Index().Byte(), Error()).
+		BlockFunc(func(body *Group) {
+			body.Switch(Id("realvalue").Op(":=").Id("value").Op(".").Parens(Type())).BlockFunc(func(switchGroup *Group) {
+				switchGroup.Case(Nil()).Line().Return(Index().Byte().Parens(Lit("null")), Nil())
+				for _, variant := range variants {
+					var payload Code
+					if variant.IsSimple() {
+						payload = Struct().Values()
+					} else {
+						payload = Id("realvalue")
+					}
+					switchGroup.Case(Op("*").Id(formatComplexEnumVariantTypeName(enumTypeName, variant.Name))).Line().Return(
+						Qual("encoding/json", "Marshal").Call(Map(String()).Any().Values(Dict{
+							Lit(variant.Name): payload,
+						})),
+					)
+				}
+				switchGroup.Default().Line().Return(Nil(), Qual("fmt", "Errorf").Call(Lit(enumTypeName+": unknown variant type %T"), Id("value")))
+			})
+		})
+
+	code.Line().Line().Commentf("%s decodes a %s encoded as `{\"VariantName\": {...}}`; `null` is decoded as nil.", formatEnumJSONUnmarshalerName(enumTypeName), enumTypeName)
+	code.Line().Func().Id(formatEnumJSONUnmarshalerName(enumTypeName)).
+		Params(Id("data").Index().Byte()).
+		Params(Id(enumTypeName), Error()).
+		BlockFunc(func(body *Group) {
+			body.Var().Id("variants").Map(String()).Qual("encoding/json", "RawMessage")
+			body.If(
+				Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(Id("data"), Op("&").Id("variants")),
+				Err().Op("!=").Nil(),
+			).Block(
+				Return(Nil(), Qual("fmt", "Errorf").Call(Lit("failed parsing "+enumTypeName+": %w"), Err())),
+			)
+			body.If(Id("variants").Op("==").Nil()).Block(
+				Return(Nil(), Nil()),
+			)
+			body.If(Len(Id("variants")).Op("!=").Lit(1)).Block(
+				Return(Nil(), Qual("fmt", "Errorf").Call(Lit(enumTypeName+": expected exactly one variant, got %d"), Len(Id("variants")))),
+			)
+			body.For(List(Id("name"), Id("raw")).Op(":=").Range().Id("variants")).Block(
+				Switch(Id("name")).BlockFunc(func(switchGroup *Group) {
+					for _, variant := range variants {
+						variantTypeName := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
+						if variant.IsSimple() {
+							switchGroup.Case(Lit(variant.Name)).Line().Return(New(Id(variantTypeName)), Nil())
+							continue
+						}
+						switchGroup.Case(Lit(variant.Name)).Block(
+							Id("variant").Op(":=").New(Id(variantTypeName)),
+							If(
+								Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(Id("raw"), Id("variant")),
+								Err().Op("!=").Nil(),
+							).Block(
+								Return(Nil(), Qual("fmt", "Errorf").Call(Lit("failed parsing "+enumTypeName+" variant "+variant.Name+": %w"), Err())),
+							),
+							Return(Id("variant"), Nil()),
+						)
+					}
+					switchGroup.Default().Line().Return(Nil(), Qual("fmt", "Errorf").Call(Lit(enumTypeName+": unknown variant %q"), Id("name")))
+				}),
+			)
+			body.Return(Nil(), Nil())
+		})
+	return code
+}
+
+// structField is a field of a generated struct, as seen by the JSON codecs
+// and by Equal/Clone.
+type structField struct {
+	Name     string // Go field name.
+	JSONName string // Name in the json struct tag.
+	IdlName  string // Name in the IDL, used in the paths of the errors.
+	Ty       idltype.IdlType
+}
+
+// containsComplexEnum tells whether ty is a complex enum, or an option, vector or array
+// (at any depth) of complex enums.
+func containsComplexEnum(ty idltype.IdlType) bool {
+	switch vv := ty.(type) {
+	case *idltype.Vec:
+		return containsComplexEnum(vv.Vec)
+	case *idltype.Array:
+		return containsComplexEnum(vv.Type)
+	case *idltype.Option, *idltype.COption:
+		return containsComplexEnum(optionInnerType(vv))
+	default:
+		return isComplexEnum(ty)
+	}
+}
+
+// namedStructFields returns the Go view of the given fields.
+// Tuple fields are named like in tupleToFieldsNamed.
+func namedStructFields(fields idl.IdlDefinedFields, goName func(field idl.IdlField) string, jsonName func(field idl.IdlField) string) []structField {
+	var named []idl.IdlField
+	switch fields := fields.(type) {
+	case idl.IdlDefinedFieldsNamed:
+		named = fields
+	case idl.IdlDefinedFieldsTuple:
+		named = tupleToFieldsNamed(fields)
+	}
+	out := make([]structField, len(named))
+	for i, field := range named {
+		out[i] = structField{
+			Name:     goName(field),
+			JSONName: jsonName(field),
+			IdlName:  field.Name,
+			Ty:       field.Ty,
+		}
+	}
+	return out
+}
+
+// formatFieldGoName returns the Go name of a (variant or tuple) field.
+func formatFieldGoName(field idl.IdlField) string {
+	return tools.ToCamelUpper(field.Name)
+}
+
+// formatFieldJSONName returns the json struct tag name of a (variant or tuple) field.
+func formatFieldJSONName(field idl.IdlField) string {
+	return tools.ToCamelLower(field.Name)
+}
+
+// gen_complexEnumJSON_struct generates MarshalJSON and UnmarshalJSON for a struct
+// that contains complex enums; it generates nothing if it doesn't contain any.
+//
+// The complex enum fields are shadowed by json.RawMessage fields of a wrapper struct
+// that embeds the original one (converted to a type without methods, to avoid recursion).
+func gen_complexEnumJSON_struct(typeName string, fields []structField) Code {
+	var enumFields []structField
+	for _, field := range fields {
+		if containsComplexEnum(field.Ty) {
+			enumFields = append(enumFields, field)
+		}
+	}
+	code := Empty()
+	if len(enumFields) == 0 {
+		return code
+	}
+	auxType := StructFunc(func(group *Group) {
+		group.Id("alias")
+		for _, field := range enumFields {
+			jsonTag := field.JSONName
+			if isPointerOption(field.Ty) {
+				// Like the tag of the field: None is omitted.
+				jsonTag += ",omitempty"
+			}
+			group.Id(field.Name).Add(genRawJSONTypeName(field.Ty)).Tag(map[string]string{"json": jsonTag})
+		}
+	})
+
+	code.Line().Line().Func().Params(Id("obj").Id(typeName)).Id("MarshalJSON").
+		Params().
+		Params(Index().Byte(), Error()).
+		BlockFunc(func(body *Group) {
+			body.Type().Id("alias").Id(typeName)
+			body.Id("aux").Op(":=").Add(auxType).Values(Dict{
+				Id("alias"): Id("alias").Parens(Id("obj")),
+			})
+			body.Var().Err().Error()
+			for _, field := range enumFields {
+				gen_marshalComplexEnumJSON(body, Id("aux").Dot(field.Name), Id("obj").Dot(field.Name), field.Ty, 0, func(err Code) Code {
+					return Return(Nil(), Qual(PkgAnchorGoErrors, "NewField").Call(Lit(field.IdlName), err))
+				})
+			}
+			body.Return(Qual("encoding/json", "Marshal").Call(Id("aux")))
+		})
+
+	code.Line().Line().Func().Params(Id("obj").Op("*").Id(typeName)).Id("UnmarshalJSON").
+		Params(Id("data").Index().Byte()).
+		Params(Error()).
+		BlockFunc(func(body *Group) {
+			body.Type().Id("alias").Id(typeName)
+			body.Var().Id("aux").Add(auxType)
+			body.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(Id("data"), Op("&").Id("aux"))
+			body.If(Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			)
+			body.Op("*").Id("obj").Op("=").Id(typeName).Parens(Id("aux").Dot("alias"))
+			for _, field := range enumFields {
+				gen_unmarshalComplexEnumJSON(body, Id("obj").Dot(field.Name), Id("aux").Dot(field.Name), field.Ty, 0, func(err Code) Code {
+					return Return(Qual(PkgAnchorGoErrors, "NewField").Call(Lit(field.IdlName), err))
+				})
+			}
+			body.Return(Nil())
+		})
+	return code
+}
+
+// gen_complexEnumJSON_alias generates MarshalJSON and UnmarshalJSON for a named type
+// (see gen_IDLTypeDefTyType) whose underlying type contains complex enums (see containsComplexEnum).
+func gen_complexEnumJSON_alias(typeName string, alias idltype.IdlType) Code {
+	code := Empty()
+	code.Line().Line().Func().Params(Id("obj").Id(typeName)).Id("MarshalJSON").
+		Params().
+		Params(Index().Byte(), Error()).
+		BlockFunc(func(body *Group) {
+			body.Var().Id("raw").Add(genRawJSONTypeName(alias))
+			body.Var().Err().Error()
+			gen_marshalComplexEnumJSON(body, Id("raw"), Id("obj"), alias, 0, func(err Code) Code {
+				return Return(Nil(), err)
+			})
+			body.Return(Qual("encoding/json", "Marshal").Call(Id("raw")))
+		})
+
+	code.Line().Line().Func().Params(Id("obj").Op("*").Id(typeName)).Id("UnmarshalJSON").
+		Params(Id("data").Index().Byte()).
+		Params(Error()).
+		BlockFunc(func(body *Group) {
+			body.Var().Id("raw").Add(genRawJSONTypeName(alias))
+			body.Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(Id("data"), Op("&").Id("raw"))
+			body.If(Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			)
+			body.Var().Id("value").Add(genTypeName(alias))
+			gen_unmarshalComplexEnumJSON(body, Id("value"), Id("raw"), alias, 0, func(err Code) Code {
+				return Return(err)
+			})
+			body.Op("*").Id("obj").Op("=").Id(typeName).Parens(Id("value"))
+			body.Return(Nil())
+		})
+	return code
+}
+
+// genRawJSONTypeName returns the type that holds the JSON encoding of ty
+// (see containsComplexEnum): options are json.RawMessage (or the type of their value,
+// if it's a vector or array), and vectors and arrays are slices and arrays of those.
+func genRawJSONTypeName(ty idltype.IdlType) Code {
+	switch vv := ty.(type) {
+	case *idltype.Vec:
+		return Index().Add(genRawJSONTypeName(vv.Vec))
+	case *idltype.Array:
+		return Index(Id(strconv.Itoa(vv.Size.(*idltype.IdlArrayLenValue).Value))).Add(genRawJSONTypeName(vv.Type))
+	case *idltype.Option, *idltype.COption:
+		return genRawJSONTypeName(optionInnerType(vv))
+	default:
+		return Qual("encoding/json", "RawMessage")
+	}
+}
+
+// gen_marshalComplexEnumJSON encodes src (of type ty, see containsComplexEnum) into dst,
+// of the type returned by genRawJSONTypeName.
+// The depth is the one of ty in the type of the field: only the options of depth 0 are pointers.
+func gen_marshalComplexEnumJSON(body *Group, dst *Statement, src *Statement, ty idltype.IdlType, depth int, onError func(err Code) Code) {
+	switch vv := ty.(type) {
+	case *idltype.Vec:
+		body.If(src.Clone().Op("!=").Nil()).BlockFunc(func(block *Group) {
+			block.Add(dst.Clone()).Op("=").Make(genRawJSONTypeName(ty), Len(src.Clone()))
+			gen_marshalComplexEnumJSONItems(block, dst, src, vv.Vec, depth, onError)
+		})
+	case *idltype.Array:
+		gen_marshalComplexEnumJSONItems(body, dst, src, vv.Type, depth, onError)
+	case *idltype.Option, *idltype.COption:
+		// None is encoded as null, like a nil enum.
+		inner := optionInnerType(vv)
+		switch {
+		case genericOptions:
+			value := formatDepthName("value", depth)
+			body.If(List(Id(value), Id("ok")).Op(":=").Add(src.Clone()).Dot("Get").Call(), Id("ok")).BlockFunc(func(block *Group) {
+				gen_marshalComplexEnumJSON(block, dst, Id(value), inner, depth+1, onError)
+			})
+		case depth == 0:
+			body.If(src.Clone().Op("!=").Nil()).BlockFunc(func(block *Group) {
+				gen_marshalComplexEnumJSON(block, dst, derefOption(src, inner).(*Statement), inner, depth+1, onError)
+			})
+		default:
+			// The nested pointer options hold their value directly.
+			gen_marshalComplexEnumJSON(body, dst, src, inner, depth+1, onError)
+		}
+	default:
+		body.List(dst.Clone(), Err()).Op("=").Id(formatEnumJSONMarshalerName(tools.ToCamelUpper(ty.(*idltype.Defined).Name))).Call(src.Clone())
+		body.If(Err().Op("!=").Nil()).Block(
+			onError(Err()),
+		)
+	}
+}
+
+func gen_marshalComplexEnumJSONItems(body *Group, dst *Statement, src *Statement, itemType idltype.IdlType, depth int, onError func(err Code) Code) {
+	index := formatLoopIndexName(depth)
+	body.For(Id(index).Op(":=").Range().Add(src.Clone())).BlockFunc(func(block *Group) {
+		gen_marshalComplexEnumJSON(block, dst.Clone().Index(Id(index)), src.Clone().Index(Id(index)), itemType, depth+1, func(err Code) Code {
+			return onError(Qual(PkgAnchorGoErrors, "NewIndex").Call(Id(index), err))
+		})
+	})
+}
+
+// gen_unmarshalComplexEnumJSON decodes src (the counterpart of ty returned by genRawJSONTypeName) into dst.
+// Fields missing from the JSON are left as they are.
+func gen_unmarshalComplexEnumJSON(body *Group, dst *Statement, src *Statement, ty idltype.IdlType, depth int, onError func(err Code) Code) {
+	switch vv := ty.(type) {
+	case *idltype.Vec:
+		body.If(src.Clone().Op("!=").Nil()).BlockFunc(func(block *Group) {
+			block.Add(dst.Clone()).Op("=").Make(genTypeName(ty), Len(src.Clone()))
+			gen_unmarshalComplexEnumJSONItems(block, dst, src, vv.Vec, depth, onError)
+		})
+	case *idltype.Array:
+		// Missing items are left as they are.
+		gen_unmarshalComplexEnumJSONItems(body, dst, src, vv.Type, depth, onError)
+	case *idltype.Option, *idltype.COption:
+		inner := optionInnerType(vv)
+		if !genericOptions && depth > 0 {
+			// The nested pointer options hold their value directly.
+			gen_unmarshalComplexEnumJSON(body, dst, src, inner, depth+1, onError)
+			return
+		}
+		// null (or a missing field) is None.
+		value := formatDepthName("value", depth)
+		body.If(src.Clone().Op("!=").Nil()).BlockFunc(func(block *Group) {
+			block.Var().Id(value).Add(genTypeName(inner))
+			gen_unmarshalComplexEnumJSON(block, Id(value), src, inner, depth+1, onError)
+			var some, none Code
+			if genericOptions {
+				someName, noneName := "Some", "None"
+				if IsCOption(vv) {
+					someName, noneName = "SomeC", "NoneC"
+				}
+				some = Qual(PkgAnchorGoOption, someName).Call(Id(value))
+				none = Qual(PkgAnchorGoOption, noneName).Types(genTypeName(inner)).Call()
+			} else {
+				some = Op("&").Id(value)
+				none = Nil()
+			}
+			switch inner.(type) {
+			case *idltype.Array, *idltype.Option, *idltype.COption:
+				block.Add(dst.Clone()).Op("=").Add(some)
+			default:
+				// The value of a null enum or vector is nil.
+				block.If(Id(value).Op("!=").Nil()).Block(
+					dst.Clone().Op("=").Add(some),
+				).Else().Block(
+					dst.Clone().Op("=").Add(none),
+				)
+			}
+		})
+	default:
+		body.If(src.Clone().Op("!=").Nil()).Block(
+			List(dst.Clone(), Err()).Op("=").Id(formatEnumJSONUnmarshalerName(tools.ToCamelUpper(ty.(*idltype.Defined).Name))).Call(src.Clone()),
+			If(Err().Op("!=").Nil()).Block(
+				onError(Err()),
+			),
+		)
+	}
+}
+
+// gen_unmarshalComplexEnumJSONItems decodes the items of src into the items of dst.
+func gen_unmarshalComplexEnumJSONItems(body *Group, dst *Statement, src *Statement, itemType idltype.IdlType, depth int, onError func(err Code) Code) {
+	index := formatLoopIndexName(depth)
+	body.For(Id(index).Op(":=").Range().Add(src.Clone())).BlockFunc(func(block *Group) {
+		gen_unmarshalComplexEnumJSON(block, dst.Clone().Index(Id(index)), src.Clone().Index(Id(index)), itemType, depth+1, func(err Code) Code {
+			return onError(Qual(PkgAnchorGoErrors, "NewIndex").Call(Id(index), err))
+		})
+	})
+}
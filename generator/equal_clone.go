@@ -0,0 +1,390 @@
+package generator
+
+import (
+	"strconv"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// The generated types get Equal and Clone methods (and complex enums get EqualX and CloneX functions)
+// that compare and deep-copy values field by field, without reflection:
+// reflect.DeepEqual is slow, compares the unexported internals of bin.Uint128,
+// and can't look through the interfaces of complex enums.
+//
+// Vectors are compared by their items, so a nil vector equals an empty one.
+
+func formatEnumEqualName(enumTypeName string) string {
+	return "Equal" + tools.ToCamelUpper(enumTypeName)
+}
+
+func formatEnumCloneName(enumTypeName string) string {
+	return "Clone" + tools.ToCamelUpper(enumTypeName)
+}
+
+// gen_EqualClone_struct generates the Equal and Clone methods of a struct.
+func gen_EqualClone_struct(receiverTypeName string, fields []structField) Code {
+	code := Empty()
+	code.Line().Line().Commentf("Equal tells whether obj and other hold the same values.")
+	code.Line().Func().Params(Id("obj").Id(receiverTypeName)).Id("Equal").
+		Params(Id("other").Id(receiverTypeName)).
+		Bool().
+		BlockFunc(func(body *Group) {
+			for _, field := range fields {
+				gen_equal(body, Id("obj").Dot(field.Name), Id("other").Dot(field.Name), field.Ty, 0)
+			}
+			body.Return(True())
+		})
+
+	code.Line().Line().Commentf("Clone returns a deep copy of obj.")
+	code.Line().Func().Params(Id("obj").Id(receiverTypeName)).Id("Clone").
+		Params().
+		Id(receiverTypeName).
+		BlockFunc(func(body *Group) {
+			body.Id("clone").Op(":=").Id("obj")
+			for _, field := range fields {
+				if needsClone(field.Ty) {
+					gen_clone(body, Id("clone").Dot(field.Name), Id("obj").Dot(field.Name), field.Ty, 0)
+				}
+			}
+			body.Return(Id("clone"))
+		})
+	return code
+}
+
+// gen_EqualClone_comparable generates the Equal and Clone methods of
+// a named type that can be compared with `==`.
+func gen_EqualClone_comparable(receiverTypeName string) Code {
+	code := Empty()
+	code.Line().Line().Commentf("Equal tells whether obj and other hold the same value.")
+	code.Line().Func().Params(Id("obj").Id(receiverTypeName)).Id("Equal").
+		Params(Id("other").Id(receiverTypeName)).
+		Bool().
+		Block(Return(Id("obj").Op("==").Id("other")))
+
+	code.Line().Line().Commentf("Clone returns a copy of obj.")
+	code.Line().Func().Params(Id("obj").Id(receiverTypeName)).Id("Clone").
+		Params().
+		Id(receiverTypeName).
+		Block(Return(Id("obj")))
+	return code
+}
+
+// gen_EqualClone_unitVariant generates the Equal and Clone methods of a unit enum variant;
+// unit variants carry no value (a decoded one holds the variant index), so they are all equal.
+func gen_EqualClone_unitVariant(receiverTypeName string) Code {
+	code := Empty()
+	code.Line().Line().Commentf("Equal tells whether obj and other hold the same value; unit variants always do.")
+	code.Line().Func().Params(Id("obj").Id(receiverTypeName)).Id("Equal").
+		Params(Id("other").Id(receiverTypeName)).
+		Bool().
+		Block(Return(True()))
+
+	code.Line().Line().Commentf("Clone returns a copy of obj.")
+	code.Line().Func().Params(Id("obj").Id(receiverTypeName)).Id("Clone").
+		Params().
+		Id(receiverTypeName).
+		Block(Return(Id("obj")))
+	return code
+}
+
+// gen_EqualClone_alias generates the Equal and Clone methods of a named type
+// (alias); the values are converted to the aliased type to be compared and copied.
+func gen_EqualClone_alias(typeName string, alias idltype.IdlType) Code {
+	if isComparable(alias) {
+		return gen_EqualClone_comparable(typeName)
+	}
+	code := Empty()
+	code.Line().Line().Commentf("Equal tells whether obj and other hold the same value.")
+	code.Line().Func().Params(Id("obj").Id(typeName)).Id("Equal").
+		Params(Id("other").Id(typeName)).
+		Bool().
+		BlockFunc(func(body *Group) {
+			body.List(Id("value"), Id("otherValue")).Op(":=").List(
+				Add(genValueTypeName(alias)).Parens(Id("obj")),
+				Add(genValueTypeName(alias)).Parens(Id("other")),
+			)
+			gen_equal(body, Id("value"), Id("otherValue"), alias, 0)
+			body.Return(True())
+		})
+
+	code.Line().Line().Commentf("Clone returns a deep copy of obj.")
+	code.Line().Func().Params(Id("obj").Id(typeName)).Id("Clone").
+		Params().
+		Id(typeName).
+		BlockFunc(func(body *Group) {
+			if !needsClone(alias) {
+				body.Return(Id("obj"))
+				return
+			}
+			body.Id("value").Op(":=").Add(genValueTypeName(alias)).Parens(Id("obj"))
+			body.Id("clone").Op(":=").Id("value")
+			gen_clone(body, Id("clone"), Id("value"), alias, 0)
+			body.Return(Id(typeName).Parens(Id("clone")))
+		})
+	return code
+}
+
+// gen_EqualClone_complexEnum generates the EqualX and CloneX functions of a complex enum.
+func gen_EqualClone_complexEnum(enumTypeName string, variants idl.VariantSlice) Code {
+	code := Empty()
+	code.Line().Line().Commentf("%s tells whether a and b are the same %s variant, with the same values.", formatEnumEqualName(enumTypeName), enumTypeName)
+	code.Line().Func().Id(formatEnumEqualName(enumTypeName)).
+		Params(Id("a"), Id("b").Id(enumTypeName)).
+		Bool().
+		BlockFunc(func(body *Group) {
+			body.Switch(Id("av").Op(":=").Id("a").Op(".").Parens(Type())).BlockFunc(func(switchGroup *Group) {
+				switchGroup.Case(Nil()).Line().Return(Id("b").Op("==").Nil())
+				for _, variant := range variants {
+					variantTypeName := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
+					switchGroup.Case(Op("*").Id(variantTypeName)).Block(
+						List(Id("bv"), Id("ok")).Op(":=").Id("b").Op(".").Parens(Op("*").Id(variantTypeName)),
+						If(Op("!").Id("ok").Op("||").Parens(Id("av").Op("==").Nil()).Op("!=").Parens(Id("bv").Op("==").Nil())).Block(
+							Return(False()),
+						),
+						Return(Id("av").Op("==").Nil().Op("||").Id("av").Dot("Equal").Call(Op("*").Id("bv"))),
+					)
+				}
+				switchGroup.Default().Line().Return(False())
+			})
+		})
+
+	code.Line().Line().Commentf("%s returns a deep copy of the given %s.", formatEnumCloneName(enumTypeName), enumTypeName)
+	code.Line().Func().Id(formatEnumCloneName(enumTypeName)).
+		Params(Id("value").Id(enumTypeName)).
+		Id(enumTypeName).
+		BlockFunc(func(body *Group) {
+			body.Switch(Id("realvalue").Op(":=").Id("value").Op(".").Parens(Type())).BlockFunc(func(switchGroup *Group) {
+				for _, variant := range variants {
+					switchGroup.Case(Op("*").Id(formatComplexEnumVariantTypeName(enumTypeName, variant.Name))).Block(
+						If(Id("realvalue").Op("==").Nil()).Block(
+							Return(Id("value")),
+						),
+						Id("clone").Op(":=").Id("realvalue").Dot("Clone").Call(),
+						Return(Op("&").Id("clone")),
+					)
+				}
+			})
+			body.Return(Id("value"))
+		})
+	return code
+}
+
+// isComparable tells whether the values of type ty can be compared with `==`.
+func isComparable(ty idltype.IdlType) bool {
+	switch vv := ty.(type) {
+	case *idltype.Bool,
+		*idltype.U8, *idltype.I8,
+		*idltype.U16, *idltype.I16,
+		*idltype.U32, *idltype.I32,
+		*idltype.U64, *idltype.I64,
+		*idltype.F32, *idltype.F64,
+		*idltype.U256, *idltype.I256,
+		*idltype.String, *idltype.Pubkey:
+		return true
+	case *idltype.Array:
+		return isComparable(valueItemType(vv.Type))
+	default:
+		return isSimpleEnum(ty)
+	}
+}
+
+// needsClone tells whether copying a value of type ty by assignment
+// would share memory with the original.
+func needsClone(ty idltype.IdlType) bool {
+	switch vv := ty.(type) {
+	case *idltype.Bytes, *idltype.Vec:
+		return true
+	case *idltype.Option, *idltype.COption:
+		return !genericOptions || needsClone(optionInnerType(ty))
+	case *idltype.Array:
+		return needsClone(valueItemType(vv.Type))
+	case *idltype.Defined:
+		return !isSimpleEnum(ty)
+	default:
+		return false
+	}
+}
+
+// valueItemType returns the type of the items of a vector or array,
+// or of the value of an option. Nested pointer options are represented
+// by their value (see genTypeName), so they are unwrapped.
+func valueItemType(ty idltype.IdlType) idltype.IdlType {
+	for isPointerOption(ty) {
+		ty = optionInnerType(ty)
+	}
+	return ty
+}
+
+// derefOption dereferences the given pointer (to a value of type ty),
+// adding parentheses where the value is indexed or selected.
+func derefOption(expr Code, ty idltype.IdlType) Code {
+	switch ty.(type) {
+	case *idltype.Vec, *idltype.Array, *idltype.U128, *idltype.I128, *idltype.Pubkey, *idltype.U256, *idltype.I256:
+		return Parens(Op("*").Add(expr))
+	case *idltype.Defined:
+		if !isComplexEnum(ty) {
+			return Parens(Op("*").Add(expr))
+		}
+	}
+	return Op("*").Add(expr)
+}
+
+// formatLoopIndexName returns the name of the index of a (nested) loop.
+func formatLoopIndexName(depth int) string {
+	names := []string{"i", "j", "k"}
+	if depth < len(names) {
+		return names[depth]
+	}
+	return "i" + strconv.Itoa(depth)
+}
+
+func formatDepthName(name string, depth int) string {
+	if depth == 0 {
+		return name
+	}
+	return name + strconv.Itoa(depth)
+}
+
+// gen_equal generates the statements that return false if a and b (of type ty) differ.
+func gen_equal(body *Group, a Code, b Code, ty idltype.IdlType, depth int) {
+	returnFalse := Return(False())
+	switch vv := ty.(type) {
+	case *idltype.U128, *idltype.I128:
+		body.If(
+			Add(a).Dot("Lo").Op("!=").Add(b).Dot("Lo").Op("||").
+				Add(a).Dot("Hi").Op("!=").Add(b).Dot("Hi"),
+		).Block(returnFalse)
+	case *idltype.Bytes:
+		body.If(Op("!").Qual("bytes", "Equal").Call(a, b)).Block(returnFalse)
+	case *idltype.Vec:
+		item := valueItemType(vv.Vec)
+		if _, ok := item.(*idltype.U8); ok {
+			body.If(Op("!").Qual("bytes", "Equal").Call(a, b)).Block(returnFalse)
+			return
+		}
+		index := Id(formatLoopIndexName(depth))
+		body.If(Len(a).Op("!=").Len(b)).Block(returnFalse)
+		body.For(Add(index).Op(":=").Range().Add(a)).BlockFunc(func(forBody *Group) {
+			gen_equal(forBody, Add(a).Index(index), Add(b).Index(index), item, depth+1)
+		})
+	case *idltype.Array:
+		if isComparable(ty) {
+			body.If(Add(a).Op("!=").Add(b)).Block(returnFalse)
+			return
+		}
+		index := Id(formatLoopIndexName(depth))
+		body.For(Add(index).Op(":=").Range().Add(a)).BlockFunc(func(forBody *Group) {
+			gen_equal(forBody, Add(a).Index(index), Add(b).Index(index), valueItemType(vv.Type), depth+1)
+		})
+	case *idltype.Option, *idltype.COption:
+		inner := optionInnerType(ty)
+		if genericOptions {
+			aValue, bValue := Id(formatDepthName("value", depth)), Id(formatDepthName("otherValue", depth))
+			aOk, bOk := Id(formatDepthName("ok", depth)), Id(formatDepthName("otherOk", depth))
+			body.BlockFunc(func(optBody *Group) {
+				optBody.List(aValue, aOk).Op(":=").Add(a).Dot("Get").Call()
+				optBody.List(bValue, bOk).Op(":=").Add(b).Dot("Get").Call()
+				optBody.If(Add(aOk).Op("!=").Add(bOk)).Block(returnFalse)
+				optBody.If(aOk).BlockFunc(func(someBody *Group) {
+					gen_equal(someBody, aValue, bValue, inner, depth+1)
+				})
+			})
+			return
+		}
+		inner = valueItemType(inner)
+		body.If(Parens(Add(a).Op("==").Nil()).Op("!=").Parens(Add(b).Op("==").Nil())).Block(returnFalse)
+		if isComparable(inner) {
+			body.If(Add(a).Op("!=").Nil().Op("&&").Op("*").Add(a).Op("!=").Op("*").Add(b)).Block(returnFalse)
+			return
+		}
+		body.If(Add(a).Op("!=").Nil()).BlockFunc(func(someBody *Group) {
+			gen_equal(someBody, derefOption(a, inner), derefOption(b, inner), inner, depth)
+		})
+	case *idltype.Defined:
+		switch {
+		case isComplexEnum(ty):
+			body.If(Op("!").Id(formatEnumEqualName(vv.Name)).Call(a, b)).Block(returnFalse)
+		case isSimpleEnum(ty):
+			body.If(Add(a).Op("!=").Add(b)).Block(returnFalse)
+		default:
+			body.If(Op("!").Add(a).Dot("Equal").Call(b)).Block(returnFalse)
+		}
+	default:
+		body.If(Add(a).Op("!=").Add(b)).Block(returnFalse)
+	}
+}
+
+// gen_clone generates the statements that assign to dst a deep copy of src (of type ty).
+// dst must be either the zero value or a shallow copy of src.
+func gen_clone(body *Group, dst Code, src Code, ty idltype.IdlType, depth int) {
+	switch vv := ty.(type) {
+	case *idltype.Bytes:
+		body.If(Add(src).Op("!=").Nil()).Block(
+			Add(dst).Op("=").Make(Index().Byte(), Len(src)),
+			Copy(dst, src),
+		)
+	case *idltype.Vec:
+		item := valueItemType(vv.Vec)
+		body.If(Add(src).Op("!=").Nil()).BlockFunc(func(someBody *Group) {
+			someBody.Add(dst).Op("=").Make(genTypeName(ty), Len(src))
+			if !needsClone(item) {
+				someBody.Copy(dst, src)
+				return
+			}
+			index := Id(formatLoopIndexName(depth))
+			someBody.For(Add(index).Op(":=").Range().Add(src)).BlockFunc(func(forBody *Group) {
+				gen_clone(forBody, Add(dst).Index(index), Add(src).Index(index), item, depth+1)
+			})
+		})
+	case *idltype.Array:
+		item := valueItemType(vv.Type)
+		if !needsClone(item) {
+			body.Add(dst).Op("=").Add(src)
+			return
+		}
+		index := Id(formatLoopIndexName(depth))
+		body.For(Add(index).Op(":=").Range().Add(src)).BlockFunc(func(forBody *Group) {
+			gen_clone(forBody, Add(dst).Index(index), Add(src).Index(index), item, depth+1)
+		})
+	case *idltype.Option, *idltype.COption:
+		inner := optionInnerType(ty)
+		value := Id(formatDepthName("value", depth))
+		if genericOptions {
+			someName := "Some"
+			if IsCOption(ty) {
+				someName = "SomeC"
+			}
+			cloned := Id(formatDepthName("cloned", depth))
+			body.If(List(value, Id(formatDepthName("ok", depth))).Op(":=").Add(src).Dot("Get").Call(), Id(formatDepthName("ok", depth))).BlockFunc(func(someBody *Group) {
+				someBody.Var().Add(cloned).Add(genTypeName(inner))
+				gen_clone(someBody, cloned, value, inner, depth+1)
+				someBody.Add(dst).Op("=").Qual(PkgAnchorGoOption, someName).Call(cloned)
+			})
+			return
+		}
+		inner = valueItemType(inner)
+		body.If(Add(src).Op("!=").Nil()).BlockFunc(func(someBody *Group) {
+			if needsClone(inner) {
+				someBody.Var().Add(value).Add(genTypeName(inner))
+				gen_clone(someBody, value, derefOption(src, inner), inner, depth+1)
+			} else {
+				someBody.Add(value).Op(":=").Op("*").Add(src)
+			}
+			someBody.Add(dst).Op("=").Op("&").Add(value)
+		})
+	case *idltype.Defined:
+		switch {
+		case isComplexEnum(ty):
+			body.Add(dst).Op("=").Id(formatEnumCloneName(vv.Name)).Call(src)
+		case isSimpleEnum(ty):
+			body.Add(dst).Op("=").Add(src)
+		default:
+			body.Add(dst).Op("=").Add(src).Dot("Clone").Call()
+		}
+	default:
+		body.Add(dst).Op("=").Add(src)
+	}
+}
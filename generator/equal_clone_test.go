@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"testing"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenEqualClone(t *testing.T) {
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "EqTestStatus",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind:     "enum",
+					Variants: idl.VariantSlice{{Name: "On"}, {Name: "Off"}},
+				},
+			},
+			{
+				Name: "EqTestShape",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind: "enum",
+					Variants: idl.VariantSlice{
+						{Name: "Empty"},
+						{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+					},
+				},
+			},
+			{
+				Name: "EqTestHolder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "big", Ty: &idltype.U128{}},
+						{Name: "status", Ty: &idltype.Defined{Name: "EqTestStatus"}},
+						{Name: "data", Ty: &idltype.Bytes{}},
+						{Name: "shapes", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "EqTestShape"}}},
+						{Name: "maybe", Ty: &idltype.Option{Option: &idltype.U64{}}},
+						{Name: "keys", Ty: &idltype.Array{Type: &idltype.Pubkey{}, Size: &idltype.IdlArrayLenValue{Value: 2}}},
+					},
+				},
+			},
+		},
+	}
+	for _, typ := range idlData.Types {
+		registerComplexEnums(typ)
+	}
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file := NewFile("test")
+	for _, typ := range idlData.Types {
+		code, err := gen.gen_IDLTypeDef(typ)
+		require.NoError(t, err)
+		file.Add(code)
+	}
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		"func (obj EqTestHolder) Equal(other EqTestHolder) bool {",
+		"if obj.Big.Lo != other.Big.Lo || obj.Big.Hi != other.Big.Hi {",
+		"if obj.Status != other.Status {",
+		"if !bytes.Equal(obj.Data, other.Data) {",
+		"for i := range obj.Shapes {\n\t\tif !EqualEqTestShape(obj.Shapes[i], other.Shapes[i]) {",
+		"if (obj.Maybe == nil) != (other.Maybe == nil) {",
+		"if obj.Maybe != nil && *obj.Maybe != *other.Maybe {",
+		"if obj.Keys != other.Keys {",
+		"func (obj EqTestHolder) Clone() EqTestHolder {\n\tclone := obj",
+		"clone.Shapes[i] = CloneEqTestShape(obj.Shapes[i])",
+		"value := *obj.Maybe\n\t\tclone.Maybe = &value",
+		"func EqualEqTestShape(a, b EqTestShape) bool {",
+		"case *EqTestShape_Circle:\n\t\tbv, ok := b.(*EqTestShape_Circle)",
+		"func CloneEqTestShape(value EqTestShape) EqTestShape {",
+		"func (obj EqTestShape_Empty) Equal(other EqTestShape_Empty) bool {\n\treturn true\n}",
+		"func (obj EqTestShape_Circle) Equal(other EqTestShape_Circle) bool {",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+	// Fixed arrays of comparable values are copied by the assignment.
+	assert.NotContains(t, generatedCode, "clone.Keys")
+}
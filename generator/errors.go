@@ -1,12 +1,8 @@
 package generator
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
-
 	. "github.com/dave/jennifer/jen"
-	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gagliardetto/anchor-go/tools"
 )
 
 func (g *Generator) gen_errors() (*OutputFile, error) {
@@ -15,17 +11,81 @@ func (g *Generator) gen_errors() (*OutputFile, error) {
 	file.HeaderComment("This file contains errors.")
 	{
 		code := Empty()
-		for _, e := range g.idl.Errors {
-			_ = e
-			// spew.Dump(e)
+		// The interface implemented by all the errors of the program:
+		code.Type().Id("CustomError").Interface(
+			Id("Code").Params().Int(),
+			Id("Name").Params().String(),
+			Id("Error").Params().String(),
+		).Line().Line()
+
+		code.Type().Id("customErrorDef").Struct(
+			Id("code").Int(),
+			Id("name").String(),
+			Id("msg").String(),
+		).Line().Line()
 
-			// 	type IdlErrorCode struct {
-			// 		Code uint32 `json:"code"`
-			// 		Name string `json:"name"`
-			// 		//	    #[serde(skip_serializing_if = "is_default")]
-			// 		//	    pub msg: Option<String>,
-			// 		Msg Option[string] `json:"msg,omitzero"`
-			// 	}
+		code.Func().Params(Id("e").Op("*").Id("customErrorDef")).Id("Code").Params().Int().Block(
+			Return(Id("e").Dot("code")),
+		).Line().Line()
+		code.Func().Params(Id("e").Op("*").Id("customErrorDef")).Id("Name").Params().String().Block(
+			Return(Id("e").Dot("name")),
+		).Line().Line()
+		code.Func().Params(Id("e").Op("*").Id("customErrorDef")).Id("Error").Params().String().Block(
+			Return(Qual("fmt", "Sprintf").Call(Lit("%s(%d): %s"), Id("e").Dot("name"), Id("e").Dot("code"), Id("e").Dot("msg"))),
+		).Line().Line()
+
+		if len(g.idl.Errors) > 0 {
+			code.Var().DefsFunc(func(group *Group) {
+				for _, e := range g.idl.Errors {
+					msg := e.Msg.UnwrapOr(e.Name)
+					if e.Msg.IsSome() {
+						group.Comment(msg)
+					}
+					group.Id(formatErrorVarName(e.Name)).Op("=").Op("&").Id("customErrorDef").Values(
+						Id("code").Op(":").Lit(int(e.Code)),
+						Id("name").Op(":").Lit(e.Name),
+						Id("msg").Op(":").Lit(msg),
+					)
+				}
+			}).Line().Line()
+		}
+
+		code.Comment("Errors maps the error codes of the program to their typed errors.").Line()
+		code.Var().Id("Errors").Op("=").Map(Int()).Id("CustomError").Values(DictFunc(func(dict Dict) {
+			for _, e := range g.idl.Errors {
+				dict[Lit(int(e.Code))] = Id(formatErrorVarName(e.Name))
+			}
+		})).Line().Line()
+
+		code.Comment("DecodeCustomError returns the typed error of the program for the custom error code").Line()
+		code.Comment("contained in the given RPC error, if any.").Line()
+		code.Func().Id("DecodeCustomError").Params(Id("rpcErr").Error()).Params(Id("err").Error(), Id("ok").Bool()).Block(
+			If(List(Id("errCode"), Id("o")).Op(":=").Id("decodeErrorCode").Call(Id("rpcErr")), Id("o")).Block(
+				If(List(Id("customErr"), Id("o")).Op(":=").Id("Errors").Index(Id("errCode")), Id("o")).Block(
+					Id("err").Op("=").Id("customErr"),
+					Id("ok").Op("=").True(),
+					Return(),
+				),
+			),
+			Return(),
+		).Line().Line()
+
+		code.Add(gen_decodeErrorCode()).Line().Line()
+
+		if g.programID() != nil {
+			code.Comment("ParseAnchorError parses the AnchorError logged by a failed transaction.").Line()
+			code.Comment("If the error was thrown by the program at GetProgramID(), ProgramError is set to the").Line()
+			code.Comment("corresponding typed error, so that errors.Is works against the Err* values.").Line()
+			code.Func().Id("ParseAnchorError").Params(Id("logs").Index().String()).Params(Op("*").Qual(PkgAnchorGoErrors, "AnchorError"), Bool()).Block(
+				Return(Id("Default").Call().Dot("ParseAnchorError").Call(Id("logs"))),
+			)
+		} else {
+			code.Comment("ParseAnchorError parses the AnchorError logged by a failed transaction.").Line()
+			code.Comment("If the error was thrown by this program, ProgramError is set to the").Line()
+			code.Comment("corresponding typed error, so that errors.Is works against the Err* values.").Line()
+			code.Func().Id("ParseAnchorError").Params(Id("logs").Index().String()).Params(Op("*").Qual(PkgAnchorGoErrors, "AnchorError"), Bool()).BlockFunc(func(body *Group) {
+				gen_parseAnchorErrorBody(body, nil)
+			})
 		}
 		file.Add(code)
 	}
@@ -35,66 +95,60 @@ func (g *Generator) gen_errors() (*OutputFile, error) {
 	}, nil
 }
 
-type CustomError interface {
-	Code() int
-	Name() string
-	Error() string
-}
-type customErrorDef struct {
-	code int
-	name string
-	msg  string
+func formatErrorVarName(errorName string) string {
+	return "Err" + tools.ToCamelUpper(errorName)
 }
 
-func (e *customErrorDef) Code() int {
-	return e.code
+// gen_decodeErrorCode generates the function that extracts the custom
+// program error code from an RPC error:
+//
+//	{"err": {"InstructionError": [0, {"Custom": 6000}]}}
+func gen_decodeErrorCode() Code {
+	return Func().Id("decodeErrorCode").Params(Id("rpcErr").Error()).Params(Id("errorCode").Int(), Id("ok").Bool()).Block(
+		Var().Id("jErr").Op("*").Qual(PkgSolanaGoJsonRPC, "RPCError"),
+		If(Qual("errors", "As").Call(Id("rpcErr"), Op("&").Id("jErr")).Op("&&").Id("jErr").Dot("Data").Op("!=").Nil()).Block(
+			If(List(Id("root"), Id("o")).Op(":=").Id("jErr").Dot("Data").Assert(Map(String()).Any()), Id("o")).Block(
+				If(List(Id("rootErr"), Id("o")).Op(":=").Id("root").Index(Lit("err")).Assert(Map(String()).Any()), Id("o")).Block(
+					If(List(Id("rootErrInstructionError"), Id("o")).Op(":=").Id("rootErr").Index(Lit("InstructionError")), Id("o")).Block(
+						If(List(Id("rootErrInstructionErrorItems"), Id("o")).Op(":=").Id("rootErrInstructionError").Assert(Index().Any()), Id("o")).Block(
+							If(Len(Id("rootErrInstructionErrorItems")).Op("==").Lit(2)).Block(
+								If(List(Id("v"), Id("o")).Op(":=").Id("rootErrInstructionErrorItems").Index(Lit(1)).Assert(Map(String()).Any()), Id("o")).Block(
+									If(List(Id("v2"), Id("o")).Op(":=").Id("v").Index(Lit("Custom")).Assert(Qual("encoding/json", "Number")), Id("o")).Block(
+										If(List(Id("code"), Err()).Op(":=").Id("v2").Dot("Int64").Call(), Err().Op("==").Nil()).Block(
+											Id("ok").Op("=").True(),
+											Id("errorCode").Op("=").Int().Call(Id("code")),
+										),
+									).Else().If(List(Id("v2"), Id("o")).Op(":=").Id("v").Index(Lit("Custom")).Assert(Float64()), Id("o")).Block(
+										Id("ok").Op("=").True(),
+										Id("errorCode").Op("=").Int().Call(Id("v2")),
+									),
+								),
+							),
+						),
+					),
+				),
+			),
+		),
+		Return(),
+	)
 }
 
-func (e *customErrorDef) Name() string {
-	return e.name
-}
-
-func (e *customErrorDef) Error() string {
-	return fmt.Sprintf("%s(%d): %s", e.name, e.code, e.msg)
-}
-
-var Errors = map[int]CustomError{}
-
-func DecodeCustomError(rpcErr error) (err error, ok bool) {
-	if errCode, o := decodeErrorCode(rpcErr); o {
-		if customErr, o := Errors[errCode]; o {
-			err = customErr
-			ok = true
-			return
-		}
-	}
-	return
-}
-
-func decodeErrorCode(rpcErr error) (errorCode int, ok bool) {
-	var jErr *jsonrpc.RPCError
-	if errors.As(rpcErr, &jErr) && jErr.Data != nil {
-		if root, o := jErr.Data.(map[string]any); o {
-			if rootErr, o := root["err"].(map[string]any); o {
-				if rootErrInstructionError, o := rootErr["InstructionError"]; o {
-					if rootErrInstructionErrorItems, o := rootErrInstructionError.([]any); o {
-						if len(rootErrInstructionErrorItems) == 2 {
-							if v, o := rootErrInstructionErrorItems[1].(map[string]any); o {
-								if v2, o := v["Custom"].(json.Number); o {
-									if code, err := v2.Int64(); err == nil {
-										ok = true
-										errorCode = int(code)
-									}
-								} else if v2, o := v["Custom"].(float64); o {
-									ok = true
-									errorCode = int(v2)
-								}
-							}
-						}
-					}
-				}
-			}
-		}
+// gen_parseAnchorErrorBody generates the body of ParseAnchorError, which links the AnchorError
+// to the typed error of the program if it was thrown by programID (by any program if nil).
+func gen_parseAnchorErrorBody(body *Group, programID Code) {
+	body.List(Id("anchorErr"), Id("ok")).Op(":=").Qual(PkgAnchorGoErrors, "ParseAnchorError").Call(Id("logs"))
+	body.If(Op("!").Id("ok")).Block(
+		Return(Nil(), False()),
+	)
+	linkErr := If(List(Id("customErr"), Id("ok")).Op(":=").Id("Errors").Index(Id("anchorErr").Dot("Number")), Id("ok")).Block(
+		Id("anchorErr").Dot("ProgramError").Op("=").Id("customErr"),
+	)
+	if programID != nil {
+		body.If(Id("anchorErr").Dot("ProgramID").Dot("IsZero").Call().Op("||").Id("anchorErr").Dot("ProgramID").Dot("Equals").Call(programID)).Block(
+			linkErr,
+		)
+	} else {
+		body.Add(linkErr)
 	}
-	return
+	body.Return(Id("anchorErr"), True())
 }
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenFuzzTests(t *testing.T) {
+	idlData := &idl.Idl{
+		Accounts: []idl.IdlAccount{{Name: "FzVault"}},
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "FzVault",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind:   "struct",
+					Fields: idl.IdlDefinedFieldsNamed{{Name: "amount", Ty: &idltype.U64{}}},
+				},
+			},
+		},
+		Instructions: []idl.IdlInstruction{
+			{
+				Name: "deposit",
+				Args: []idl.IdlField{{Name: "amount", Ty: &idltype.U64{}}},
+				Accounts: []idl.IdlInstructionAccountItem{
+					&idl.IdlInstructionAccount{Name: "vault", Writable: true},
+					&idl.IdlInstructionAccount{Name: "owner", Signer: true},
+				},
+			},
+		},
+	}
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file, err := gen.gen_fuzzTests()
+	require.NoError(t, err)
+	generatedCode := file.File.GoString()
+
+	for _, expected := range []string{
+		"return newFuzzer().RandSource(rand.NewSource(1))",
+		"func FuzzParseAnyAccount(f *testing.F) {",
+		"var value FzVault\n\t\t\tfuzzer.Fuzz(&value)",
+		"f.Add(append(Account_FzVault[:], data...))",
+		"_, _ = ParseAnyAccount(data)",
+		"func FuzzParseInstruction(f *testing.F) {",
+		"instruction, err := NewDepositInstruction(params.Amount, params.Vault, params.Owner)",
+		"f.Add(data, []byte{0, 1})",
+		"accountKeys := make([]solanago.PublicKey, 2)",
+		"_, _ = ParseInstruction(instructionData, accountIndicesData, accountKeys)",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+	// No events, no event fuzz target.
+	assert.NotContains(t, generatedCode, "FuzzParseAnyEvent")
+}
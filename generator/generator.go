@@ -11,17 +11,35 @@ import (
 var Debug = false // Set to true to enable debug logging.
 
 type Generator struct {
-	options *GeneratorOptions
-	idl     *idl.Idl
+	options  *GeneratorOptions
+	idl      *idl.Idl
+	versions []idlVersion // All the versions of the IDL, oldest first; nil without PreviousIdls.
 }
 
 type GeneratorOptions struct {
-	OutputDir   string            // Directory to write the generated code to.
-	Package     string            // Package name for the generated code.
-	ModPath     string            // Module path for the generated code. E.g. "github.com/gagliardetto/mysolana-program-go"
-	ProgramId   *solana.PublicKey // Program ID to use in the generated code.
-	ProgramName string            // Name of the program for the generated code.
-	SkipGoMod   bool              // If true, skip generating the go.mod file.
+	OutputDir      string            // Directory to write the generated code to.
+	Package        string            // Package name for the generated code.
+	ModPath        string            // Module path for the generated code. E.g. "github.com/gagliardetto/mysolana-program-go"
+	ProgramId      *solana.PublicKey // Program ID to use in the generated code.
+	ProgramName    string            // Name of the program for the generated code.
+	SkipGoMod      bool              // If true, skip generating the go.mod file.
+	GenericOptions bool              // If true, generate options as option.Option[T] and option.COption[T] instead of pointers.
+	PreviousIdls   []*idl.Idl        // Previous versions of the IDL (oldest first), whose account layouts are parsed by ParseAnyAccountVersioned.
+	Renames        Renames           // Names to generate some types and instructions with, instead of their IDL names.
+	Filter         Filter            // Instructions, accounts and events to generate (all of them by default).
+	// AnchorGoReplace is the directory that replaces anchor-go in the go.mod (e.g. a checkout of anchor-go),
+	// as written in a replace directive: relative to OutputDir, or absolute.
+	AnchorGoReplace string
+}
+
+// resetTypeRegistries clears the registries filled by Generate; as they're shared,
+// programs can be generated one after the other, but not concurrently.
+func resetTypeRegistries() {
+	clear(typeRegistryComplexEnum)
+	clear(typeRegistrySimpleEnum)
+	clear(typeRegistryMinEncodedSize)
+	clear(typeRegistryZeroEncodedSize)
+	genericOptions = false
 }
 
 func NewGenerator(idl *idl.Idl, options *GeneratorOptions) *Generator {
@@ -54,13 +72,29 @@ func (g *Generator) Generate() (*Output, error) {
 			ProgramName: "myprogram",
 		}
 	}
+	// The registries are package-level: clear those of the previously generated program.
+	resetTypeRegistries()
+	if err := g.applyOverrides(); err != nil {
+		return nil, err
+	}
 	if err := g.idl.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid IDL: %w", err)
 	}
+	if _, err := g.clusterProgramIDs(); err != nil {
+		return nil, fmt.Errorf("invalid IDL: %w", err)
+	}
+	if err := monomorphizeGenerics(g.idl); err != nil {
+		return nil, fmt.Errorf("error while instantiating generic types: %w", err)
+	}
 	output := &Output{
 		Files: make([]*OutputFile, 0),
 	}
 
+	// Add the types of the previous versions of the IDL whose layout changed:
+	if err := g.registerVersions(); err != nil {
+		return nil, fmt.Errorf("error while comparing the versions of the IDL: %w", err)
+	}
+
 	{
 		// Register complex enums.
 		{
@@ -70,6 +104,11 @@ func (g *Generator) Generate() (*Output, error) {
 				registerComplexEnums(typ)
 			}
 		}
+		// Inline the aliases that can't be generated as Go named types:
+		inlineAliases(g.idl)
+		// Register the minimum encoded sizes, which bound the declared lengths of vectors:
+		registerMinEncodedSizes(g.idl)
+		genericOptions = g.options.GenericOptions
 		if len(g.idl.Docs) > 0 {
 			file, err := g.genfile_doc()
 			if err != nil {
@@ -98,6 +137,13 @@ func (g *Generator) Generate() (*Output, error) {
 			}
 			output.Files = append(output.Files, file)
 		}
+		if len(g.versions) > 0 {
+			file, err := g.genfile_versions()
+			if err != nil {
+				return nil, err
+			}
+			output.Files = append(output.Files, file)
+		}
 		{
 			file, err := g.gen_discriminators()
 			if err != nil {
@@ -133,6 +179,20 @@ func (g *Generator) Generate() (*Output, error) {
 			}
 			output.Files = append(output.Files, file)
 		}
+		{
+			file, err := g.gen_fuzzTests()
+			if err != nil {
+				return nil, err
+			}
+			output.Files = append(output.Files, file)
+		}
+		{
+			file, err := g.gen_benchmarks()
+			if err != nil {
+				return nil, err
+			}
+			output.Files = append(output.Files, file)
+		}
 		{
 			file, err := g.gen_instructions()
 			if err != nil {
@@ -140,8 +200,15 @@ func (g *Generator) Generate() (*Output, error) {
 			}
 			output.Files = append(output.Files, file)
 		}
-		if g.options.ProgramId != nil {
-			file, err := g.genfile_programID(*g.options.ProgramId)
+		{
+			file, err := g.genfile_client()
+			if err != nil {
+				return nil, err
+			}
+			output.Files = append(output.Files, file)
+		}
+		if programID := g.programID(); programID != nil {
+			file, err := g.genfile_programID(*programID)
 			if err != nil {
 				return nil, err
 			}
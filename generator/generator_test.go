@@ -0,0 +1,437 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+// checkoutDir is the checkout of anchor-go that replaces the released anchor-go
+// in the go.mod of the generated code built by the tests.
+func checkoutDir(t *testing.T) string {
+	t.Helper()
+	dir, err := filepath.Abs("..")
+	require.NoError(t, err)
+	return dir
+}
+
+// buildGenerated writes the generated code (with its go.mod, which replaces anchor-go
+// with this checkout, see checkoutDir) to a temporary directory, and checks that it builds.
+// It returns the directory.
+func buildGenerated(t *testing.T, output *Output) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the generated code")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), output.GoMod, 0o644))
+	for _, file := range output.Files {
+		require.NoError(t, file.File.Save(filepath.Join(dir, file.Name)))
+	}
+	// The dependencies are those of anchor-go: use the module cache only.
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-mod=mod")
+	for _, args := range [][]string{
+		{"mod", "tidy"},
+		{"vet", "./..."},
+	} {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "go %v:\n%s", args, out)
+	}
+	return dir
+}
+
+// newShapeIdl returns the IDL of a program with a Holder account holding a Shape,
+// which is a complex enum if complexShape, else a struct.
+func newShapeIdl(complexShape bool) *idl.Idl {
+	shape := idl.IdlTypeDef{Name: "Shape", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+		{Name: "sides", Ty: &idltype.U8{}},
+	}}}
+	if complexShape {
+		shape.Ty = &idl.IdlTypeDefTyEnum{Kind: "enum", Variants: idl.VariantSlice{
+			{Name: "Empty"},
+			{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+		}}
+	}
+	return &idl.Idl{
+		Metadata: idl.IdlMetadata{Name: "shapes", Version: "0.1.0", Spec: "0.1.0"},
+		Instructions: []idl.IdlInstruction{
+			{Name: "set_shape", Discriminator: idl.IdlDiscriminator{1, 2, 3, 4, 5, 6, 7, 8}, Args: []idl.IdlField{
+				{Name: "shape", Ty: &idltype.Defined{Name: "Shape"}},
+			}},
+		},
+		Accounts: []idl.IdlAccount{
+			{Name: "Holder", Discriminator: idl.IdlDiscriminator{8, 7, 6, 5, 4, 3, 2, 1}},
+		},
+		Types: idl.IdTypeDef_slice{
+			shape,
+			{Name: "Holder", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "shape", Ty: &idltype.Defined{Name: "Shape"}},
+				{Name: "shapes", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "Shape"}}},
+			}}},
+		},
+	}
+}
+
+func TestGenerateSeveralPrograms(t *testing.T) {
+	// The programs are generated in the same process (e.g. from a config file):
+	// the types of a program must not leak into the next one.
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	for _, tt := range []struct {
+		name         string
+		complexShape bool
+	}{
+		{"enum_shapes", true},
+		{"struct_shapes", false},
+		{"enum_shapes_again", true},
+	} {
+		gen := NewGenerator(newShapeIdl(tt.complexShape), &GeneratorOptions{
+			Package:     tt.name,
+			ProgramName: tt.name,
+			ModPath:     "example.com/" + tt.name,
+			ProgramId:   &programID,
+
+			AnchorGoReplace: checkoutDir(t),
+		})
+		output, err := gen.Generate()
+		require.NoError(t, err)
+		t.Run(tt.name, func(t *testing.T) {
+			buildGenerated(t, output)
+		})
+	}
+}
+
+func TestGenerateDecodeErrorPaths(t *testing.T) {
+	// The paths of the errors use the names of the IDL, not those of the Go fields.
+	tagFields := idl.IdlDefinedFieldsNamed{
+		{Name: "note", Ty: &idltype.Option{Option: &idltype.U32{}}},
+		{Name: "tag_value", Ty: &idltype.U32{}},
+	}
+	programIdl := &idl.Idl{
+		Metadata: idl.IdlMetadata{Name: "orders", Version: "0.1.0", Spec: "0.1.0"},
+		Types: idl.IdTypeDef_slice{
+			{Name: "Tag", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: tagFields}},
+			{Name: "Customer", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "tags", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "Tag"}}},
+			}}},
+			{Name: "Order", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "customer_info", Ty: &idltype.Defined{Name: "Customer"}},
+			}}},
+		},
+	}
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	output, err := NewGenerator(programIdl, &GeneratorOptions{
+		Package:     "orders",
+		ProgramName: "orders",
+		ModPath:     "example.com/orders",
+		ProgramId:   &programID,
+
+		AnchorGoReplace: checkoutDir(t),
+	}).Generate()
+	require.NoError(t, err)
+	dir := buildGenerated(t, output)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "decode_error_test.go"), []byte(`package orders
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/errors"
+)
+
+func TestDecodeError(t *testing.T) {
+	data := []byte{
+		2, 0, 0, 0, // customer_info.tags: 2 items
+		1, 7, 0, 0, 0, 1, 0, 0, 0, // customer_info.tags[0]
+		1, 7, 0, 0, 0, 2, 0, // customer_info.tags[1], truncated in tag_value
+	}
+	err := new(Order).Unmarshal(data)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if path := errors.PathOf(err).String(); path != "customer_info.tags[1].tag_value" {
+		t.Errorf("path: got %q", path)
+	}
+	if offset, ok := errors.OffsetOf(err); !ok || offset != 18 {
+		t.Errorf("offset: got %d, %v", offset, ok)
+	}
+	if !strings.Contains(err.Error(), "customer_info.tags[1].tag_value at offset 18: ") {
+		t.Errorf("message: got %q", err)
+	}
+}
+`), 0o644))
+	cmd := exec.Command("go", "test", "-run", "TestDecodeError", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "%s", out)
+}
+
+func TestGenerateDecodeLimits(t *testing.T) {
+	// The limits of the decode options bound the vectors and strings of a single decode.
+	programIdl := &idl.Idl{
+		Metadata: idl.IdlMetadata{Name: "ledgers", Version: "0.1.0", Spec: "0.1.0"},
+		Accounts: []idl.IdlAccount{{Name: "Ledger", Discriminator: idl.IdlDiscriminator{1, 2, 3, 4, 5, 6, 7, 8}}},
+		Types: idl.IdTypeDef_slice{
+			{Name: "Nothing", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct"}},
+			{Name: "Ledger", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "amounts", Ty: &idltype.Vec{Vec: &idltype.U64{}}},
+				{Name: "memo", Ty: &idltype.String{}},
+				{Name: "nothings", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "Nothing"}}},
+			}}},
+		},
+	}
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	output, err := NewGenerator(programIdl, &GeneratorOptions{
+		Package:     "ledgers",
+		ProgramName: "ledgers",
+		ModPath:     "example.com/ledgers",
+		ProgramId:   &programID,
+
+		AnchorGoReplace: checkoutDir(t),
+	}).Generate()
+	require.NoError(t, err)
+	dir := buildGenerated(t, output)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "limits_test.go"), []byte(`package ledgers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/decode"
+	"github.com/gagliardetto/anchor-go/limits"
+)
+
+func TestDecodeLimits(t *testing.T) {
+	data := []byte{
+		1, 2, 3, 4, 5, 6, 7, 8, // discriminator
+		3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, // amounts
+		5, 0, 0, 0, 'h', 'e', 'l', 'l', 'o', // memo
+		2, 0, 0, 0, // nothings: zero-size items take no input
+	}
+	for _, tt := range []struct {
+		limits  limits.Limits
+		wantErr error
+	}{
+		{limits: limits.Limits{}},
+		{limits: limits.Limits{MaxCollectionLength: 5, MaxAllocation: 3*8 + 5}},
+		{limits: limits.Limits{MaxCollectionLength: 2}, wantErr: limits.ErrLimitExceeded},
+		{limits: limits.Limits{MaxCollectionLength: 4}, wantErr: limits.ErrLimitExceeded},
+		{limits: limits.Limits{MaxAllocation: 3*8 + 4}, wantErr: limits.ErrLimitExceeded},
+	} {
+		ledger, _, err := ParseAccount_LedgerWithOptions(data, decode.Options{Limits: tt.limits})
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%+v: got error %v, want %v", tt.limits, err, tt.wantErr)
+		}
+		if err == nil && (len(ledger.Amounts) != 3 || ledger.Memo != "hello" || len(ledger.Nothings) != 2) {
+			t.Fatalf("%+v: got %+v", tt.limits, ledger)
+		}
+	}
+
+	// A declared length that doesn't fit in the input is always rejected:
+	truncated := append(append([]byte{}, data[:8]...), 0xff, 0xff, 0xff, 0x7f)
+	if _, err := ParseAccount_Ledger(truncated); !errors.Is(err, limits.ErrLengthExceedsInput) {
+		t.Fatalf("got error %v", err)
+	}
+}
+`), 0o644))
+	cmd := exec.Command("go", "test", "-run", "TestDecodeLimits", ".")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "%s", out)
+}
+
+func TestGenerateBenchmarks(t *testing.T) {
+	// The benchmarks of the reflection run on the same values as those of the generated codec.
+	programIdl := &idl.Idl{
+		Metadata: idl.IdlMetadata{Name: "vaults", Version: "0.1.0", Spec: "0.1.0"},
+		Accounts: []idl.IdlAccount{
+			{Name: "Vault", Discriminator: idl.IdlDiscriminator{1, 2, 3, 4, 5, 6, 7, 8}},
+			{Name: "Shaped", Discriminator: idl.IdlDiscriminator{8, 7, 6, 5, 4, 3, 2, 1}},
+		},
+		Types: idl.IdTypeDef_slice{
+			{Name: "Kind", Ty: &idl.IdlTypeDefTyEnum{Kind: "enum", Variants: idl.VariantSlice{{Name: "Open"}, {Name: "Closed"}}}},
+			{Name: "Shape", Ty: &idl.IdlTypeDefTyEnum{Kind: "enum", Variants: idl.VariantSlice{
+				{Name: "Empty"},
+				{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+			}}},
+			{Name: "Balance", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "amount", Ty: &idltype.U64{}},
+				{Name: "locked", Ty: &idltype.Bool{}},
+			}}},
+			{Name: "Vault", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "owner", Ty: &idltype.Pubkey{}},
+				{Name: "name", Ty: &idltype.String{}},
+				{Name: "data", Ty: &idltype.Bytes{}},
+				{Name: "weights", Ty: &idltype.Vec{Vec: &idltype.U16{}}},
+				{Name: "limit", Ty: &idltype.Option{Option: &idltype.U32{}}},
+				{Name: "delegate", Ty: &idltype.COption{COption: &idltype.Pubkey{}}},
+				{Name: "seed", Ty: &idltype.Array{Type: &idltype.U8{}, Size: &idltype.IdlArrayLenValue{Value: 4}}},
+				{Name: "total", Ty: &idltype.I128{}},
+				{Name: "kind", Ty: &idltype.Defined{Name: "Kind"}},
+				{Name: "balances", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "Balance"}}},
+			}}},
+			{Name: "Shaped", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "shape", Ty: &idltype.Defined{Name: "Shape"}},
+			}}},
+		},
+	}
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	for _, genericOptions := range []bool{false, true} {
+		output, err := NewGenerator(programIdl, &GeneratorOptions{
+			Package:        "vaults",
+			ProgramName:    "vaults",
+			ModPath:        "example.com/vaults",
+			ProgramId:      &programID,
+			GenericOptions: genericOptions,
+
+			AnchorGoReplace: checkoutDir(t),
+		}).Generate()
+		require.NoError(t, err)
+		t.Run(fmt.Sprintf("generic options: %v", genericOptions), func(t *testing.T) {
+			dir := buildGenerated(t, output)
+			cmd := exec.Command("go", "test", "-run", "^$", "-bench", ".", "-benchtime", "1x")
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, "%s", out)
+			ran := func(name string) bool {
+				return regexp.MustCompile(`(?m)^Benchmark` + name + `(-\d+)?\s`).Match(out)
+			}
+			for _, name := range []string{"VaultMarshal", "VaultUnmarshal", "VaultMarshalReflection", "VaultUnmarshalReflection", "ShapedUnmarshal"} {
+				require.True(t, ran(name), "Benchmark%s didn't run:\n%s", name, out)
+			}
+			// The reflection can't decode the interfaces of complex enums:
+			require.False(t, ran("ShapedUnmarshalReflection"))
+		})
+	}
+}
+
+func TestGenerateComplexEnumJSONContainers(t *testing.T) {
+	// Complex enums are encoded as tagged unions in options and nested containers too.
+	shape := &idltype.Defined{Name: "Shape"}
+	fields := idl.IdlDefinedFieldsNamed{
+		{Name: "maybe_shape", Ty: &idltype.Option{Option: shape}},
+		{Name: "no_shape", Ty: &idltype.Option{Option: shape}},
+		{Name: "grid", Ty: &idltype.Vec{Vec: &idltype.Vec{Vec: shape}}},
+		{Name: "rows", Ty: &idltype.Array{Type: &idltype.Vec{Vec: shape}, Size: &idltype.IdlArrayLenValue{Value: 2}}},
+	}
+	programIdl := &idl.Idl{
+		Metadata: idl.IdlMetadata{Name: "stress", Version: "0.1.0", Spec: "0.1.0"},
+		Instructions: []idl.IdlInstruction{
+			{Name: "do_it", Discriminator: idl.IdlDiscriminator{1, 2, 3, 4, 5, 6, 7, 8}, Args: []idl.IdlField{fields[0], fields[2]}},
+		},
+		Types: idl.IdTypeDef_slice{
+			{Name: "Shape", Ty: &idl.IdlTypeDefTyEnum{Kind: "enum", Variants: idl.VariantSlice{
+				{Name: "Empty"},
+				{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+			}}},
+			{Name: "Holder", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: fields}},
+		},
+	}
+	// The values, with pointer options and with generic options:
+	values := map[bool]string{
+		false: `
+	var circle Shape = NewShapeCircle(5)
+	holder := Holder{
+		MaybeShape: &circle,
+		Grid:       [][]Shape{{NewShapeCircle(5), NewShapeEmpty()}, nil},
+		Rows:       [2][]Shape{{NewShapeEmpty()}, {}},
+	}
+	instruction := DoItInstruction{MaybeShape: &circle, Grid: holder.Grid}`,
+		true: `
+	holder := Holder{
+		MaybeShape: option.Some(NewShapeCircle(5)),
+		NoShape:    option.None[Shape](),
+		Grid:       [][]Shape{{NewShapeCircle(5), NewShapeEmpty()}, nil},
+		Rows:       [2][]Shape{{NewShapeEmpty()}, {}},
+	}
+	instruction := DoItInstruction{MaybeShape: holder.MaybeShape, Grid: holder.Grid}`,
+	}
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	for _, genericOptions := range []bool{false, true} {
+		output, err := NewGenerator(programIdl, &GeneratorOptions{
+			Package:        "stress",
+			ProgramName:    "stress",
+			ModPath:        "example.com/stress",
+			ProgramId:      &programID,
+			GenericOptions: genericOptions,
+
+			AnchorGoReplace: checkoutDir(t),
+		}).Generate()
+		require.NoError(t, err)
+		t.Run(fmt.Sprintf("generic options: %v", genericOptions), func(t *testing.T) {
+			dir := buildGenerated(t, output)
+			optionImport := ""
+			if genericOptions {
+				optionImport = `"github.com/gagliardetto/anchor-go/option"`
+			}
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "json_containers_test.go"), []byte(`package stress
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	`+optionImport+`
+)
+
+func TestJSONContainers(t *testing.T) {`+values[genericOptions]+`
+	data, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`+"`"+`"maybe_shape":{"Circle":{"radius":5}}`+"`"+`,
+		`+"`"+`"grid":[[{"Circle":{"radius":5}},{"Empty":{}}],null]`+"`"+`,
+		`+"`"+`"rows":[[{"Empty":{}}],[]]`+"`"+`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("%s does not contain %s", data, expected)
+		}
+	}
+	var decoded Holder
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(holder) {
+		t.Errorf("got %+v, want %+v", decoded, holder)
+	}
+
+	data, err = json.Marshal(instruction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `+"`"+`"maybe_shape":{"Circle":{"radius":5}}`+"`"+`) {
+		t.Errorf("%s does not contain the tagged maybe_shape", data)
+	}
+	var decodedInstruction DoItInstruction
+	if err := json.Unmarshal(data, &decodedInstruction); err != nil {
+		t.Fatal(err)
+	}
+	if !EqualShape(decodedInstruction.Grid[0][0], instruction.Grid[0][0]) {
+		t.Errorf("got %+v, want %+v", decodedInstruction, instruction)
+	}
+}
+`), 0o644))
+			cmd := exec.Command("go", "test", "-run", "TestJSONContainers", ".")
+			cmd.Dir = dir
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, "%s", out)
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// monomorphizeGenerics replaces (in place) each instantiation of a generic type
+// (e.g. `Pool<Token, 8>`) with a reference to a concrete type definition
+// (e.g. `PoolToken8`) where the type and const generics have been substituted.
+// The concrete definitions are appended to idlObj.Types, and the generic
+// definitions are removed, since they can't be generated as they are.
+func monomorphizeGenerics(idlObj *idl.Idl) error {
+	m := &monomorphizer{
+		idl:       idlObj,
+		generics:  make(map[string]idl.IdlTypeDef),
+		instances: make(map[string]string),
+		names:     make(map[string]bool),
+	}
+	types := make([]idl.IdlTypeDef, 0, len(idlObj.Types))
+	for _, def := range idlObj.Types {
+		m.names[tools.ToCamelUpper(def.Name)] = true
+		if len(def.Generics) > 0 {
+			m.generics[def.Name] = def
+		} else {
+			types = append(types, def)
+		}
+	}
+	if len(m.generics) == 0 {
+		return nil
+	}
+	// The generic definitions are only used as templates for the concrete ones;
+	// the concrete definitions are appended while mapping.
+	idlObj.Types = types
+	mapIdlTypes(idlObj, m.instantiateRefs)
+	return m.err
+}
+
+type monomorphizer struct {
+	idl       *idl.Idl
+	generics  map[string]idl.IdlTypeDef // Generic definitions, by name.
+	instances map[string]string         // Concrete type name, by instantiation key.
+	names     map[string]bool           // All the type names in use, as generated in Go.
+	err       error
+}
+
+// instantiateRefs is the mapIdlType callback that replaces a reference
+// to a generic type with a reference to its concrete instance.
+func (m *monomorphizer) instantiateRefs(ty idltype.IdlType) idltype.IdlType {
+	defined, ok := ty.(*idltype.Defined)
+	if !ok || len(defined.Generics) == 0 || m.err != nil {
+		return ty
+	}
+	name, err := m.instantiate(defined)
+	if err != nil {
+		m.fail(err)
+		return ty
+	}
+	return &idltype.Defined{Name: name}
+}
+
+// instantiate returns the name of the concrete instance of the given
+// instantiation, creating it if needed.
+func (m *monomorphizer) instantiate(ref *idltype.Defined) (string, error) {
+	def, ok := m.generics[ref.Name]
+	if !ok {
+		return "", fmt.Errorf("type %q is not generic, but was used with generic arguments", ref.Name)
+	}
+	if len(ref.Generics) != len(def.Generics) {
+		return "", fmt.Errorf("type %q expects %d generic arguments, got %d", ref.Name, len(def.Generics), len(ref.Generics))
+	}
+
+	argNames := make([]string, len(ref.Generics))
+	for i, arg := range ref.Generics {
+		argName, err := formatGenericArgName(arg)
+		if err != nil {
+			return "", fmt.Errorf("type %q: generic argument %d: %w", ref.Name, i, err)
+		}
+		argNames[i] = argName
+	}
+	key := ref.Name + "<" + strings.Join(argNames, ",") + ">"
+	if name, ok := m.instances[key]; ok {
+		return name, nil
+	}
+	name := joinGenericArgNames(tools.ToCamelUpper(ref.Name), argNames)
+	docs := def.Docs
+	if m.names[tools.ToCamelUpper(name)] {
+		// The name of another type (e.g. `PoolU8` for `Pool<u8>`, if the IDL
+		// also defines a PoolU8 type): add a suffix, and say what the type is.
+		base := name
+		for i := 2; m.names[tools.ToCamelUpper(name)]; i++ {
+			name = base + "Instance" + strconv.Itoa(i)
+		}
+		docs = append(slices.Clone(docs), fmt.Sprintf("%s is %s.", name, key))
+	}
+	m.instances[key] = name
+	m.names[tools.ToCamelUpper(name)] = true
+
+	typeArgs := make(map[string]idltype.IdlType)
+	constArgs := make(map[string]string)
+	for i, param := range def.Generics {
+		switch param := param.(type) {
+		case *idl.IdlTypeDefGenericType:
+			arg, ok := ref.Generics[i].(*idltype.IdlGenericArgType)
+			if !ok {
+				return "", fmt.Errorf("type %q: expected a type for generic %q, got %T", ref.Name, param.Name, ref.Generics[i])
+			}
+			typeArgs[param.Name] = arg.Ty
+		case *idl.IdlTypeDefGenericConst:
+			arg, ok := ref.Generics[i].(*idltype.IdlGenericArgConst)
+			if !ok {
+				return "", fmt.Errorf("type %q: expected a const for generic %q, got %T", ref.Name, param.Name, ref.Generics[i])
+			}
+			constArgs[param.Name] = arg.Value
+		default:
+			return "", fmt.Errorf("type %q: unknown generic kind %T", ref.Name, param)
+		}
+	}
+
+	substitute := func(ty idltype.IdlType) idltype.IdlType {
+		switch vv := ty.(type) {
+		case *idltype.Generic:
+			if arg, ok := typeArgs[vv.Generic]; ok {
+				return arg
+			}
+		case *idltype.Array:
+			if size, ok := vv.Size.(*idltype.IdlArrayLenGeneric); ok {
+				if value, ok := constArgs[size.Generic]; ok {
+					n, err := strconv.Atoi(strings.ReplaceAll(value, "_", ""))
+					if err != nil || n < 0 {
+						m.fail(fmt.Errorf("type %q: invalid array length %q for generic %q", ref.Name, value, size.Generic))
+						return ty
+					}
+					return &idltype.Array{Type: vv.Type, Size: &idltype.IdlArrayLenValue{Value: n}}
+				}
+			}
+		case *idltype.Defined:
+			// Forward the const generics to nested generic types.
+			for i, arg := range vv.Generics {
+				if constArg, ok := arg.(*idltype.IdlGenericArgConst); ok {
+					if value, ok := constArgs[constArg.Value]; ok {
+						vv.Generics[i] = &idltype.IdlGenericArgConst{Kind: constArg.Kind, Value: value}
+					}
+				}
+			}
+			return m.instantiateRefs(vv)
+		}
+		return ty
+	}
+
+	// Add the concrete definition, with the same shape as the generic one:
+	concrete := idl.IdlTypeDef{
+		Name:          name,
+		Docs:          docs,
+		Serialization: def.Serialization,
+		Repr:          def.Repr,
+		Ty:            def.Ty,
+	}
+	single := &idl.Idl{Types: []idl.IdlTypeDef{concrete}}
+	mapIdlTypes(single, substitute)
+	if m.err != nil {
+		return "", m.err
+	}
+	m.idl.Types = append(m.idl.Types, single.Types[0])
+	return name, nil
+}
+
+func (m *monomorphizer) fail(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// formatGenericArgName returns the part of the name of a concrete
+// instance that corresponds to the given generic argument, e.g.
+// `Token` for `{"kind":"type","type":{"defined":{"name":"Token"}}}`,
+// and `8` for `{"kind":"const","value":"8"}`.
+func formatGenericArgName(arg idltype.IdlGenericArg) (string, error) {
+	switch arg := arg.(type) {
+	case *idltype.IdlGenericArgType:
+		return formatGenericTypeName(arg.Ty)
+	case *idltype.IdlGenericArgConst:
+		return tools.ToCamelUpper(strings.ReplaceAll(arg.Value, "-", "Neg")), nil
+	default:
+		return "", fmt.Errorf("unknown generic argument kind %T", arg)
+	}
+}
+
+func formatGenericTypeName(ty idltype.IdlType) (string, error) {
+	switch vv := ty.(type) {
+	case *idltype.Defined:
+		argNames := make([]string, len(vv.Generics))
+		for i, arg := range vv.Generics {
+			argName, err := formatGenericArgName(arg)
+			if err != nil {
+				return "", err
+			}
+			argNames[i] = argName
+		}
+		return joinGenericArgNames(tools.ToCamelUpper(vv.Name), argNames), nil
+	case *idltype.Option:
+		inner, err := formatGenericTypeName(vv.Option)
+		return "Option" + inner, err
+	case *idltype.COption:
+		inner, err := formatGenericTypeName(vv.COption)
+		return "COption" + inner, err
+	case *idltype.Vec:
+		inner, err := formatGenericTypeName(vv.Vec)
+		return "Vec" + inner, err
+	case *idltype.Array:
+		inner, err := formatGenericTypeName(vv.Type)
+		if err != nil {
+			return "", err
+		}
+		size, ok := vv.Size.(*idltype.IdlArrayLenValue)
+		if !ok {
+			return "", fmt.Errorf("unresolved generic array length %s", vv.Size)
+		}
+		return joinGenericArgNames("Array", []string{inner, strconv.Itoa(size.Value)}), nil
+	case *idltype.Generic:
+		return "", fmt.Errorf("unresolved generic type %q", vv.Generic)
+	default:
+		// Primitives, e.g. "u64" -> "U64", "pubkey" -> "Pubkey".
+		return tools.ToCamelUpper(ty.String()), nil
+	}
+}
+
+// joinGenericArgNames appends the names of the generic arguments to the name of a
+// generic type, with an "x" between two digits, which would be ambiguous otherwise
+// (e.g. `PoolU8x1x23` for `Pool<u8, 1, 23>`, and `PoolU8x12x3` for `Pool<u8, 12, 3>`,
+// generated in Go as PoolU8X1x23 and PoolU8X12x3 by tools.ToCamelUpper).
+func joinGenericArgNames(name string, argNames []string) string {
+	for _, argName := range argNames {
+		if name != "" && argName != "" && isDigit(name[len(name)-1]) && isDigit(argName[0]) {
+			name += "x"
+		}
+		name += argName
+	}
+	return name
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonomorphizeGenerics(t *testing.T) {
+	pool := func(ty idltype.IdlType, size string) *idltype.Defined {
+		return &idltype.Defined{
+			Name: "Pool",
+			Generics: []idltype.IdlGenericArg{
+				&idltype.IdlGenericArgType{Kind: "type", Ty: ty},
+				&idltype.IdlGenericArgConst{Kind: "const", Value: size},
+			},
+		}
+	}
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "Pool",
+				Generics: []idl.IdlTypeDefGeneric{
+					&idl.IdlTypeDefGenericType{Kind: "type", Name: "T"},
+					&idl.IdlTypeDefGenericConst{Kind: "const", Name: "N", Ty: "usize"},
+				},
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "items", Ty: &idltype.Array{Type: &idltype.Generic{Generic: "T"}, Size: &idltype.IdlArrayLenGeneric{Generic: "N"}}},
+						{Name: "grid", Ty: &idltype.Array{Type: &idltype.Array{Type: &idltype.U8{}, Size: &idltype.IdlArrayLenGeneric{Generic: "N"}}, Size: &idltype.IdlArrayLenValue{Value: 2}}},
+					},
+				},
+			},
+			{
+				Name: "Holder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "a", Ty: pool(&idltype.U64{}, "8")},
+						{Name: "b", Ty: pool(&idltype.U64{}, "8")},
+						{Name: "c", Ty: &idltype.Vec{Vec: pool(&idltype.Pubkey{}, "3")}},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, monomorphizeGenerics(idlData))
+
+	// The generic definition is replaced by one concrete definition per instantiation.
+	names := make([]string, len(idlData.Types))
+	for i, def := range idlData.Types {
+		names[i] = def.Name
+	}
+	assert.Equal(t, []string{"Holder", "PoolU64x8", "PoolPubkey3"}, names)
+
+	fields := idlData.Types[0].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)
+	assert.Equal(t, &idltype.Defined{Name: "PoolU64x8"}, fields[0].Ty)
+	assert.Equal(t, &idltype.Defined{Name: "PoolU64x8"}, fields[1].Ty)
+	assert.Equal(t, &idltype.Vec{Vec: &idltype.Defined{Name: "PoolPubkey3"}}, fields[2].Ty)
+
+	poolFields := idlData.Types[2].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)
+	assert.Equal(t, &idltype.Array{Type: &idltype.Pubkey{}, Size: &idltype.IdlArrayLenValue{Value: 3}}, poolFields[0].Ty)
+	assert.Equal(t, &idltype.Array{Type: &idltype.Array{Type: &idltype.U8{}, Size: &idltype.IdlArrayLenValue{Value: 3}}, Size: &idltype.IdlArrayLenValue{Value: 2}}, poolFields[1].Ty)
+
+	// Wrong number of generic arguments.
+	bad := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			idlData.Types[0],
+			{
+				Name:     "Pool",
+				Generics: []idl.IdlTypeDefGeneric{&idl.IdlTypeDefGenericType{Kind: "type", Name: "T"}},
+				Ty:       &idl.IdlTypeDefTyStruct{Kind: "struct"},
+			},
+		},
+	}
+	bad.Types[0].Ty = &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{{Name: "a", Ty: pool(&idltype.U64{}, "8")}}}
+	require.Error(t, monomorphizeGenerics(bad))
+}
+
+func TestMonomorphizeGenericsNames(t *testing.T) {
+	grid := func(ty idltype.IdlType, rows string, cols string) *idltype.Defined {
+		return &idltype.Defined{
+			Name: "Grid",
+			Generics: []idltype.IdlGenericArg{
+				&idltype.IdlGenericArgType{Kind: "type", Ty: ty},
+				&idltype.IdlGenericArgConst{Kind: "const", Value: rows},
+				&idltype.IdlGenericArgConst{Kind: "const", Value: cols},
+			},
+		}
+	}
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "Grid",
+				Generics: []idl.IdlTypeDefGeneric{
+					&idl.IdlTypeDefGenericType{Kind: "type", Name: "T"},
+					&idl.IdlTypeDefGenericConst{Kind: "const", Name: "R", Ty: "usize"},
+					&idl.IdlTypeDefGenericConst{Kind: "const", Name: "C", Ty: "usize"},
+				},
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "cells", Ty: &idltype.Array{Type: &idltype.Array{Type: &idltype.Generic{Generic: "T"}, Size: &idltype.IdlArrayLenGeneric{Generic: "C"}}, Size: &idltype.IdlArrayLenGeneric{Generic: "R"}}},
+					},
+				},
+			},
+			{
+				// Named like the instance Grid<pubkey, 2, 2>.
+				Name: "GridPubkey2x2",
+				Ty:   &idl.IdlTypeDefTyStruct{Kind: "struct"},
+			},
+			{
+				Name: "Holder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						// Without a separator, both would be GridU8123.
+						{Name: "a", Ty: grid(&idltype.U8{}, "1", "23")},
+						{Name: "b", Ty: grid(&idltype.U8{}, "12", "3")},
+						{Name: "c", Ty: grid(&idltype.Pubkey{}, "2", "2")},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, monomorphizeGenerics(idlData))
+
+	fields := idlData.Types[1].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)
+	assert.Equal(t, &idltype.Defined{Name: "GridU8x1x23"}, fields[0].Ty)
+	assert.Equal(t, &idltype.Defined{Name: "GridU8x12x3"}, fields[1].Ty)
+	assert.Equal(t, &idltype.Defined{Name: "GridPubkey2x2Instance2"}, fields[2].Ty)
+	instance := idlData.Types.ByName("GridPubkey2x2Instance2")
+	require.NotNil(t, instance)
+	assert.Equal(t, []string{"GridPubkey2x2Instance2 is Grid<Pubkey,2,2>."}, instance.Docs)
+
+	assert.Equal(t, "ArrayU8x32", joinGenericArgNames("Array", []string{"U8", "32"}))
+	assert.Equal(t, "PoolPubkey3", joinGenericArgNames("Pool", []string{"Pubkey", "3"}))
+}
@@ -1,7 +1,19 @@
 package generator
 
 import (
+	"runtime/debug"
+
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// anchorGoModulePath is the module of the runtime packages imported by the generated code.
+	anchorGoModulePath = "github.com/gagliardetto/anchor-go"
+	// minAnchorGoVersion is the first release of anchor-go with all the runtime packages
+	// (errors, limits, numeric, option, ...) imported by the generated code.
+	minAnchorGoVersion = "v0.4.0"
 )
 
 // gen_gomod generates a `go.mod` file for the generated code, and writes
@@ -11,16 +23,51 @@ func (g *Generator) gen_gomod() ([]byte, error) {
 	mdf.AddModuleStmt(g.options.ModPath)
 
 	mdf.AddNewRequire("github.com/gagliardetto/solana-go", "v1.12.0", false)
-	mdf.AddNewRequire("github.com/gagliardetto/anchor-go", "v0.3.2", false)
+	info, _ := debug.ReadBuildInfo()
+	mdf.AddNewRequire(anchorGoModulePath, anchorGoVersion(info), false)
 	mdf.AddNewRequire("github.com/gagliardetto/binary", "v0.8.0", false)
 	mdf.AddNewRequire("github.com/gagliardetto/treeout", "v0.1.4", false)
 	mdf.AddNewRequire("github.com/gagliardetto/gofuzz", "v1.2.2", false)
 	mdf.AddNewRequire("github.com/stretchr/testify", "v1.10.0", false)
 	mdf.AddNewRequire("github.com/davecgh/go-spew", "v1.1.1", false)
 
-	// add replacement for "github.com/gagliardetto/anchor-go/errors" to ../../demo-anchor-go/errors
-	// mdf.AddReplace("github.com/gagliardetto/anchor-go", "", "../../demo-anchor-go", "")
+	if g.options.AnchorGoReplace != "" {
+		if err := mdf.AddReplace(anchorGoModulePath, "", g.options.AnchorGoReplace, ""); err != nil {
+			return nil, err
+		}
+	}
 	mdf.Cleanup()
 
 	return mdf.Format()
 }
+
+// anchorGoVersion returns the version of anchor-go to require in the generated go.mod:
+// the release the generator was built from (e.g. with `go install ...@v0.4.1`), if it's
+// one that has the runtime packages, else minAnchorGoVersion.
+func anchorGoVersion(info *debug.BuildInfo) string {
+	if info == nil {
+		return minAnchorGoVersion
+	}
+	var mod *debug.Module
+	if info.Main.Path == anchorGoModulePath {
+		mod = &info.Main
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == anchorGoModulePath {
+			mod = dep
+		}
+	}
+	if mod != nil && mod.Replace != nil {
+		mod = mod.Replace
+	}
+	if mod != nil && isReleasedVersion(mod.Version) && semver.Compare(mod.Version, minAnchorGoVersion) >= 0 {
+		return mod.Version
+	}
+	return minAnchorGoVersion
+}
+
+// isReleasedVersion tells whether version is a release, unlike "(devel)", the pseudo-versions
+// of the builds from a checkout, or the versions of builds from a modified checkout.
+func isReleasedVersion(version string) bool {
+	return semver.IsValid(version) && !module.IsPseudoVersion(version) && semver.Build(version) == ""
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/mod/modfile"
+)
+
+func TestAnchorGoVersion(t *testing.T) {
+	for name, tt := range map[string]struct {
+		info        *debug.BuildInfo
+		wantVersion string
+	}{
+		"Installed": {
+			info:        &debug.BuildInfo{Main: debug.Module{Path: anchorGoModulePath, Version: "v1.2.3"}},
+			wantVersion: "v1.2.3",
+		},
+		"Dependency": {
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tool", Version: "(devel)"},
+				Deps: []*debug.Module{{Path: anchorGoModulePath, Version: "v1.4.0"}},
+			},
+			wantVersion: "v1.4.0",
+		},
+		"Replaced by a version": {
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tool"},
+				Deps: []*debug.Module{{Path: anchorGoModulePath, Version: "v1.4.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v1.4.1"}}},
+			},
+			wantVersion: "v1.4.1",
+		},
+		"Replaced by a directory": {
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tool"},
+				Deps: []*debug.Module{{Path: anchorGoModulePath, Version: "v1.4.0", Replace: &debug.Module{Path: "../anchor-go"}}},
+			},
+			wantVersion: minAnchorGoVersion,
+		},
+		"Checkout": {
+			info:        &debug.BuildInfo{Main: debug.Module{Path: anchorGoModulePath, Version: "(devel)"}},
+			wantVersion: minAnchorGoVersion,
+		},
+		"Modified checkout": {
+			info:        &debug.BuildInfo{Main: debug.Module{Path: anchorGoModulePath, Version: "v1.2.4-0.20261019120000-abcdef123456+dirty"}},
+			wantVersion: minAnchorGoVersion,
+		},
+		"Built in a checkout": {
+			info:        &debug.BuildInfo{Main: debug.Module{Path: anchorGoModulePath, Version: "v1.2.4-0.20261019120000-abcdef123456"}},
+			wantVersion: minAnchorGoVersion,
+		},
+		"Older release": {
+			info:        &debug.BuildInfo{Main: debug.Module{Path: anchorGoModulePath, Version: "v0.3.2"}},
+			wantVersion: minAnchorGoVersion,
+		},
+		"Unknown": {
+			wantVersion: minAnchorGoVersion,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.wantVersion, anchorGoVersion(tt.info))
+		})
+	}
+}
+
+func TestGenGoMod(t *testing.T) {
+	t.Run("Released", func(t *testing.T) {
+		gen := &Generator{options: &GeneratorOptions{ModPath: "example.com/client"}}
+		data, err := gen.gen_gomod()
+		require.NoError(t, err)
+		mdf, err := modfile.Parse("go.mod", data, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "example.com/client", mdf.Module.Mod.Path)
+		assert.Empty(t, mdf.Replace)
+		var version string
+		for _, req := range mdf.Require {
+			if req.Mod.Path == anchorGoModulePath {
+				version = req.Mod.Version
+			}
+		}
+		assert.True(t, isReleasedVersion(version), version)
+	})
+	t.Run("Replaced", func(t *testing.T) {
+		gen := &Generator{options: &GeneratorOptions{ModPath: "example.com/client", AnchorGoReplace: "../anchor-go"}}
+		data, err := gen.gen_gomod()
+		require.NoError(t, err)
+		mdf, err := modfile.Parse("go.mod", data, nil)
+		require.NoError(t, err)
+		require.Len(t, mdf.Replace, 1)
+		assert.Equal(t, anchorGoModulePath, mdf.Replace[0].Old.Path)
+		assert.Equal(t, "../anchor-go", mdf.Replace[0].New.Path)
+	})
+}
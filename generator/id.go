@@ -1,21 +1,100 @@
 package generator
 
 import (
+	"fmt"
+
 	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
 	"github.com/gagliardetto/solana-go"
 )
 
-// TODO:
-// - generate program IDs for mainnet, devnet, testnet, and localnet.
+// clusterProgramID is the program ID deployed on a cluster (from metadata.deployments).
+type clusterProgramID struct {
+	cluster string // e.g. "Mainnet"
+	id      solana.PublicKey
+}
+
+// clusterProgramIDs returns the program IDs listed in metadata.deployments.
+func (g *Generator) clusterProgramIDs() ([]clusterProgramID, error) {
+	if !g.idl.Metadata.Deployments.IsSome() {
+		return nil, nil
+	}
+	deployments := g.idl.Metadata.Deployments.Unwrap()
+	var ids []clusterProgramID
+	for _, deployment := range []struct {
+		cluster string
+		address idl.Option[string]
+	}{
+		{"Mainnet", deployments.Mainnet},
+		{"Testnet", deployments.Testnet},
+		{"Devnet", deployments.Devnet},
+		{"Localnet", deployments.Localnet},
+	} {
+		if !deployment.address.IsSome() {
+			continue
+		}
+		id, err := solana.PublicKeyFromBase58(deployment.address.Unwrap())
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s program ID in metadata.deployments: %w", deployment.cluster, err)
+		}
+		ids = append(ids, clusterProgramID{cluster: deployment.cluster, id: id})
+	}
+	return ids, nil
+}
+
+// programID returns the default program ID of the generated code: the one provided
+// in the options, else the first one of metadata.deployments (nil if none).
+func (g *Generator) programID() *solana.PublicKey {
+	if g.options.ProgramId != nil {
+		return g.options.ProgramId
+	}
+	ids, err := g.clusterProgramIDs()
+	if err != nil || len(ids) == 0 {
+		return nil
+	}
+	return &ids[0].id
+}
 
 func (g *Generator) genfile_programID(id solana.PublicKey) (*OutputFile, error) {
 	file := NewFile(g.options.Package)
 	file.HeaderComment("Code generated by https://github.com/gagliardetto/anchor-go. DO NOT EDIT.")
 	file.HeaderComment("This file contains the program ID.")
 
+	clusterIDs, err := g.clusterProgramIDs()
+	if err != nil {
+		return nil, err
+	}
+	{
+		file.Comment("ProgramID is the default program ID; the instruction builders and the error parser")
+		file.Comment("use the one returned by GetProgramID, which can be changed with SetProgramID.")
+		file.Var().Id("ProgramID").Op("=").Qual(PkgSolanaGo, "MustPublicKeyFromBase58").Call(Lit(id.String()))
+		file.Line()
+	}
+	if len(clusterIDs) > 0 {
+		file.Comment("Program IDs of the deployments listed in the IDL metadata:")
+		file.Var().DefsFunc(func(group *Group) {
+			for _, clusterID := range clusterIDs {
+				group.Id("ProgramID"+clusterID.cluster).Op("=").Qual(PkgSolanaGo, "MustPublicKeyFromBase58").Call(Lit(clusterID.id.String()))
+			}
+		})
+		file.Line()
+	}
 	{
-		idDecl := Var().Id("ProgramID").Op("=").Qual(PkgSolanaGo, "MustPublicKeyFromBase58").Call(Lit(id.String()))
-		file.Add(idDecl)
+		file.Var().Id("programIDOverride").Qual("sync/atomic", "Pointer").Types(Qual(PkgSolanaGo, "PublicKey"))
+		file.Line()
+		file.Comment("SetProgramID sets the program ID used by the instruction builders and the error parser")
+		file.Comment("(e.g. to ProgramIDDevnet, or to the ID of a fork); it is safe for concurrent use.")
+		file.Func().Id("SetProgramID").Params(Id("programID").Qual(PkgSolanaGo, "PublicKey")).Block(
+			Id("programIDOverride").Dot("Store").Call(Op("&").Id("programID")),
+		)
+		file.Line()
+		file.Comment("GetProgramID returns the program ID set with SetProgramID, or ProgramID if it was never called.")
+		file.Func().Id("GetProgramID").Params().Qual(PkgSolanaGo, "PublicKey").Block(
+			If(Id("programID").Op(":=").Id("programIDOverride").Dot("Load").Call(), Id("programID").Op("!=").Nil()).Block(
+				Return(Op("*").Id("programID")),
+			),
+			Return(Id("ProgramID")),
+		)
 	}
 
 	return &OutputFile{
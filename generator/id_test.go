@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenProgramID(t *testing.T) {
+	idlData := &idl.Idl{
+		Metadata: idl.IdlMetadata{
+			Deployments: idl.Some(idl.IdlDeployments{
+				Mainnet: idl.Some("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"),
+				Devnet:  idl.Some("So11111111111111111111111111111111111111112"),
+			}),
+		},
+	}
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	// Without a program ID in the options, the default is the first deployment.
+	programID := gen.programID()
+	require.NotNil(t, programID)
+	require.Equal(t, "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS", programID.String())
+
+	file, err := gen.genfile_programID(*programID)
+	require.NoError(t, err)
+	generatedCode := file.File.GoString()
+	for _, expected := range []string{
+		`var ProgramID = solanago.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")`,
+		`ProgramIDMainnet = solanago.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")`,
+		`ProgramIDDevnet  = solanago.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")`,
+		"func SetProgramID(programID solanago.PublicKey) {",
+		"func GetProgramID() solanago.PublicKey {",
+	} {
+		assert.Contains(t, generatedCode, expected, "Generated code:\n%s", generatedCode)
+	}
+	assert.NotContains(t, generatedCode, "ProgramIDTestnet")
+	assert.NotContains(t, generatedCode, "ProgramIDLocalnet")
+
+	t.Run("Invalid deployment", func(t *testing.T) {
+		idlData.Metadata.Deployments = idl.Some(idl.IdlDeployments{Testnet: idl.Some("not a pubkey")})
+		_, err := gen.clusterProgramIDs()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid Testnet program ID")
+		assert.Nil(t, gen.programID())
+	})
+}
@@ -19,6 +19,66 @@ func (g *Generator) gen_instructions() (*OutputFile, error) {
 			ixCode := Empty()
 			{
 				declarerName := newInstructionFuncName(instruction.Name)
+				// The parameters of the builder (and of its package-level wrapper):
+				genParams := func() Code {
+					return DoGroup(
+						func(g *Group) {
+							addCommentSections := len(instruction.Args) > 0 && len(instruction.Accounts) > 0
+							if addCommentSections {
+								g.Line().Comment("Params:")
+							}
+							g.Add(
+								ListMultiline(
+									func(paramsCode *Group) {
+										for _, param := range instruction.Args {
+											paramsCode.Id(formatParamName(param.Name)).Add(genValueTypeName(param.Ty))
+										}
+									},
+								),
+							)
+							if addCommentSections {
+								g.Line().Comment("Accounts:")
+							}
+							g.Add(
+								ListMultiline(
+									func(accountsCode *Group) {
+										for _, account := range instruction.Accounts {
+											switch acc := account.(type) {
+											case *idl.IdlInstructionAccount:
+												{
+													accountsCode.Id(formatAccountNameParam(acc.Name)).Qual(PkgSolanaGo, "PublicKey")
+												}
+												// TODO: for accounts:
+												// - Optional?
+												// - PDA?
+												// - Address?
+												// - Relations?
+											case *idl.IdlInstructionAccounts:
+												{
+													panic(fmt.Errorf("Accounts groups are not supported yet: %s", acc.Name))
+													// accs := acc.Accounts
+													// // add comment for the accounts
+													// if len(accs) > 0 {
+													// 	accountsCode.Commentf("Accounts group %q:", acc.Name)
+													// }
+													// for _, acc := range accs {
+													// 	// If the account has a name, use it as the parameter name.
+													// 	// Otherwise, use a generic name.
+													// 	acc := acc.(*idl.IdlInstructionAccount)
+													// 	accountName := formatAccountNameParam(acc.Name)
+													// 	accountsCode.Id(accountName).Qual(PkgSolanaGo, "PublicKey")
+													// }
+												}
+											default:
+												panic("unknown account type: " + spew.Sdump(account))
+											}
+										}
+									},
+								),
+							)
+						},
+					)
+				}
 				ixCode.Commentf("Builds a %q instruction.", instruction.Name)
 				{
 					if len(instruction.Docs) > 0 {
@@ -30,90 +90,28 @@ func (g *Generator) gen_instructions() (*OutputFile, error) {
 					}
 				}
 				ixCode.Line()
-				ixCode.Func().Id(declarerName).
-					Params(
-						DoGroup(
-							func(g *Group) {
-								addCommentSections := len(instruction.Args) > 0 && len(instruction.Accounts) > 0
-								if addCommentSections {
-									g.Line().Comment("Params:")
-								}
-								g.Add(
-									ListMultiline(
-										func(paramsCode *Group) {
-											for _, param := range instruction.Args {
-												paramType := genTypeName(param.Ty)
-												if IsOption(param.Ty) || IsCOption(param.Ty) {
-													paramType = Op("*").Add(paramType)
-												}
-												paramsCode.Id(formatParamName(param.Name)).Add(paramType)
-											}
-										},
-									),
-								)
-								if addCommentSections {
-									g.Line().Comment("Accounts:")
-								}
-								g.Add(
-									ListMultiline(
-										func(accountsCode *Group) {
-											for _, account := range instruction.Accounts {
-												switch acc := account.(type) {
-												case *idl.IdlInstructionAccount:
-													{
-														accountsCode.Id(formatAccountNameParam(acc.Name)).Qual(PkgSolanaGo, "PublicKey")
-													}
-													// TODO: for accounts:
-													// - Optional?
-													// - PDA?
-													// - Address?
-													// - Relations?
-												case *idl.IdlInstructionAccounts:
-													{
-														panic(fmt.Errorf("Accounts groups are not supported yet: %s", acc.Name))
-														// accs := acc.Accounts
-														// // add comment for the accounts
-														// if len(accs) > 0 {
-														// 	accountsCode.Commentf("Accounts group %q:", acc.Name)
-														// }
-														// for _, acc := range accs {
-														// 	// If the account has a name, use it as the parameter name.
-														// 	// Otherwise, use a generic name.
-														// 	acc := acc.(*idl.IdlInstructionAccount)
-														// 	accountName := formatAccountNameParam(acc.Name)
-														// 	accountsCode.Id(accountName).Qual(PkgSolanaGo, "PublicKey")
-														// }
-													}
-												default:
-													panic("unknown account type: " + spew.Sdump(account))
-												}
-											}
-										},
-									),
-								)
-							},
-						),
-					).
+				ixCode.Func().Params(Id("c").Op("*").Id("Client")).Id(declarerName).
+					Params(genParams()).
 					ParamsFunc(func(returnsCode *Group) {
 						returnsCode.Qual(PkgSolanaGo, "Instruction")
 						returnsCode.Error()
 					}).BlockFunc(func(body *Group) {
-					if len(instruction.Args) > 0 {
-						body.Id("buf__").Op(":=").New(Qual("bytes", "Buffer"))
-						body.Id("enc__").Op(":=").Qual(PkgBinary, "NewBorshEncoder").Call(Id("buf__"))
+					body.Id("buf__").Op(":=").New(Qual("bytes", "Buffer"))
+					body.Id("enc__").Op(":=").Qual(PkgBinary, "NewBorshEncoder").Call(Id("buf__"))
 
-						{
-							// write the discriminator
-							body.Line().Comment("Encode the instruction discriminator.")
-							discriminatorName := FormatInstructionDiscriminatorName(instruction.Name)
-							body.Err().Op(":=").Id("enc__").Dot("WriteBytes").Call(Id(discriminatorName).Index(Op(":")), False())
-							body.If(Err().Op("!=").Nil()).Block(
-								Return(
-									Nil(),
-									Qual("fmt", "Errorf").Call(Lit("failed to write instruction discriminator: %w"), Err()),
-								),
-							)
-						}
+					{
+						// write the discriminator (also for instructions without args)
+						body.Line().Comment("Encode the instruction discriminator.")
+						discriminatorName := FormatInstructionDiscriminatorName(instruction.Name)
+						body.Err().Op(":=").Id("enc__").Dot("WriteBytes").Call(Id(discriminatorName).Index(Op(":")), False())
+						body.If(Err().Op("!=").Nil()).Block(
+							Return(
+								Nil(),
+								Qual("fmt", "Errorf").Call(Lit("failed to write instruction discriminator: %w"), Err()),
+							),
+						)
+					}
+					if len(instruction.Args) > 0 {
 						// for _, param := range instruction.Args {
 						// 	paramName := formatParamName(param.Name)
 						// 	isComplexEnum(param.Ty)
@@ -220,13 +218,9 @@ func (g *Generator) gen_instructions() (*OutputFile, error) {
 							func(g *Group) {
 								g.Add(
 									ListMultiline(func(gg *Group) {
-										gg.Id("ProgramID")
+										gg.Id("c").Dot("programID")
 										gg.Id("accounts__")
-										if len(instruction.Args) > 0 {
-											gg.Id("buf__").Dot("Bytes").Call()
-										} else {
-											gg.Nil() // No arguments to encode.
-										}
+										gg.Id("buf__").Dot("Bytes").Call()
 									}),
 								)
 							},
@@ -234,6 +228,26 @@ func (g *Generator) gen_instructions() (*OutputFile, error) {
 						Nil(), // No error
 					)
 				})
+
+				// The package-level builder uses the default client:
+				ixCode.Line().Line()
+				ixCode.Commentf("%s builds a %q instruction of the program at GetProgramID() (see Client.%s).", declarerName, instruction.Name, declarerName)
+				ixCode.Line()
+				ixCode.Func().Id(declarerName).
+					Params(genParams()).
+					Params(Qual(PkgSolanaGo, "Instruction"), Error()).
+					Block(
+						Return(Id("Default").Call().Dot(declarerName).CallFunc(func(args *Group) {
+							for _, param := range instruction.Args {
+								args.Id(formatParamName(param.Name))
+							}
+							for _, account := range instruction.Accounts {
+								if acc, ok := account.(*idl.IdlInstructionAccount); ok {
+									args.Id(formatAccountNameParam(acc.Name))
+								}
+							}
+						})),
+					)
 			}
 			file.Add(ixCode)
 		}
@@ -244,15 +258,7 @@ func (g *Generator) gen_instructions() (*OutputFile, error) {
 		typeNames := []string{}
 		discriminatorNames := []string{}
 		for _, instruction := range g.idl.Instructions {
-			// Check if the instruction name already ends with "instruction" (case-insensitive)
-			instructionNameLower := strings.ToLower(instruction.Name)
-			if strings.HasSuffix(instructionNameLower, "instruction") {
-				// Already has "instruction" suffix, don't add it again
-				typeNames = append(typeNames, tools.ToCamelUpper(instruction.Name))
-			} else {
-				// Add "Instruction" suffix
-				typeNames = append(typeNames, tools.ToCamelUpper(instruction.Name)+"Instruction")
-			}
+			typeNames = append(typeNames, formatInstructionTypeName(instruction.Name))
 			discriminatorNames = append(discriminatorNames, tools.ToCamelUpper(instruction.Name))
 		}
 
@@ -317,6 +323,18 @@ func newInstructionFuncName(instructionName string) string {
 	}
 }
 
+// formatInstructionTypeName returns the name of the struct of the parsed instruction.
+func formatInstructionTypeName(instructionName string) string {
+	// Check if the instruction name already ends with "instruction" (case-insensitive)
+	instructionNameLower := strings.ToLower(instructionName)
+	if strings.HasSuffix(instructionNameLower, "instruction") {
+		// Already has "instruction" suffix, don't add it again
+		return tools.ToCamelUpper(instructionName)
+	}
+	// Add "Instruction" suffix
+	return tools.ToCamelUpper(instructionName) + "Instruction"
+}
+
 func formatAccountCommentDocs(index int, account *idl.IdlInstructionAccount) string {
 	buf := new(strings.Builder)
 	buf.WriteString(fmt.Sprintf("Account %d %q", index, account.Name))
@@ -473,25 +491,13 @@ func (g *Generator) gen_instructionType(instruction idl.IdlInstruction) (Code, e
 	code := Empty()
 
 	// Check if the instruction name already ends with "instruction" (case-insensitive)
-	instructionNameLower := strings.ToLower(instruction.Name)
-	var typeName string
-	if strings.HasSuffix(instructionNameLower, "instruction") {
-		// Already has "instruction" suffix, don't add it again
-		typeName = tools.ToCamelUpper(instruction.Name)
-	} else {
-		// Add "Instruction" suffix
-		typeName = tools.ToCamelUpper(instruction.Name) + "Instruction"
-	}
+	typeName := formatInstructionTypeName(instruction.Name)
 
 	// Generate the instruction struct type
 	code.Type().Id(typeName).StructFunc(func(structGroup *Group) {
 		// Add fields for each instruction argument
 		for _, arg := range instruction.Args {
-			fieldType := genTypeName(arg.Ty)
-			if IsOption(arg.Ty) || IsCOption(arg.Ty) {
-				fieldType = Op("*").Add(fieldType)
-			}
-			structGroup.Id(tools.ToCamelUpper(arg.Name)).Add(fieldType).Tag(map[string]string{
+			structGroup.Id(tools.ToCamelUpper(arg.Name)).Add(genValueTypeName(arg.Ty)).Tag(map[string]string{
 				"json": arg.Name,
 			})
 		}
@@ -536,6 +542,18 @@ func (g *Generator) gen_instructionType(instruction idl.IdlInstruction) (Code, e
 		}
 	})
 
+	// Generate MarshalJSON/UnmarshalJSON methods, if the arguments contain complex enums
+	code.Add(gen_complexEnumJSON_struct(
+		typeName,
+		namedStructFields(
+			idl.IdlDefinedFieldsNamed(instruction.Args),
+			formatFieldGoName,
+			func(field idl.IdlField) string {
+				return field.Name
+			},
+		),
+	))
+
 	// Generate GetDiscriminator method (required by Instruction interface)
 	code.Line().Line()
 	code.Func().Params(Id("obj").Op("*").Id(typeName)).Id("GetDiscriminator").
@@ -545,11 +563,14 @@ func (g *Generator) gen_instructionType(instruction idl.IdlInstruction) (Code, e
 			Return(Id(FormatInstructionDiscriminatorName(tools.ToCamelUpper(instruction.Name))).Index(Op(":"))),
 		)
 
-	// Generate UnmarshalWithDecoder method
+	// Generate UnmarshalWithDecoder and UnmarshalWithLimits methods
 	code.Line().Line()
 	code.Commentf("UnmarshalWithDecoder unmarshals the %s from Borsh-encoded bytes prefixed with its discriminator.", typeName).Line()
-	code.Func().Params(Id("obj").Op("*").Id(typeName)).Id("UnmarshalWithDecoder").
-		Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).
+	code.Add(gen_UnmarshalWithDecoder("obj", typeName))
+	code.Line().Line()
+	code.Commentf("UnmarshalWithLimits is like UnmarshalWithDecoder, with the limits of budget.").Line()
+	code.Func().Params(Id("obj").Op("*").Id(typeName)).Id("UnmarshalWithLimits").
+		Params(ListFunc(decoderParams)).
 		Params(Error()).
 		BlockFunc(func(block *Group) {
 			// Note: discriminator has already been read and validated by the parser
@@ -575,38 +596,13 @@ func (g *Generator) gen_instructionType(instruction idl.IdlInstruction) (Code, e
 					),
 				)
 			}
-			for _, arg := range instruction.Args {
-				fieldName := tools.ToCamelUpper(arg.Name)
-				block.Commentf("Deserialize `%s`:", fieldName)
-
-				if IsOption(arg.Ty) || IsCOption(arg.Ty) {
-					var optionalityReaderName string
-					switch {
-					case IsOption(arg.Ty):
-						optionalityReaderName = "ReadOption"
-					case IsCOption(arg.Ty):
-						optionalityReaderName = "ReadCOption"
-					}
-
-					block.BlockFunc(func(optGroup *Group) {
-						optGroup.List(Id("ok"), Err()).Op(":=").Id("decoder").Dot(optionalityReaderName).Call()
-						optGroup.If(Err().Op("!=").Nil()).Block(
-							Return(Err()),
-						)
-						optGroup.If(Id("ok")).Block(
-							List(Err()).Op("=").Id("decoder").Dot("Decode").Call(Op("&").Id("obj").Dot(fieldName)),
-							If(Err().Op("!=").Nil()).Block(
-								Return(Err()),
-							),
-						)
-					})
-				} else {
-					block.List(Err()).Op("=").Id("decoder").Dot("Decode").Call(Op("&").Id("obj").Dot(fieldName))
-					block.If(Err().Op("!=").Nil()).Block(
-						Return(Err()),
-					)
-				}
-			}
+			gen_unmarshal_DefinedFieldsNamed(
+				block,
+				instruction.Args,
+				func(field idl.IdlField) *Statement {
+					return Id("obj").Dot(tools.ToCamelUpper(field.Name))
+				},
+			)
 
 			// Note: Accounts are not typically serialized in instruction data
 			// They are passed as part of the transaction's account metas
@@ -634,8 +630,7 @@ func (g *Generator) gen_instructionType(instruction idl.IdlInstruction) (Code, e
 					case *idl.IdlInstructionAccount:
 						{
 							block.Commentf("Decode from %s account index", acc.Name)
-							block.Id("index").Op("=").Uint8().Call(Lit(0))
-							block.List(Err()).Op("=").Id("decoder").Dot("Decode").Call(Op("&").Id("index"))
+							block.List(Id("index"), Err()).Op("=").Id("decoder").Dot("ReadUint8").Call()
 							block.If(Err().Op("!=").Nil()).Block(
 								Return(Nil(), Qual("fmt", "Errorf").Call(Lit("failed to decode %s account index: %w"), Lit(acc.Name), Err())),
 							)
@@ -754,10 +749,8 @@ func (g *Generator) gen_instructionType(instruction idl.IdlInstruction) (Code, e
 		Params(Id("buf").Index().Byte()).
 		Params(Error()).
 		BlockFunc(func(block *Group) {
-			block.Var().Id("err").Error()
-			block.List(Err()).Op("=").Id("obj").Dot("UnmarshalWithDecoder").Call(
-				Qual(PkgBinary, "NewBorshDecoder").Call(Id("buf")),
-			)
+			block.Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("buf"))
+			block.Err().Op(":=").Id("obj").Dot("UnmarshalWithDecoder").Call(Id("decoder"))
 			block.If(Err().Op("!=").Nil()).Block(
 				Return(
 					Qual("fmt", "Errorf").Call(
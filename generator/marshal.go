@@ -5,7 +5,6 @@ import (
 
 	. "github.com/dave/jennifer/jen"
 	"github.com/gagliardetto/anchor-go/idl"
-	"github.com/gagliardetto/anchor-go/idl/idltype"
 	"github.com/gagliardetto/anchor-go/tools"
 )
 
@@ -151,231 +150,70 @@ func gen_marshal_DefinedFieldsNamed(
 			body.Commentf("Serialize `%s`:", exportedArgName)
 		}
 
-		if isComplexEnum(field.Ty) || (IsArray(field.Ty) && isComplexEnum(field.Ty.(*idltype.Array).Type)) || (IsVec(field.Ty) && isComplexEnum(field.Ty.(*idltype.Vec).Vec)) {
-			switch field.Ty.(type) {
-			case *idltype.Defined:
-				enumTypeName := field.Ty.(*idltype.Defined).Name
-				body.BlockFunc(func(argBody *Group) {
-					argBody.Err().Op(":=").Id(formatEnumEncoderName(enumTypeName)).Call(Id(encoderVariableName), nameFormatter(field))
-					argBody.If(
-						Err().Op("!=").Nil(),
-					).Block(
-						ReturnFunc(
-							func(returnBody *Group) {
-								if returnNilErr {
-									returnBody.Nil()
-								}
-								returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-									Lit(exportedArgName),
-									Err(),
-								)
-							},
-						),
-					)
-				})
-			case *idltype.Array:
-				enumTypeName := field.Ty.(*idltype.Array).Type.(*idltype.Defined).Name
-				// TODO: handle array length, which is defined in the type.
-				body.BlockFunc(func(argBody *Group) {
-					argBody.For(
-						Id("i").Op(":=").Lit(0),
-						Id("i").Op("<").Len(nameFormatter(field)),
-						Id("i").Op("++"),
-					).BlockFunc(func(forBody *Group) {
-						forBody.Err().Op(":=").Id(formatEnumEncoderName(enumTypeName)).Call(
-							Id(encoderVariableName),
-							nameFormatter(field).Index(Id("i")),
-						)
-						forBody.If(
-							Err().Op("!=").Nil(),
-						).Block(
-							ReturnFunc(
-								func(returnBody *Group) {
-									if returnNilErr {
-										returnBody.Nil()
-									}
-									returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-										Lit(exportedArgName),
-										Qual(PkgAnchorGoErrors, "NewIndex").Call(
-											Id("i"),
-											Err(),
-										),
-									)
-								},
-							),
-						)
-					})
+		returnErr := func(err Code) Code {
+			return ReturnFunc(func(returnBody *Group) {
+				if returnNilErr {
+					returnBody.Nil()
+				}
+				returnBody.Add(err)
+			})
+		}
+		switch {
+		case genericOptions && (IsOption(field.Ty) || IsCOption(field.Ty)):
+			gen_marshal_genericOption(body, field, nameFormatter, encoderVariableName, returnNilErr)
+		case IsOption(field.Ty) || IsCOption(field.Ty):
+			inner := optionInnerType(field.Ty)
+			var optionalityWriterName string
+			if IsOption(field.Ty) {
+				optionalityWriterName = "WriteOption"
+			} else {
+				optionalityWriterName = "WriteCOption"
+			}
+			returnOptionalityErr := returnErr(
+				Qual(PkgAnchorGoErrors, "NewOption").Call(
+					Lit(field.Name),
+					Qual("fmt", "Errorf").Call(
+						Lit("error while encoding optionality: %w"),
+						Err(),
+					),
+				),
+			)
+			encodeValue := func(someBody *Group) {
+				gen_encodeValue(someBody, encoderVariableName, derefOption(nameFormatter(field), inner), inner, 0, func(err Code) Code {
+					return returnErr(Qual(PkgAnchorGoErrors, "NewField").Call(Lit(field.Name), err))
 				})
-			case *idltype.Vec:
-				enumTypeName := field.Ty.(*idltype.Vec).Vec.(*idltype.Defined).Name
-				body.BlockFunc(func(argBody *Group) {
-					argBody.Err().Op(":=").Id(encoderVariableName).Dot("WriteLength").Call(
-						Len(nameFormatter(field)),
-					)
-					argBody.If(
-						Err().Op("!=").Nil(),
-					).Block(
-						ReturnFunc(
-							func(returnBody *Group) {
-								if returnNilErr {
-									returnBody.Nil()
-								}
-								returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-									Lit(exportedArgName),
-									Qual("fmt", "Errorf").Call(
-										Lit("error while writing vector length: %w"),
-										Err(),
-									),
-								)
-							},
+			}
+			if checkNil {
+				body.BlockFunc(func(optGroup *Group) {
+					// if nil:
+					optGroup.If(nameFormatter(field).Op("==").Nil()).Block(
+						Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(False()),
+						If(Err().Op("!=").Nil()).Block(
+							returnOptionalityErr,
 						),
-					)
-					argBody.For(
-						Id("i").Op(":=").Lit(0),
-						Id("i").Op("<").Len(nameFormatter(field)),
-						Id("i").Op("++"),
-					).BlockFunc(func(forBody *Group) {
-						forBody.Err().Op(":=").Id(formatEnumEncoderName(enumTypeName)).Call(
-							Id(encoderVariableName),
-							nameFormatter(field).Index(Id("i")),
-						)
-						forBody.If(
-							Err().Op("!=").Nil(),
-						).Block(
-							ReturnFunc(
-								func(returnBody *Group) {
-									if returnNilErr {
-										returnBody.Nil()
-									}
-									returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-										Lit(exportedArgName),
-										Qual(PkgAnchorGoErrors, "NewIndex").Call(
-											Id("i"),
-											Err(),
-										),
-									)
-								},
-							),
+					).Else().BlockFunc(func(someBody *Group) {
+						someBody.Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(True())
+						someBody.If(Err().Op("!=").Nil()).Block(
+							returnOptionalityErr,
 						)
+						encodeValue(someBody)
 					})
 				})
-			}
-		} else {
-			if IsOption(field.Ty) || IsCOption(field.Ty) {
-				var optionalityWriterName string
-				if IsOption(field.Ty) {
-					optionalityWriterName = "WriteOption"
-				} else {
-					optionalityWriterName = "WriteCOption"
-				}
-				if checkNil {
-					body.BlockFunc(func(optGroup *Group) {
-						// if nil:
-						optGroup.If(nameFormatter(field).Op("==").Nil()).Block(
-							Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(False()),
-							If(Err().Op("!=").Nil()).Block(
-								ReturnFunc(
-									func(returnBody *Group) {
-										if returnNilErr {
-											returnBody.Nil()
-										}
-										returnBody.Qual(PkgAnchorGoErrors, "NewOption").Call(
-											Lit(exportedArgName),
-											Qual("fmt", "Errorf").Call(
-												Lit("error while encoding optionality: %w"),
-												Err(),
-											),
-										)
-									},
-								),
-							),
-						).Else().Block(
-							Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(True()),
-							If(Err().Op("!=").Nil()).Block(
-								ReturnFunc(
-									func(returnBody *Group) {
-										if returnNilErr {
-											returnBody.Nil()
-										}
-										returnBody.Qual(PkgAnchorGoErrors, "NewOption").Call(
-											Lit(exportedArgName),
-											Qual("fmt", "Errorf").Call(
-												Lit("error while encoding optionality: %w"),
-												Err(),
-											),
-										)
-									},
-								),
-							),
-							Err().Op("=").Id(encoderVariableName).Dot("Encode").Call(nameFormatter(field)),
-							If(Err().Op("!=").Nil()).Block(
-								ReturnFunc(
-									func(returnBody *Group) {
-										if returnNilErr {
-											returnBody.Nil()
-										}
-										returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-											Lit(exportedArgName),
-											Err(),
-										)
-									},
-								),
-							),
-						)
-					})
-				} else {
-					body.BlockFunc(func(optGroup *Group) {
-						// TODO: make optional fields of accounts a pointer.
-						// Write as if not nil:
-						optGroup.Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(True())
-						optGroup.If(Err().Op("!=").Nil()).Block(
-							ReturnFunc(
-								func(returnBody *Group) {
-									if returnNilErr {
-										returnBody.Nil()
-									}
-									returnBody.Qual(PkgAnchorGoErrors, "NewOption").Call(
-										Lit(exportedArgName),
-										Qual("fmt", "Errorf").Call(
-											Lit("error while encoding optionality: %w"),
-											Err(),
-										),
-									)
-								},
-							),
-						)
-						optGroup.Err().Op("=").Id(encoderVariableName).Dot("Encode").Call(nameFormatter(field))
-						optGroup.If(Err().Op("!=").Nil()).Block(
-							ReturnFunc(
-								func(returnBody *Group) {
-									if returnNilErr {
-										returnBody.Nil()
-									}
-									returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-										Lit(exportedArgName),
-										Err(),
-									)
-								},
-							),
-						)
-					})
-				}
 			} else {
-				body.Err().Op("=").Id(encoderVariableName).Dot("Encode").Call(nameFormatter(field))
-				body.If(Err().Op("!=").Nil()).Block(
-					ReturnFunc(
-						func(returnBody *Group) {
-							if returnNilErr {
-								returnBody.Nil()
-							}
-							returnBody.Qual(PkgAnchorGoErrors, "NewField").Call(
-								Lit(exportedArgName),
-								Err(),
-							)
-						},
-					),
-				)
+				body.BlockFunc(func(optGroup *Group) {
+					// TODO: make optional fields of accounts a pointer.
+					// Write as if not nil:
+					optGroup.Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(True())
+					optGroup.If(Err().Op("!=").Nil()).Block(
+						returnOptionalityErr,
+					)
+					encodeValue(optGroup)
+				})
 			}
+		default:
+			gen_encodeValue(body, encoderVariableName, nameFormatter(field), field.Ty, 0, func(err Code) Code {
+				return returnErr(Qual(PkgAnchorGoErrors, "NewField").Call(Lit(field.Name), err))
+			})
 		}
 	}
 }
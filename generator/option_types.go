@@ -0,0 +1,125 @@
+package generator
+
+import (
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+)
+
+// genericOptions tells whether options and coptions are generated as option.Option[T]
+// and option.COption[T] instead of pointers (see GeneratorOptions.GenericOptions).
+// It's set by Generate.
+var genericOptions bool
+
+// isPointerOption tells whether ty is an option (or coption) represented as a pointer,
+// i.e. a value that is nil for None.
+func isPointerOption(ty idltype.IdlType) bool {
+	return !genericOptions && (IsOption(ty) || IsCOption(ty))
+}
+
+// genValueTypeName returns the Go type of a field, a param or an alias of type ty;
+// unlike genTypeName, it returns a pointer for pointer options.
+func genValueTypeName(ty idltype.IdlType) Code {
+	if isPointerOption(ty) {
+		return Op("*").Add(genTypeName(ty))
+	}
+	return genTypeName(ty)
+}
+
+// genOptionTypeName returns option.Option[T] or option.COption[T].
+func genOptionTypeName(ty idltype.IdlType) Code {
+	if IsCOption(ty) {
+		return Qual(PkgAnchorGoOption, "COption").Types(genTypeName(optionInnerType(ty)))
+	}
+	return Qual(PkgAnchorGoOption, "Option").Types(genTypeName(optionInnerType(ty)))
+}
+
+func optionInnerType(ty idltype.IdlType) idltype.IdlType {
+	switch vv := ty.(type) {
+	case *idltype.Option:
+		return vv.Option
+	case *idltype.COption:
+		return vv.COption
+	}
+	return nil
+}
+
+// gen_marshal_genericOption encodes a field of type option.Option[T] (or option.COption[T]).
+func gen_marshal_genericOption(
+	body *Group,
+	field idl.IdlField,
+	nameFormatter func(field idl.IdlField) *Statement,
+	encoderVariableName string,
+	returnNilErr bool,
+) {
+	optionalityWriterName := "WriteOption"
+	if IsCOption(field.Ty) {
+		optionalityWriterName = "WriteCOption"
+	}
+	returnErr := func(err Code) Code {
+		return ReturnFunc(func(returnBody *Group) {
+			if returnNilErr {
+				returnBody.Nil()
+			}
+			returnBody.Add(err)
+		})
+	}
+	inner := optionInnerType(field.Ty)
+	body.Block(
+		List(Id("value"), Id("ok")).Op(":=").Add(nameFormatter(field)).Dot("Get").Call(),
+		Err().Op("=").Id(encoderVariableName).Dot(optionalityWriterName).Call(Id("ok")),
+		If(Err().Op("!=").Nil()).Block(
+			returnErr(Qual(PkgAnchorGoErrors, "NewOption").Call(
+				Lit(field.Name),
+				Qual("fmt", "Errorf").Call(
+					Lit("error while encoding optionality: %w"),
+					Err(),
+				),
+			)),
+		),
+		If(Id("ok")).BlockFunc(func(someBody *Group) {
+			gen_encodeValue(someBody, encoderVariableName, Id("value"), inner, 1, func(err Code) Code {
+				return returnErr(Qual(PkgAnchorGoErrors, "NewField").Call(Lit(field.Name), err))
+			})
+		}),
+	)
+}
+
+// gen_unmarshal_genericOption decodes a field of type option.Option[T] (or option.COption[T]).
+func gen_unmarshal_genericOption(
+	body *Group,
+	field idl.IdlField,
+	nameFormatter func(field idl.IdlField) *Statement,
+) {
+	optionalityReaderName, someName, noneName := "ReadOption", "Some", "None"
+	if IsCOption(field.Ty) {
+		optionalityReaderName, someName, noneName = "ReadCOption", "SomeC", "NoneC"
+	}
+	inner := optionInnerType(field.Ty)
+	body.Block(
+		List(Id("ok"), Err()).Op(":=").Id("decoder").Dot(optionalityReaderName).Call(),
+		If(Err().Op("!=").Nil()).Block(
+			Return(
+				Qual(PkgAnchorGoErrors, "NewOption").Call(
+					Lit(field.Name),
+					withDecoderOffset(
+						Qual("fmt", "Errorf").Call(
+							Lit("error while reading optionality: %w"),
+							Err(),
+						),
+					),
+				),
+			),
+		),
+		If(Id("ok")).BlockFunc(func(someBody *Group) {
+			someBody.Var().Id("value").Add(genTypeName(inner))
+			// Nested options are declared in a block of their own, with names of the next depth.
+			gen_decodeValue(someBody, Id("value"), inner, 1, func(err Code) Code {
+				return Return(Qual(PkgAnchorGoErrors, "NewOption").Call(Lit(field.Name), err))
+			})
+			someBody.Add(nameFormatter(field)).Op("=").Qual(PkgAnchorGoOption, someName).Call(Id("value"))
+		}).Else().Block(
+			nameFormatter(field).Op("=").Qual(PkgAnchorGoOption, noneName).Types(genTypeName(inner)).Call(),
+		),
+	)
+}
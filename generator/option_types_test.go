@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"testing"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenGenericOptions(t *testing.T) {
+	genericOptions = true
+	defer func() { genericOptions = false }()
+
+	typ := idl.IdlTypeDef{
+		Name: "OptionTestHolder",
+		Ty: &idl.IdlTypeDefTyStruct{
+			Kind: "struct",
+			Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "maybe_key", Ty: &idltype.Option{Option: &idltype.Pubkey{}}},
+				{Name: "maybe_amount", Ty: &idltype.COption{COption: &idltype.U64{}}},
+				{Name: "list", Ty: &idltype.Vec{Vec: &idltype.Option{Option: &idltype.U8{}}}},
+			},
+		},
+	}
+	gen := &Generator{
+		idl:     &idl.Idl{Types: []idl.IdlTypeDef{typ}},
+		options: &GeneratorOptions{Package: "test", GenericOptions: true},
+	}
+	code, err := gen.gen_IDLTypeDef(typ)
+	require.NoError(t, err)
+	file := NewFile("test")
+	file.Add(code)
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		"MaybeKey    option.Option[solanago.PublicKey] `json:\"maybe_key\"`",
+		"MaybeAmount option.COption[uint64]            `json:\"maybe_amount\"`",
+		"List        []option.Option[uint8]            `json:\"list\"`",
+		"value, ok := obj.MaybeKey.Get()\n\t\terr = encoder.WriteOption(ok)",
+		"err = encoder.WriteCOption(ok)",
+		"ok, err := decoder.ReadCOption()",
+		"obj.MaybeKey = option.Some(value)",
+		"obj.MaybeAmount = option.NoneC[uint64]()",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+	assert.NotContains(t, generatedCode, "bin:\"optional\"")
+	assert.NotContains(t, generatedCode, "*solanago.PublicKey")
+}
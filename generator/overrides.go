@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+)
+
+// Renames overrides the names of the IDL in the generated code,
+// e.g. to avoid clashes between the generated identifiers.
+type Renames struct {
+	// Types maps IDL names of defined types (including the layouts of the
+	// accounts and events) to the names to generate them with.
+	Types map[string]string
+	// Instructions maps IDL names of instructions to the names to generate them with.
+	Instructions map[string]string
+}
+
+// Filter selects the instructions, accounts and events to generate;
+// the zero value selects all of them. The types are always generated.
+type Filter struct {
+	Instructions NameFilter
+	Accounts     NameFilter
+	Events       NameFilter
+}
+
+// NameFilter selects names: the names of Include (or all the names if Include is empty),
+// except the names of Exclude.
+type NameFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func (f NameFilter) selects(name string) bool {
+	return (len(f.Include) == 0 || slices.Contains(f.Include, name)) && !slices.Contains(f.Exclude, name)
+}
+
+// check fails if the filter names something that isn't in names.
+func (f NameFilter) check(kind string, names []string) error {
+	for _, name := range append(slices.Clone(f.Include), f.Exclude...) {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("filter: %s %q is not in the IDL", kind, name)
+		}
+	}
+	return nil
+}
+
+// applyOverrides filters and renames the IDL (and its previous versions) as the options say.
+func (g *Generator) applyOverrides() error {
+	if err := applyFilter(g.idl, g.options.Filter); err != nil {
+		return err
+	}
+	if err := applyRenames(g.idl, g.options.Renames, true); err != nil {
+		return err
+	}
+	for _, previous := range g.options.PreviousIdls {
+		// The previous versions don't necessarily have all the names.
+		if err := applyRenames(previous, g.options.Renames, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyFilter(idlObj *idl.Idl, filter Filter) error {
+	var instructionNames, accountNames, eventNames []string
+	for _, ix := range idlObj.Instructions {
+		instructionNames = append(instructionNames, ix.Name)
+	}
+	for _, account := range idlObj.Accounts {
+		accountNames = append(accountNames, account.Name)
+	}
+	for _, event := range idlObj.Events {
+		eventNames = append(eventNames, event.Name)
+	}
+	if err := filter.Instructions.check("instruction", instructionNames); err != nil {
+		return err
+	}
+	if err := filter.Accounts.check("account", accountNames); err != nil {
+		return err
+	}
+	if err := filter.Events.check("event", eventNames); err != nil {
+		return err
+	}
+	idlObj.Instructions = slices.DeleteFunc(idlObj.Instructions, func(ix idl.IdlInstruction) bool {
+		return !filter.Instructions.selects(ix.Name)
+	})
+	idlObj.Accounts = slices.DeleteFunc(idlObj.Accounts, func(account idl.IdlAccount) bool {
+		return !filter.Accounts.selects(account.Name)
+	})
+	idlObj.Events = slices.DeleteFunc(idlObj.Events, func(event idl.IdlEvent) bool {
+		return !filter.Events.selects(event.Name)
+	})
+	return nil
+}
+
+// applyRenames renames the types and instructions of the IDL; if strict,
+// it fails if a renamed name isn't in the IDL.
+func applyRenames(idlObj *idl.Idl, renames Renames, strict bool) error {
+	if len(renames.Types) > 0 {
+		typeNames := make(map[string]bool)
+		for _, def := range idlObj.Types {
+			typeNames[def.Name] = true
+		}
+		for from, to := range renames.Types {
+			if to == "" {
+				return fmt.Errorf("rename: empty new name for type %q", from)
+			}
+			if !typeNames[from] {
+				if strict {
+					return fmt.Errorf("rename: type %q is not defined in the IDL", from)
+				}
+				continue
+			}
+			if typeNames[to] && renames.Types[to] == "" {
+				return fmt.Errorf("rename: cannot rename type %q to %q, which is already defined in the IDL", from, to)
+			}
+		}
+		rename := func(name string) string {
+			if to, ok := renames.Types[name]; ok {
+				return to
+			}
+			return name
+		}
+		for i := range idlObj.Types {
+			idlObj.Types[i].Name = rename(idlObj.Types[i].Name)
+		}
+		for i := range idlObj.Accounts {
+			idlObj.Accounts[i].Name = rename(idlObj.Accounts[i].Name)
+		}
+		for i := range idlObj.Events {
+			idlObj.Events[i].Name = rename(idlObj.Events[i].Name)
+		}
+		mapIdlTypes(idlObj, func(ty idltype.IdlType) idltype.IdlType {
+			if defined, ok := ty.(*idltype.Defined); ok {
+				return &idltype.Defined{Name: rename(defined.Name), Generics: defined.Generics}
+			}
+			return ty
+		})
+	}
+	if len(renames.Instructions) > 0 {
+		instructionNames := make(map[string]bool)
+		for _, ix := range idlObj.Instructions {
+			instructionNames[ix.Name] = true
+		}
+		for from, to := range renames.Instructions {
+			if to == "" {
+				return fmt.Errorf("rename: empty new name for instruction %q", from)
+			}
+			if !instructionNames[from] {
+				if strict {
+					return fmt.Errorf("rename: instruction %q is not in the IDL", from)
+				}
+				continue
+			}
+			if instructionNames[to] && renames.Instructions[to] == "" {
+				return fmt.Errorf("rename: cannot rename instruction %q to %q, which is already in the IDL", from, to)
+			}
+		}
+		for i, ix := range idlObj.Instructions {
+			if to, ok := renames.Instructions[ix.Name]; ok {
+				idlObj.Instructions[i].Name = to
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOverridesTestIdl() *idl.Idl {
+	return &idl.Idl{
+		Instructions: []idl.IdlInstruction{
+			{Name: "deposit", Discriminator: idl.IdlDiscriminator{1, 1, 1, 1, 1, 1, 1, 1}, Args: []idl.IdlField{
+				{Name: "vault", Ty: &idltype.Option{Option: &idltype.Defined{Name: "Vault"}}},
+			}},
+			{Name: "admin_withdraw", Discriminator: idl.IdlDiscriminator{2, 2, 2, 2, 2, 2, 2, 2}},
+		},
+		Accounts: []idl.IdlAccount{
+			{Name: "Vault", Discriminator: idl.IdlDiscriminator{3, 3, 3, 3, 3, 3, 3, 3}},
+			{Name: "Config", Discriminator: idl.IdlDiscriminator{4, 4, 4, 4, 4, 4, 4, 4}},
+		},
+		Events: []idl.IdlEvent{
+			{Name: "Deposited", Discriminator: idl.IdlDiscriminator{5, 5, 5, 5, 5, 5, 5, 5}},
+		},
+		Types: idl.IdTypeDef_slice{
+			{Name: "Vault", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "amount", Ty: &idltype.U64{}},
+			}}},
+			{Name: "Config", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "vaults", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "Vault"}}},
+			}}},
+			{Name: "Deposited", Ty: &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{
+				{Name: "vault", Ty: &idltype.Defined{Name: "Vault"}},
+			}}},
+		},
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	t.Run("Renames", func(t *testing.T) {
+		idlData := newOverridesTestIdl()
+		previous := newOverridesTestIdl()
+		previous.Types = previous.Types[:1]
+		gen := &Generator{
+			idl: idlData,
+			options: &GeneratorOptions{
+				PreviousIdls: []*idl.Idl{previous},
+				Renames: Renames{
+					Types:        map[string]string{"Vault": "Treasury"},
+					Instructions: map[string]string{"admin_withdraw": "withdraw"},
+				},
+			},
+		}
+		require.NoError(t, gen.applyOverrides())
+
+		assert.Equal(t, "Treasury", idlData.Types[0].Name)
+		assert.Equal(t, "Treasury", idlData.Accounts[0].Name)
+		assert.Equal(t, &idltype.Vec{Vec: &idltype.Defined{Name: "Treasury"}}, idlData.Types[1].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)[0].Ty)
+		assert.Equal(t, &idltype.Defined{Name: "Treasury"}, idlData.Types[2].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)[0].Ty)
+		assert.Equal(t, &idltype.Option{Option: &idltype.Defined{Name: "Treasury"}}, idlData.Instructions[0].Args[0].Ty)
+		assert.Equal(t, "withdraw", idlData.Instructions[1].Name)
+		assert.Equal(t, idl.IdlDiscriminator{2, 2, 2, 2, 2, 2, 2, 2}, idlData.Instructions[1].Discriminator)
+		// The previous versions are renamed alike.
+		assert.Equal(t, "Treasury", previous.Types[0].Name)
+		assert.Equal(t, "Treasury", previous.Accounts[0].Name)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		idlData := newOverridesTestIdl()
+		gen := &Generator{
+			idl: idlData,
+			options: &GeneratorOptions{
+				Filter: Filter{
+					Instructions: NameFilter{Exclude: []string{"admin_withdraw"}},
+					Accounts:     NameFilter{Include: []string{"Vault"}},
+					Events:       NameFilter{Include: []string{"Deposited"}, Exclude: []string{"Deposited"}},
+				},
+			},
+		}
+		require.NoError(t, gen.applyOverrides())
+		require.Len(t, idlData.Instructions, 1)
+		assert.Equal(t, "deposit", idlData.Instructions[0].Name)
+		require.Len(t, idlData.Accounts, 1)
+		assert.Equal(t, "Vault", idlData.Accounts[0].Name)
+		assert.Empty(t, idlData.Events)
+		// The types are kept.
+		assert.Len(t, idlData.Types, 3)
+	})
+
+	for name, options := range map[string]GeneratorOptions{
+		"Unknown type":        {Renames: Renames{Types: map[string]string{"Nope": "Other"}}},
+		"Type clash":          {Renames: Renames{Types: map[string]string{"Vault": "Config"}}},
+		"Empty name":          {Renames: Renames{Instructions: map[string]string{"deposit": ""}}},
+		"Unknown instruction": {Renames: Renames{Instructions: map[string]string{"nope": "other"}}},
+		"Unknown account":     {Filter: Filter{Accounts: NameFilter{Exclude: []string{"Nope"}}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			gen := &Generator{idl: newOverridesTestIdl(), options: &options}
+			require.Error(t, gen.applyOverrides())
+		})
+	}
+
+	t.Run("Swap", func(t *testing.T) {
+		idlData := newOverridesTestIdl()
+		gen := &Generator{idl: idlData, options: &GeneratorOptions{
+			Renames: Renames{Types: map[string]string{"Vault": "Config", "Config": "Vault"}},
+		}}
+		require.NoError(t, gen.applyOverrides())
+		assert.Equal(t, "Config", idlData.Types[0].Name)
+		assert.Equal(t, "Vault", idlData.Types[1].Name)
+		assert.Equal(t, &idltype.Vec{Vec: &idltype.Defined{Name: "Config"}}, idlData.Types[1].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)[0].Ty)
+	})
+}
@@ -1,17 +1,284 @@
 package generator
 
 import (
+	"fmt"
+
 	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
 )
 
+// gen_tests generates round-trip tests for the codecs of the generated types and instructions:
+// each test fills values with random data (gofuzz), encodes them, decodes the result
+// and checks that it's equal to the original value.
 func (g *Generator) gen_tests() (*OutputFile, error) {
 	file := NewFile(g.options.Package)
 	file.HeaderComment("Code generated by https://github.com/gagliardetto/anchor-go. DO NOT EDIT.")
 	file.HeaderComment("This file contains tests.")
 	{
+		file.Commentf("fuzzIterations is the number of random values checked by each round-trip test.")
+		file.Const().Id("fuzzIterations").Op("=").Lit(100)
+		file.Line()
+
+		file.Add(g.gen_newFuzzer())
+		file.Line()
+
+		file.Commentf("encodeDecode encodes value, and decodes the result into got.")
+		file.Func().Id("encodeDecode").
+			Params(
+				Id("t").Op("*").Qual("testing", "T"),
+				Id("value").Qual(PkgBinary, "BinaryMarshaler"),
+				Id("got").Qual(PkgBinary, "BinaryUnmarshaler"),
+			).
+			BlockFunc(func(body *Group) {
+				body.Id("buf").Op(":=").New(Qual("bytes", "Buffer"))
+				body.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Id("value").Dot("MarshalWithEncoder").Call(Qual(PkgBinary, "NewBorshEncoder").Call(Id("buf"))))
+				body.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Id("got").Dot("UnmarshalWithDecoder").Call(Qual(PkgBinary, "NewBorshDecoder").Call(Id("buf").Dot("Bytes").Call())))
+			})
+		file.Line()
+
+		for _, def := range g.idl.Types {
+			code := gen_typeRoundTripTest(def)
+			if code != nil {
+				file.Add(code)
+				file.Line()
+			}
+		}
+		for _, instruction := range g.idl.Instructions {
+			file.Add(gen_instructionRoundTripTest(instruction))
+			file.Line()
+		}
 	}
 	return &OutputFile{
 		Name: "tests_test.go",
 		File: file,
 	}, nil
 }
+
+// gen_newFuzzer generates the newFuzzer function, that returns a fuzzer which
+// fills simple enums with valid values, complex enums with random variants
+// and (with generic options) options with random Some/None values.
+func (g *Generator) gen_newFuzzer() Code {
+	code := Empty()
+	code.Commentf("newFuzzer returns a fuzzer that generates valid values for the types of the program.")
+	code.Line().Func().Id("newFuzzer").Params().Op("*").Qual(PkgGoFuzz, "Fuzzer").
+		BlockFunc(func(body *Group) {
+			body.Return(
+				Qual(PkgGoFuzz, "New").Call().
+					Dot("NilChance").Call(Lit(0.2)).
+					Dot("NumElements").Call(Lit(0), Lit(4)).
+					Dot("Funcs").CallFunc(func(funcs *Group) {
+					for _, def := range g.idl.Types {
+						enum, ok := def.Ty.(*idl.IdlTypeDefTyEnum)
+						if !ok {
+							continue
+						}
+						enumTypeName := tools.ToCamelUpper(def.Name)
+						if enum.IsAllSimple() {
+							funcs.Line().Func().Params(Id("value").Op("*").Id(enumTypeName), Id("c").Qual(PkgGoFuzz, "Continue")).Block(
+								Op("*").Id("value").Op("=").Id(enumTypeName).Call(Id("c").Dot("Intn").Call(Lit(len(enum.Variants)))),
+							)
+							continue
+						}
+						funcs.Line().Func().Params(Id("value").Op("*").Id(enumTypeName), Id("c").Qual(PkgGoFuzz, "Continue")).Block(
+							Switch(Id("c").Dot("Intn").Call(Lit(len(enum.Variants)))).BlockFunc(func(switchGroup *Group) {
+								for variantIndex, variant := range enum.Variants {
+									variantTypeName := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
+									if variant.IsSimple() {
+										switchGroup.Case(Lit(variantIndex)).Line().Op("*").Id("value").Op("=").New(Id(variantTypeName))
+										continue
+									}
+									switchGroup.Case(Lit(variantIndex)).Block(
+										Id("variant").Op(":=").New(Id(variantTypeName)),
+										Id("c").Dot("Fuzz").Call(Id("variant")),
+										Op("*").Id("value").Op("=").Id("variant"),
+									)
+								}
+							}),
+						)
+					}
+					// gofuzz leaves some arrays zeroed (like nil pointers), but
+					// a zero complex enum (a nil interface) can't be encoded.
+					for _, ty := range collectTypes(g.idl, IsArray) {
+						funcs.Line().Func().Params(Id("value").Op("*").Add(genTypeName(ty)), Id("c").Qual(PkgGoFuzz, "Continue")).Block(
+							For(Id("i").Op(":=").Range().Id("value")).Block(
+								Id("c").Dot("Fuzz").Call(Op("&").Id("value").Index(Id("i"))),
+							),
+						)
+					}
+					if genericOptions {
+						for _, ty := range collectTypes(g.idl, func(ty idltype.IdlType) bool { return IsOption(ty) || IsCOption(ty) }) {
+							someName, noneName := "Some", "None"
+							if IsCOption(ty) {
+								someName, noneName = "SomeC", "NoneC"
+							}
+							inner := genTypeName(optionInnerType(ty))
+							funcs.Line().Func().Params(Id("value").Op("*").Add(genOptionTypeName(ty)), Id("c").Qual(PkgGoFuzz, "Continue")).Block(
+								If(Id("c").Dot("RandBool").Call()).Block(
+									Op("*").Id("value").Op("=").Qual(PkgAnchorGoOption, noneName).Types(inner).Call(),
+									Return(),
+								),
+								Var().Id("inner").Add(inner),
+								Id("c").Dot("Fuzz").Call(Op("&").Id("inner")),
+								Op("*").Id("value").Op("=").Qual(PkgAnchorGoOption, someName).Call(Id("inner")),
+							)
+						}
+					}
+					funcs.Line()
+				}),
+			)
+		})
+	return code
+}
+
+// collectTypes returns the distinct types that match the given predicate among the types
+// used by the type definitions and instructions of the IDL (including the nested ones).
+func collectTypes(idlObj *idl.Idl, match func(ty idltype.IdlType) bool) []idltype.IdlType {
+	var out []idltype.IdlType
+	seen := make(map[string]bool)
+	collect := func(ty idltype.IdlType) {
+		mapIdlType(ty, func(ty idltype.IdlType) idltype.IdlType {
+			if match(ty) {
+				key := fmt.Sprintf("%#v", genTypeName(ty))
+				if !seen[key] {
+					seen[key] = true
+					out = append(out, ty)
+				}
+			}
+			return ty
+		})
+	}
+	collectFields := func(fields idl.IdlDefinedFields) {
+		switch fields := fields.(type) {
+		case idl.IdlDefinedFieldsNamed:
+			for _, field := range fields {
+				collect(field.Ty)
+			}
+		case idl.IdlDefinedFieldsTuple:
+			for _, ty := range fields {
+				collect(ty)
+			}
+		}
+	}
+	for _, def := range idlObj.Types {
+		switch ty := def.Ty.(type) {
+		case *idl.IdlTypeDefTyStruct:
+			collectFields(ty.Fields)
+		case *idl.IdlTypeDefTyEnum:
+			for _, variant := range ty.Variants {
+				if variant.Fields.IsSome() {
+					collectFields(variant.Fields.Unwrap())
+				}
+			}
+		case *idl.IdlTypeDefTyType:
+			collect(ty.Alias)
+		}
+	}
+	for _, instruction := range idlObj.Instructions {
+		for _, arg := range instruction.Args {
+			collect(arg.Ty)
+		}
+	}
+	return out
+}
+
+// gen_typeRoundTripTest generates the round-trip test of a type;
+// it returns nil for the aliases that are inlined.
+func gen_typeRoundTripTest(def idl.IdlTypeDef) Code {
+	typeName := tools.ToCamelUpper(def.Name)
+	var check func(body *Group)
+	switch ty := def.Ty.(type) {
+	case *idl.IdlTypeDefTyStruct:
+		check = func(body *Group) {
+			body.List(Id("data"), Err()).Op(":=").Id("value").Dot("Marshal").Call()
+			body.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Err())
+			body.List(Id("got"), Err()).Op(":=").Id("Unmarshal" + typeName).Call(Id("data"))
+			body.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Err())
+			body.Qual(PkgTestifyRequire, "True").Call(Id("t"), Id("value").Dot("Equal").Call(Op("*").Id("got")), Lit("%#v != %#v"), Id("value"), Op("*").Id("got"))
+		}
+	case *idl.IdlTypeDefTyEnum:
+		if ty.IsAllSimple() {
+			check = func(body *Group) {
+				body.Var().Id("got").Id(typeName)
+				body.Id("encodeDecode").Call(Id("t"), Id("value"), Op("&").Id("got"))
+				body.Qual(PkgTestifyRequire, "Equal").Call(Id("t"), Id("value"), Id("got"))
+			}
+			break
+		}
+		check = func(body *Group) {
+			body.Id("buf").Op(":=").New(Qual("bytes", "Buffer"))
+			body.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Id(formatEnumEncoderName(typeName)).Call(Qual(PkgBinary, "NewBorshEncoder").Call(Id("buf")), Id("value")))
+			body.List(Id("got"), Err()).Op(":=").Id(formatEnumParserName(typeName)).Call(Qual(PkgBinary, "NewBorshDecoder").Call(Id("buf").Dot("Bytes").Call()))
+			body.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Err())
+			body.Qual(PkgTestifyRequire, "True").Call(Id("t"), Id(formatEnumEqualName(typeName)).Call(Id("value"), Id("got")), Lit("%#v != %#v"), Id("value"), Id("got"))
+		}
+	case *idl.IdlTypeDefTyType:
+		if isInlinedAlias(ty.Alias) {
+			return nil
+		}
+		check = func(body *Group) {
+			body.Var().Id("got").Id(typeName)
+			body.Id("encodeDecode").Call(Id("t"), Id("value"), Op("&").Id("got"))
+			body.Qual(PkgTestifyRequire, "True").Call(Id("t"), Id("value").Dot("Equal").Call(Id("got")), Lit("%#v != %#v"), Id("value"), Id("got"))
+		}
+	default:
+		return nil
+	}
+
+	code := Empty()
+	code.Func().Id("Test" + typeName + "RoundTrip").Params(Id("t").Op("*").Qual("testing", "T")).
+		BlockFunc(func(body *Group) {
+			body.Id("fuzzer").Op(":=").Id("newFuzzer").Call()
+			body.For(Id("i").Op(":=").Lit(0), Id("i").Op("<").Id("fuzzIterations"), Id("i").Op("++")).BlockFunc(func(forBody *Group) {
+				forBody.Var().Id("value").Id(typeName)
+				forBody.Id("fuzzer").Dot("Fuzz").Call(Op("&").Id("value"))
+				check(forBody)
+			})
+		})
+	return code
+}
+
+// gen_instructionRoundTripTest generates the round-trip test of an instruction:
+// the instruction is built from random args, and the args parsed back from its data
+// must be equal to the original ones.
+func gen_instructionRoundTripTest(instruction idl.IdlInstruction) Code {
+	typeName := formatInstructionTypeName(instruction.Name)
+	equalArgsName := "equal" + typeName + "Args"
+
+	code := Empty()
+	code.Commentf("%s tells whether a and b have the same args.", equalArgsName)
+	code.Line().Func().Id(equalArgsName).Params(Id("a"), Id("b").Op("*").Id(typeName)).Bool().
+		BlockFunc(func(body *Group) {
+			for _, arg := range instruction.Args {
+				gen_equal(body, Id("a").Dot(tools.ToCamelUpper(arg.Name)), Id("b").Dot(tools.ToCamelUpper(arg.Name)), arg.Ty, 0)
+			}
+			body.Return(True())
+		})
+
+	code.Line().Line().Func().Id("Test" + typeName + "RoundTrip").Params(Id("t").Op("*").Qual("testing", "T")).
+		BlockFunc(func(body *Group) {
+			body.Id("fuzzer").Op(":=").Id("newFuzzer").Call()
+			body.For(Id("i").Op(":=").Lit(0), Id("i").Op("<").Id("fuzzIterations"), Id("i").Op("++")).BlockFunc(func(forBody *Group) {
+				forBody.Id("params").Op(":=").New(Id(typeName))
+				forBody.Id("fuzzer").Dot("Fuzz").Call(Id("params"))
+				forBody.List(Id("instruction"), Err()).Op(":=").Id(newInstructionFuncName(instruction.Name)).CallFunc(func(args *Group) {
+					for _, arg := range instruction.Args {
+						args.Id("params").Dot(tools.ToCamelUpper(arg.Name))
+					}
+					for _, account := range instruction.Accounts {
+						if acc, ok := account.(*idl.IdlInstructionAccount); ok {
+							args.Id("params").Dot(tools.ToCamelUpper(acc.Name))
+						}
+					}
+				})
+				forBody.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Err())
+				forBody.List(Id("data"), Err()).Op(":=").Id("instruction").Dot("Data").Call()
+				forBody.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Err())
+				forBody.List(Id("got"), Err()).Op(":=").Id("Unmarshal" + typeName).Call(Id("data"))
+				forBody.Qual(PkgTestifyRequire, "NoError").Call(Id("t"), Err())
+				forBody.Qual(PkgTestifyRequire, "True").Call(Id("t"), Id(equalArgsName).Call(Id("params"), Id("got")), Lit("%#v != %#v"), Id("params"), Id("got"))
+			})
+		})
+	return code
+}
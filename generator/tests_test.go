@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenTests(t *testing.T) {
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "RtStatus",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind:     "enum",
+					Variants: idl.VariantSlice{{Name: "On"}, {Name: "Off"}},
+				},
+			},
+			{
+				Name: "RtShape",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind: "enum",
+					Variants: idl.VariantSlice{
+						{Name: "Empty"},
+						{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+					},
+				},
+			},
+			{
+				Name: "RtHolder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "shapes", Ty: &idltype.Array{Type: &idltype.Defined{Name: "RtShape"}, Size: &idltype.IdlArrayLenValue{Value: 2}}},
+					},
+				},
+			},
+			{
+				Name: "RtAmount",
+				Ty:   &idl.IdlTypeDefTyType{Kind: "type", Alias: &idltype.U64{}},
+			},
+		},
+		Instructions: []idl.IdlInstruction{
+			{
+				Name: "set_shape",
+				Args: []idl.IdlField{{Name: "shape", Ty: &idltype.Defined{Name: "RtShape"}}},
+				Accounts: []idl.IdlInstructionAccountItem{
+					&idl.IdlInstructionAccount{Name: "holder", Writable: true},
+				},
+			},
+		},
+	}
+	for _, typ := range idlData.Types {
+		registerComplexEnums(typ)
+	}
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file, err := gen.gen_tests()
+	require.NoError(t, err)
+	generatedCode := file.File.GoString()
+
+	for _, expected := range []string{
+		"func newFuzzer() *gofuzz.Fuzzer {",
+		"func(value *RtStatus, c gofuzz.Continue) {\n\t\t\t*value = RtStatus(c.Intn(2))",
+		"case 0:\n\t\t\t\t*value = new(RtShape_Empty)",
+		"variant := new(RtShape_Circle)\n\t\t\t\tc.Fuzz(variant)",
+		"func(value *[2]RtShape, c gofuzz.Continue) {",
+		"func TestRtHolderRoundTrip(t *testing.T) {",
+		"got, err := UnmarshalRtHolder(data)",
+		"require.True(t, value.Equal(*got)",
+		"func TestRtShapeRoundTrip(t *testing.T) {",
+		"require.NoError(t, EncodeRtShape(binary.NewBorshEncoder(buf), value))",
+		"func TestRtStatusRoundTrip(t *testing.T) {",
+		"func TestRtAmountRoundTrip(t *testing.T) {",
+		"func equalSetShapeInstructionArgs(a, b *SetShapeInstruction) bool {\n\tif !EqualRtShape(a.Shape, b.Shape) {",
+		"instruction, err := NewSetShapeInstruction(params.Shape, params.Holder)",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+}
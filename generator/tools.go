@@ -8,10 +8,15 @@ import (
 )
 
 const (
-	PkgBinary         = "github.com/gagliardetto/binary"
-	PkgSolanaGo       = "github.com/gagliardetto/solana-go"
-	PkgSolanaGoText   = "github.com/gagliardetto/solana-go/text"
-	PkgAnchorGoErrors = "github.com/gagliardetto/anchor-go/errors"
+	PkgBinary          = "github.com/gagliardetto/binary"
+	PkgSolanaGo        = "github.com/gagliardetto/solana-go"
+	PkgSolanaGoText    = "github.com/gagliardetto/solana-go/text"
+	PkgSolanaGoJsonRPC = "github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	PkgAnchorGoErrors  = "github.com/gagliardetto/anchor-go/errors"
+	PkgAnchorGoNumeric = "github.com/gagliardetto/anchor-go/numeric"
+	PkgAnchorGoOption  = "github.com/gagliardetto/anchor-go/option"
+	PkgAnchorGoLimits  = "github.com/gagliardetto/anchor-go/limits"
+	PkgAnchorGoDecode  = "github.com/gagliardetto/anchor-go/decode"
 	// TODO: use or remove this:
 	PkgTreeout        = "github.com/gagliardetto/treeout"
 	PkgFormat         = "github.com/gagliardetto/solana-go/text/format"
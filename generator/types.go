@@ -32,13 +32,15 @@ func (g *Generator) genfile_types() (*OutputFile, error) {
 }
 
 // `def.Type` is `IDLTypeDefTy` (which is an interface):
-// either `IDLTypeDefTyEnum` or `IDLTypeDefTyStruct`.
+// either `IDLTypeDefTyEnum`, `IDLTypeDefTyStruct` or `IDLTypeDefTyType`.
 func (g *Generator) gen_IDLTypeDef(def idl.IdlTypeDef) (Code, error) {
 	switch vv := def.Ty.(type) {
 	case *idl.IdlTypeDefTyStruct:
 		return g.gen_IDLTypeDefTyStruct(def.Name, def.Docs, *vv, false)
 	case *idl.IdlTypeDefTyEnum:
 		return g.gen_IDLTypeDefTyEnum(def.Name, def.Docs, *vv)
+	case *idl.IdlTypeDefTyType:
+		return g.gen_IDLTypeDefTyType(def.Name, def.Docs, *vv)
 	default:
 		panic(fmt.Errorf("unhandled type: %T", vv))
 	}
@@ -92,11 +94,130 @@ func (g *Generator) gen_simpleEnum(name string, docs []string, typ idl.IdlTypeDe
 					switchBlock.Default().Line().Return(Lit(""))
 				})
 			})
+		gen_simpleEnumHelpers(code, enumTypeName, typ)
 		st.Add(code.Line())
 	}
 	return st, nil
 }
 
+// gen_simpleEnumHelpers generates the validation, parsing and (text, JSON and binary)
+// encoding methods of a simple enum; the text and JSON encodings use the variant names.
+func gen_simpleEnumHelpers(code *Statement, enumTypeName string, typ idl.IdlTypeDefTyEnum) {
+	variantNames := make([]Code, len(typ.Variants))
+	for i, variant := range typ.Variants {
+		variantNames[i] = Id(formatSimpleEnumVariantName(variant.Name, enumTypeName))
+	}
+
+	// IsValid:
+	code.Line().Line().Commentf("IsValid tells whether the value is one of the variants of %s.", enumTypeName)
+	code.Line().Func().Params(Id("value").Id(enumTypeName)).Id("IsValid").
+		Params().
+		Params(Bool()).
+		BlockFunc(func(body *Group) {
+			body.Switch(Id("value")).Block(
+				Case(variantNames...).Line().Return(True()),
+			)
+			body.Return(False())
+		})
+
+	// XValues:
+	code.Line().Line().Commentf("%sValues returns all the variants of %s, in order.", enumTypeName, enumTypeName)
+	code.Line().Func().Id(enumTypeName + "Values").
+		Params().
+		Params(Index().Id(enumTypeName)).
+		Block(
+			Return(Index().Id(enumTypeName).ValuesFunc(func(group *Group) {
+				for _, name := range variantNames {
+					group.Line().Add(name)
+				}
+				group.Line()
+			})),
+		)
+
+	// ParseX:
+	code.Line().Line().Commentf("Parse%s returns the variant of %s with the given name.", enumTypeName, enumTypeName)
+	code.Line().Func().Id("Parse"+enumTypeName).
+		Params(Id("name").String()).
+		Params(Id(enumTypeName), Error()).
+		BlockFunc(func(body *Group) {
+			body.Switch(Id("name")).BlockFunc(func(switchBlock *Group) {
+				for _, variant := range typ.Variants {
+					switchBlock.Case(Lit(variant.Name)).Line().Return(Id(formatSimpleEnumVariantName(variant.Name, enumTypeName)), Nil())
+				}
+				switchBlock.Default().Line().Return(Lit(0), Qual("fmt", "Errorf").Call(Lit("invalid "+enumTypeName+": %q"), Id("name")))
+			})
+		})
+
+	// MarshalText/UnmarshalText:
+	code.Line().Line().Func().Params(Id("value").Id(enumTypeName)).Id("MarshalText").
+		Params().
+		Params(Index().Byte(), Error()).
+		Block(
+			If(Op("!").Id("value").Dot("IsValid").Call()).Block(
+				Return(Nil(), Qual("fmt", "Errorf").Call(Lit("invalid "+enumTypeName+": %d"), Id("value"))),
+			),
+			Return(Index().Byte().Parens(Id("value").Dot("String").Call()), Nil()),
+		)
+	code.Line().Line().Func().Params(Id("value").Op("*").Id(enumTypeName)).Id("UnmarshalText").
+		Params(Id("text").Index().Byte()).
+		Params(Error()).
+		Block(
+			List(Id("parsed"), Err()).Op(":=").Id("Parse"+enumTypeName).Call(String().Parens(Id("text"))),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			),
+			Op("*").Id("value").Op("=").Id("parsed"),
+			Return(Nil()),
+		)
+
+	// MarshalJSON/UnmarshalJSON:
+	code.Line().Line().Func().Params(Id("value").Id(enumTypeName)).Id("MarshalJSON").
+		Params().
+		Params(Index().Byte(), Error()).
+		Block(
+			List(Id("text"), Err()).Op(":=").Id("value").Dot("MarshalText").Call(),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Nil(), Err()),
+			),
+			Return(Qual("encoding/json", "Marshal").Call(String().Parens(Id("text")))),
+		)
+	code.Line().Line().Func().Params(Id("value").Op("*").Id(enumTypeName)).Id("UnmarshalJSON").
+		Params(Id("data").Index().Byte()).
+		Params(Error()).
+		Block(
+			Var().Id("name").String(),
+			If(
+				Err().Op(":=").Qual("encoding/json", "Unmarshal").Call(Id("data"), Op("&").Id("name")),
+				Err().Op("!=").Nil(),
+			).Block(
+				Return(Qual("fmt", "Errorf").Call(Lit("invalid "+enumTypeName+": %w"), Err())),
+			),
+			Return(Id("value").Dot("UnmarshalText").Call(Index().Byte().Parens(Id("name")))),
+		)
+
+	// MarshalWithEncoder/UnmarshalWithDecoder (rejecting unknown discriminants):
+	code.Line().Line().Func().Params(Id("value").Id(enumTypeName)).Id("MarshalWithEncoder").
+		Params(Id("encoder").Op("*").Qual(PkgBinary, "Encoder")).
+		Params(Error()).
+		Block(
+			Return(Id("encoder").Dot("WriteUint8").Call(Uint8().Parens(Id("value")))),
+		)
+	code.Line().Line().Func().Params(Id("value").Op("*").Id(enumTypeName)).Id("UnmarshalWithDecoder").
+		Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).
+		Params(Error()).
+		Block(
+			List(Id("discriminant"), Err()).Op(":=").Id("decoder").Dot("ReadUint8").Call(),
+			If(Err().Op("!=").Nil()).Block(
+				Return(Err()),
+			),
+			If(Op("!").Id(enumTypeName).Parens(Id("discriminant")).Dot("IsValid").Call()).Block(
+				Return(Qual("fmt", "Errorf").Call(Lit("invalid "+enumTypeName+" discriminant: %d"), Id("discriminant"))),
+			),
+			Op("*").Id("value").Op("=").Id(enumTypeName).Parens(Id("discriminant")),
+			Return(Nil()),
+		)
+}
+
 func addComments(code *Statement, docs []string) {
 	for _, doc := range docs {
 		code.Line()
@@ -117,34 +238,38 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 	addComments(code, docs)
 	{
 		register_TypeName_as_ComplexEnum(name)
-		containerName := formatEnumContainerName(enumTypeName)
+		kindName, err := g.enumKindName(enumTypeName)
+		if err != nil {
+			return nil, err
+		}
 		interfaceMethodName := formatInterfaceMethodName(enumTypeName)
 
 		// Declare the interface of the enum type:
 		code.Commentf("The %q interface for the %q complex enum.", interfaceMethodName, enumTypeName).Line()
-		code.Type().Id(enumTypeName).Interface(
-			Id(interfaceMethodName).Call(),
-		).Line().Line()
-
-		// Declare the enum variants container (non-exported, used internally)
-		code.Type().Id(containerName).StructFunc(
-			func(structGroup *Group) {
-				structGroup.Id("Enum").Qual(PkgBinary, "BorshEnum").Tag(map[string]string{
-					"bin": "enum",
-				})
-
-				for _, variant := range typ.Variants {
-					structGroup.Id(tools.ToCamelUpper(variant.Name)).Id(formatComplexEnumVariantTypeName(enumTypeName, variant.Name))
-				}
-			},
-		).Line().Line()
+		code.Type().Id(enumTypeName).InterfaceFunc(func(group *Group) {
+			group.Id(interfaceMethodName).Call()
+			group.Id(formatEnumKindMethodName(typ)).Call().Id(kindName)
+			if hasEnumStringMethod(typ) {
+				group.Id("String").Call().String()
+			}
+		}).Line().Line()
 
-		// Declare parser function for the enum type:
-		code.Func().Id(formatEnumParserName(enumTypeName)).Params(
-			ListFunc(func(params *Group) {
-				// Parameters:
-				params.Id("decoder").Op("*").Qual(PkgBinary, "Decoder")
-			}),
+		// Declare the kind, the constructors and the visitor of the enum type:
+		apiCode, err := g.gen_complexEnumAPI(enumTypeName, kindName, typ)
+		if err != nil {
+			return nil, err
+		}
+		code.Add(apiCode).Line()
+
+		// Declare parser functions for the enum type, without and with limits:
+		code.Func().Id(formatEnumParserName(enumTypeName)).
+			Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).
+			Params(Id(enumTypeName), Error()).
+			Block(
+				Return(Id(formatEnumLimitsParserName(enumTypeName)).Call(Id("decoder"), Nil())),
+			).Line().Line()
+		code.Func().Id(formatEnumLimitsParserName(enumTypeName)).Params(
+			ListFunc(decoderParams),
 		).Params(
 			ListFunc(func(results *Group) {
 				// Results:
@@ -153,58 +278,49 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 			}),
 		).
 			BlockFunc(func(body *Group) {
-				enumName := enumTypeName
-				body.BlockFunc(func(argBody *Group) {
-					argBody.List(Id("tmp")).Op(":=").New(Id(formatEnumContainerName(enumName)))
-
-					argBody.Err().Op(":=").Id("decoder").Dot("Decode").Call(Id("tmp"))
-
-					argBody.If(
-						Err().Op("!=").Nil(),
-					).Block(
-						Return(
-							Nil(),
-							Qual("fmt", "Errorf").Call(Lit("failed parsing "+enumTypeName+": %w"), Err()),
-						),
-					)
-
-					argBody.Switch(Id("tmp").Dot("Enum")).
-						BlockFunc(func(switchGroup *Group) {
-							interfaceType := g.idl.Types.ByName(enumName)
-
-							for variantIndex, variant := range interfaceType.Ty.(*idl.IdlTypeDefTyEnum).Variants {
-								variantTypeNameComplex := formatComplexEnumVariantTypeName(enumName, variant.Name)
-
-								if variant.IsSimple() {
-									// TODO: the actual value is not important;
-									//  what's important is the type.
-									switchGroup.Case(Lit(variantIndex)).
-										BlockFunc(func(caseGroup *Group) {
-											caseGroup.Return(
-												Parens(Op("*").Id(variantTypeNameComplex)).
-													Parens(Op("&").Id("tmp").Dot("Enum")),
-												Nil(),
-											)
-										})
-								} else {
-									switchGroup.Case(Lit(variantIndex)).
-										BlockFunc(func(caseGroup *Group) {
-											caseGroup.Return(
-												Op("&").Id("tmp").Dot(tools.ToCamelUpper(variant.Name)),
-												Nil(),
-											)
-										})
-								}
-							}
-							switchGroup.Default().
+				// Read the variant index, followed by the variant fields (if any):
+				body.List(Id("variantIndex"), Err()).Op(":=").Id("decoder").Dot("ReadUint8").Call()
+				body.If(Err().Op("!=").Nil()).Block(
+					Return(
+						Nil(),
+						Qual("fmt", "Errorf").Call(Lit("failed parsing "+enumTypeName+": %w"), Err()),
+					),
+				)
+				body.Switch(Id("variantIndex")).
+					BlockFunc(func(switchGroup *Group) {
+						for variantIndex, variant := range typ.Variants {
+							variantTypeNameComplex := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
+
+							switchGroup.Case(Lit(variantIndex)).
 								BlockFunc(func(caseGroup *Group) {
-									caseGroup.Return(
-										Nil(),
-										Qual("fmt", "Errorf").Call(Lit(enumTypeName+": unknown enum index: %v"), Id("tmp").Dot("Enum")),
+									if variant.IsSimple() {
+										// TODO: the actual value is not important;
+										//  what's important is the type.
+										caseGroup.Id("value").Op(":=").Id(variantTypeNameComplex).Call(Id("variantIndex"))
+										caseGroup.Return(Op("&").Id("value"), Nil())
+										return
+									}
+									caseGroup.Id("value").Op(":=").New(Id(variantTypeNameComplex))
+									caseGroup.If(
+										Err().Op(":=").Id("value").Dot("UnmarshalWithLimits").Call(Id("decoder"), Id("budget")),
+										Err().Op("!=").Nil(),
+									).Block(
+										Return(
+											Nil(),
+											Qual("fmt", "Errorf").Call(Lit("failed parsing "+enumTypeName+": %w"), Err()),
+										),
 									)
+									caseGroup.Return(Id("value"), Nil())
 								})
-						})
-				})
+						}
+						switchGroup.Default().
+							BlockFunc(func(caseGroup *Group) {
+								caseGroup.Return(
+									Nil(),
+									Qual("fmt", "Errorf").Call(Lit(enumTypeName+": unknown enum index: %v"), Id("variantIndex")),
+								)
+							})
+					})
 			}).Line().Line()
 
 		// Declare the marshaler for the enum type:
@@ -221,27 +337,35 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 			}),
 		).
 			BlockFunc(func(body *Group) {
-				body.BlockFunc(func(argBody *Group) {
-					argBody.List(Id("tmp")).Op(":=").Id(formatEnumContainerName(enumTypeName)).Block()
-					argBody.Switch(Id("realvalue").Op(":=").Id("value").Op(".").Parens(Type())).
-						BlockFunc(func(switchGroup *Group) {
-							// TODO: maybe it's from idl.Accounts ???
-							interfaceType := g.idl.Types.ByName(enumTypeName)
-							for variantIndex, variant := range interfaceType.Ty.(*idl.IdlTypeDefTyEnum).Variants {
-								variantTypeNameStruct := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
-
-								switchGroup.Case(Op("*").Id(variantTypeNameStruct)).
-									BlockFunc(func(caseGroup *Group) {
-										caseGroup.Id("tmp").Dot("Enum").Op("=").Lit(variantIndex)
-										caseGroup.Id("tmp").Dot(tools.ToCamelUpper(variant.Name)).Op("=").Op("*").Id("realvalue")
-									})
-							}
-						})
+				// Write the variant index, followed by the variant fields (if any):
+				body.Switch(Id("realvalue").Op(":=").Id("value").Op(".").Parens(Type())).
+					BlockFunc(func(switchGroup *Group) {
+						for variantIndex, variant := range typ.Variants {
+							variantTypeNameStruct := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
 
-					argBody.Return(Id("encoder").Dot("Encode").Call(Id("tmp")))
-				})
+							switchGroup.Case(Op("*").Id(variantTypeNameStruct)).
+								BlockFunc(func(caseGroup *Group) {
+									if variant.IsSimple() {
+										caseGroup.Return(Id("encoder").Dot("WriteUint8").Call(Lit(variantIndex)))
+										return
+									}
+									caseGroup.If(
+										Err().Op(":=").Id("encoder").Dot("WriteUint8").Call(Lit(variantIndex)),
+										Err().Op("!=").Nil(),
+									).Block(
+										Return(Err()),
+									)
+									caseGroup.Return(Id("realvalue").Dot("MarshalWithEncoder").Call(Id("encoder")))
+								})
+						}
+						switchGroup.Default().Line().Return(Qual("fmt", "Errorf").Call(Lit(enumTypeName+": unknown variant type %T"), Id("value")))
+					})
 			}).Line().Line()
 
+		// Declare the JSON marshaler and unmarshaler for the enum type:
+		code.Add(gen_complexEnumJSON(enumTypeName, typ.Variants))
+		code.Add(gen_EqualClone_complexEnum(enumTypeName, typ.Variants)).Line().Line()
+
 		for _, variant := range typ.Variants {
 			// Name of the variant type if the enum is a complex enum (i.e. enum variants are inline structs):
 			variantTypeNameComplex := formatComplexEnumVariantTypeName(enumTypeName, variant.Name)
@@ -257,14 +381,14 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 						switch fields := variant.Fields.Unwrap().(type) {
 						case idl.IdlDefinedFieldsNamed:
 							for _, variantField := range fields {
-								optionality := IsOption(variantField.Ty) || IsCOption(variantField.Ty)
+								optionality := isPointerOption(variantField.Ty)
 								structGroup.Add(genField(variantField, optionality)).
 									Add(func() Code {
 										tagMap := map[string]string{}
-										if IsOption(variantField.Ty) {
+										if optionality && IsOption(variantField.Ty) {
 											tagMap["bin"] = "optional"
 										}
-										if IsCOption(variantField.Ty) {
+										if optionality && IsCOption(variantField.Ty) {
 											tagMap["bin"] = "coption"
 										}
 										// add json tag:
@@ -279,15 +403,15 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 							}
 						case idl.IdlDefinedFieldsTuple:
 							for itemIndex, tupleItem := range fields {
-								optionality := IsOption(tupleItem) || IsCOption(tupleItem)
+								optionality := isPointerOption(tupleItem)
 								tupleItemName := FormatTupleItemName(itemIndex)
 								structGroup.Add(genFieldNamed(tupleItemName, tupleItem, optionality)).
 									Add(func() Code {
 										tagMap := map[string]string{}
-										if IsOption(tupleItem) {
+										if optionality && IsOption(tupleItem) {
 											tagMap["bin"] = "optional"
 										}
-										if IsCOption(tupleItem) {
+										if optionality && IsCOption(tupleItem) {
 											tagMap["bin"] = "coption"
 										}
 										// add json tag:
@@ -344,6 +468,7 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 					BlockFunc(func(body *Group) {
 						body.Return(Nil())
 					})
+				code.Add(gen_EqualClone_unitVariant(variantTypeNameComplex))
 				code.Line().Line()
 			} else if variant.Fields.IsSome() {
 				switch fields := variant.Fields.Unwrap().(type) {
@@ -368,6 +493,9 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 							"",
 							fields,
 						))
+					goFields := namedStructFields(fields, formatFieldGoName, formatFieldJSONName)
+					code.Add(gen_complexEnumJSON_struct(variantTypeNameComplex, goFields))
+					code.Add(gen_EqualClone_struct(variantTypeNameComplex, goFields))
 					code.Line().Line()
 				case idl.IdlDefinedFieldsTuple:
 					// TODO: handle tuples
@@ -391,6 +519,9 @@ func (g *Generator) gen_complexEnum(name string, docs []string, typ idl.IdlTypeD
 							"",
 							fields,
 						))
+					goFields := namedStructFields(fields, formatFieldGoName, formatFieldJSONName)
+					code.Add(gen_complexEnumJSON_struct(variantTypeNameComplex, goFields))
+					code.Add(gen_EqualClone_struct(variantTypeNameComplex, goFields))
 					code.Line().Line()
 				default:
 					panic("not handled: " + spew.Sdump(variant.Fields))
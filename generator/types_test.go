@@ -0,0 +1,200 @@
+package generator
+
+import (
+	"testing"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenSimpleEnum(t *testing.T) {
+	gen := &Generator{
+		idl:     &idl.Idl{},
+		options: &GeneratorOptions{Package: "test"},
+	}
+	code, err := gen.gen_IDLTypeDef(idl.IdlTypeDef{
+		Name: "status",
+		Ty: &idl.IdlTypeDefTyEnum{
+			Kind: "enum",
+			Variants: idl.VariantSlice{
+				{Name: "Active"},
+				{Name: "Paused"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	file := NewFile("test")
+	file.Add(code)
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		"type Status binary.BorshEnum",
+		"func (value Status) IsValid() bool {\n\tswitch value {\n\tcase Status_Active, Status_Paused:\n\t\treturn true\n\t}\n\treturn false\n}",
+		"func StatusValues() []Status {\n\treturn []Status{\n\t\tStatus_Active,\n\t\tStatus_Paused,\n\t}\n}",
+		"func ParseStatus(name string) (Status, error) {",
+		"case \"Paused\":\n\t\treturn Status_Paused, nil",
+		"func (value Status) MarshalText() ([]byte, error) {",
+		"func (value *Status) UnmarshalText(text []byte) error {",
+		"func (value Status) MarshalJSON() ([]byte, error) {",
+		"func (value *Status) UnmarshalJSON(data []byte) error {",
+		"func (value Status) MarshalWithEncoder(encoder *binary.Encoder) error {",
+		"if !Status(discriminant).IsValid() {\n\t\treturn fmt.Errorf(\"invalid Status discriminant: %d\", discriminant)",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+}
+
+func TestGenComplexEnumJSON(t *testing.T) {
+	idlData := &idl.Idl{
+		Types: []idl.IdlTypeDef{
+			{
+				Name: "JsonTestShape",
+				Ty: &idl.IdlTypeDefTyEnum{
+					Kind: "enum",
+					Variants: idl.VariantSlice{
+						{Name: "Empty"},
+						{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+					},
+				},
+			},
+			{
+				Name: "JsonTestHolder",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind: "struct",
+					Fields: idl.IdlDefinedFieldsNamed{
+						{Name: "amount", Ty: &idltype.U64{}},
+						{Name: "shape", Ty: &idltype.Defined{Name: "JsonTestShape"}},
+						{Name: "shapes", Ty: &idltype.Vec{Vec: &idltype.Defined{Name: "JsonTestShape"}}},
+					},
+				},
+			},
+		},
+	}
+	for _, typ := range idlData.Types {
+		registerComplexEnums(typ)
+	}
+	gen := &Generator{
+		idl:     idlData,
+		options: &GeneratorOptions{Package: "test"},
+	}
+	file := NewFile("test")
+	for _, typ := range idlData.Types {
+		code, err := gen.gen_IDLTypeDef(typ)
+		require.NoError(t, err)
+		file.Add(code)
+	}
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		"func MarshalJsonTestShapeJSON(value JsonTestShape) ([]byte, error) {",
+		"case *JsonTestShape_Empty:\n\t\treturn json.Marshal(map[string]any{\"Empty\": struct{}{}})",
+		"case *JsonTestShape_Circle:\n\t\treturn json.Marshal(map[string]any{\"Circle\": realvalue})",
+		"func UnmarshalJsonTestShapeJSON(data []byte) (JsonTestShape, error) {",
+		"variant := new(JsonTestShape_Circle)",
+		"func (obj JsonTestHolder) MarshalJSON() ([]byte, error) {\n\ttype alias JsonTestHolder",
+		"Shape  json.RawMessage   `json:\"shape\"`",
+		"Shapes []json.RawMessage `json:\"shapes\"`",
+		"aux.Shape, err = MarshalJsonTestShapeJSON(obj.Shape)",
+		"func (obj *JsonTestHolder) UnmarshalJSON(data []byte) error {",
+		"obj.Shapes[i], err = UnmarshalJsonTestShapeJSON(aux.Shapes[i])",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+	// Structs without complex enums are left to encoding/json.
+	assert.NotContains(t, generatedCode, "func (obj JsonTestShape_Circle) MarshalJSON")
+}
+
+func TestGenComplexEnumAPI(t *testing.T) {
+	typ := idl.IdlTypeDef{
+		Name: "ApiTestShape",
+		Ty: &idl.IdlTypeDefTyEnum{
+			Kind: "enum",
+			Variants: idl.VariantSlice{
+				{Name: "Empty"},
+				{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+				{Name: "Rect", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsTuple{&idltype.U32{}, &idltype.U32{}})},
+			},
+		},
+	}
+	gen := &Generator{
+		idl:     &idl.Idl{Types: []idl.IdlTypeDef{typ}},
+		options: &GeneratorOptions{Package: "test"},
+	}
+	code, err := gen.gen_IDLTypeDef(typ)
+	require.NoError(t, err)
+	file := NewFile("test")
+	file.Add(code)
+	generatedCode := file.GoString()
+
+	for _, expected := range []string{
+		"type ApiTestShape interface {\n\tisApiTestShape()\n\tKind() ApiTestShapeKind\n\tString() string\n}",
+		"type ApiTestShapeKind binary.BorshEnum",
+		"ApiTestShapeKind_Circle",
+		"func (_ *ApiTestShape_Rect) Kind() ApiTestShapeKind {\n\treturn ApiTestShapeKind_Rect\n}",
+		"func (_ *ApiTestShape_Empty) String() string {\n\treturn ApiTestShapeKind_Empty.String()\n}",
+		"func (value *ApiTestShape_Circle) String() string {\n\treturn fmt.Sprintf(\"Circle{radius: %v}\", value.Radius)\n}",
+		"func (value *ApiTestShape_Rect) String() string {\n\treturn fmt.Sprintf(\"Rect(%v, %v)\", value.V0, value.V1)\n}",
+		"func NewApiTestShapeEmpty() ApiTestShape {\n\treturn new(ApiTestShape_Empty)\n}",
+		"func NewApiTestShapeCircle(radiusParam uint64) ApiTestShape {\n\treturn &ApiTestShape_Circle{\n\t\tRadius: radiusParam,\n\t}\n}",
+		"func NewApiTestShapeRect(v0Param uint32, v1Param uint32) ApiTestShape {",
+		"type ApiTestShapeVisitor interface {\n\tVisitEmpty(value *ApiTestShape_Empty) error\n\tVisitCircle(value *ApiTestShape_Circle) error\n\tVisitRect(value *ApiTestShape_Rect) error\n}",
+		"func VisitApiTestShape(value ApiTestShape, visitor ApiTestShapeVisitor) error {",
+		"case *ApiTestShape_Circle:\n\t\treturn visitor.VisitCircle(realvalue)",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+
+	// A variant field named "kind" would clash with the Kind method.
+	clashing := idl.IdlTypeDefTyEnum{
+		Variants: idl.VariantSlice{
+			{Name: "Tagged", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "kind", Ty: &idltype.U8{}}})},
+		},
+	}
+	assert.Equal(t, "VariantKind", formatEnumKindMethodName(clashing))
+	// Likewise, a field named "string" would clash with the String method.
+	assert.True(t, hasEnumStringMethod(clashing))
+	clashing.Variants[0].Fields = idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "string", Ty: &idltype.String{}}})
+	assert.False(t, hasEnumStringMethod(clashing))
+}
+
+func TestGenComplexEnumKindNameClash(t *testing.T) {
+	shape := idl.IdlTypeDef{
+		Name: "KindTestShape",
+		Ty: &idl.IdlTypeDefTyEnum{
+			Kind: "enum",
+			Variants: idl.VariantSlice{
+				{Name: "Empty"},
+				{Name: "Circle", Fields: idl.Some[idl.IdlDefinedFields](idl.IdlDefinedFieldsNamed{{Name: "radius", Ty: &idltype.U64{}}})},
+			},
+		},
+	}
+	shapeKind := idl.IdlTypeDef{
+		Name: "KindTestShapeKind",
+		Ty:   &idl.IdlTypeDefTyStruct{Kind: "struct", Fields: idl.IdlDefinedFieldsNamed{{Name: "value", Ty: &idltype.U8{}}}},
+	}
+	gen := &Generator{
+		idl:     &idl.Idl{Types: []idl.IdlTypeDef{shape, shapeKind}},
+		options: &GeneratorOptions{Package: "test"},
+	}
+	code, err := gen.gen_IDLTypeDef(shape)
+	require.NoError(t, err)
+	file := NewFile("test")
+	file.Add(code)
+	generatedCode := file.GoString()
+
+	// The IDL has a type named KindTestShapeKind, so the kind is KindTestShapeVariantKind:
+	assert.Contains(t, generatedCode, "Kind() KindTestShapeVariantKind")
+	assert.Contains(t, generatedCode, "type KindTestShapeVariantKind binary.BorshEnum")
+	assert.NotContains(t, generatedCode, "type KindTestShapeKind ")
+
+	// When both names are taken, the type has to be renamed.
+	shapeVariantKind := shapeKind
+	shapeVariantKind.Name = "KindTestShapeVariantKind"
+	gen.idl.Types = append(gen.idl.Types, shapeVariantKind)
+	_, err = gen.gen_IDLTypeDef(shape)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KindTestShapeVariantKind")
+}
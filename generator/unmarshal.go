@@ -5,7 +5,6 @@ import (
 
 	. "github.com/dave/jennifer/jen"
 	"github.com/gagliardetto/anchor-go/idl"
-	"github.com/gagliardetto/anchor-go/idl/idltype"
 	"github.com/gagliardetto/anchor-go/tools"
 )
 
@@ -21,10 +20,6 @@ func FormatTupleItemName(index int) string {
 	return tools.ToCamelUpper(fmt.Sprintf("V%d", index))
 }
 
-func formatEnumContainerName(enumTypeName string) string {
-	return tools.ToCamelLower(enumTypeName) + "EnumContainer"
-}
-
 func formatInterfaceMethodName(enumTypeName string) string {
 	return "is" + tools.ToCamelUpper(enumTypeName)
 }
@@ -67,10 +62,22 @@ func formatEnumParserName(enumTypeName string) string {
 	return "Decode" + enumTypeName
 }
 
+func formatEnumLimitsParserName(enumTypeName string) string {
+	return "Decode" + enumTypeName + "WithLimits"
+}
+
 func formatEnumEncoderName(enumTypeName string) string {
 	return "Encode" + enumTypeName
 }
 
+func formatEnumJSONMarshalerName(enumTypeName string) string {
+	return "Marshal" + enumTypeName + "JSON"
+}
+
+func formatEnumJSONUnmarshalerName(enumTypeName string) string {
+	return "Unmarshal" + enumTypeName + "JSON"
+}
+
 func gen_UnmarshalWithDecoder_struct(
 	idl_ *idl.Idl,
 	withDiscriminator bool,
@@ -79,15 +86,12 @@ func gen_UnmarshalWithDecoder_struct(
 	fields idl.IdlDefinedFields,
 ) Code {
 	code := Empty()
+	code.Add(gen_UnmarshalWithDecoder("obj", receiverTypeName))
 	{
-		// Declare UnmarshalWithDecoder
-		code.Func().Params(Id("obj").Op("*").Id(receiverTypeName)).Id("UnmarshalWithDecoder").
-			Params(
-				ListFunc(func(params *Group) {
-					// Parameters:
-					params.Id("decoder").Op("*").Qual(PkgBinary, "Decoder")
-				}),
-			).
+		// Declare UnmarshalWithLimits
+		code.Line().Line()
+		code.Func().Params(Id("obj").Op("*").Id(receiverTypeName)).Id("UnmarshalWithLimits").
+			Params(ListFunc(decoderParams)).
 			Params(
 				ListFunc(func(results *Group) {
 					// Results:
@@ -117,10 +121,22 @@ func gen_UnmarshalWithDecoder_struct(
 
 				switch fields := fields.(type) {
 				case idl.IdlDefinedFieldsNamed:
-					gen_unmarshal_DefinedFieldsNamed(body, fields)
+					gen_unmarshal_DefinedFieldsNamed(
+						body,
+						fields,
+						func(field idl.IdlField) *Statement {
+							return Id("obj").Dot(tools.ToCamelUpper(field.Name))
+						},
+					)
 				case idl.IdlDefinedFieldsTuple:
 					convertedFields := tupleToFieldsNamed(fields)
-					gen_unmarshal_DefinedFieldsNamed(body, convertedFields)
+					gen_unmarshal_DefinedFieldsNamed(
+						body,
+						convertedFields,
+						func(field idl.IdlField) *Statement {
+							return Id("obj").Dot(tools.ToCamelUpper(field.Name))
+						},
+					)
 				case nil:
 					// No fields, just an empty struct.
 					// TODO: should we panic here?
@@ -151,9 +167,8 @@ func gen_UnmarshalWithDecoder_struct(
 			).
 			BlockFunc(func(body *Group) {
 				// Body:
-				body.Err().Op(":=").Id("obj").Dot("UnmarshalWithDecoder").Call(
-					Qual(PkgBinary, "NewBorshDecoder").Call(Id("buf")),
-				)
+				body.Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("buf"))
+				body.Err().Op(":=").Id("obj").Dot("UnmarshalWithDecoder").Call(Id("decoder"))
 				body.If(Err().Op("!=").Nil()).Block(
 					// If there was an error, return it.
 					Return(
@@ -228,6 +243,7 @@ func tupleToFieldsNamed(
 func gen_unmarshal_DefinedFieldsNamed(
 	body *Group,
 	fields idl.IdlDefinedFieldsNamed,
+	nameFormatter func(field idl.IdlField) *Statement,
 ) {
 	for _, field := range fields {
 		exportedArgName := tools.ToCamelUpper(field.Name)
@@ -237,141 +253,80 @@ func gen_unmarshal_DefinedFieldsNamed(
 			body.Commentf("Deserialize `%s`:", exportedArgName)
 		}
 
-		if isComplexEnum(field.Ty) || (IsArray(field.Ty) && isComplexEnum(field.Ty.(*idltype.Array).Type)) || (IsVec(field.Ty) && isComplexEnum(field.Ty.(*idltype.Vec).Vec)) {
-			// TODO: this assumes this cannot be an option;
-			// - check whether this is an option?
-			switch field.Ty.(type) {
-			case *idltype.Defined:
-				enumName := field.Ty.(*idltype.Defined).Name
-				body.BlockFunc(func(argBody *Group) {
-					{
-						argBody.Var().Err().Error()
-						argBody.List(
-							Id("obj").Dot(exportedArgName),
-							Err(),
-						).Op("=").Id(formatEnumParserName(enumName)).Call(Id("decoder"))
-					}
-					argBody.If(
-						Err().Op("!=").Nil(),
-					).Block(
-						Return(Err()),
-					)
-				})
-			case *idltype.Array:
-				enumTypeName := field.Ty.(*idltype.Array).Type.(*idltype.Defined).Name
-				body.BlockFunc(func(argBody *Group) {
-					// Read the array items:
-					argBody.For(
-						Id("i").Op(":=").Lit(0),
-						Id("i").Op("<").Len(Id("obj").Dot(exportedArgName)),
-						Id("i").Op("++"),
-					).BlockFunc(func(forBody *Group) {
-						forBody.List(
-							Id("obj").Dot(exportedArgName).Index(Id("i")),
-							Err(),
-						).Op("=").Id(formatEnumParserName(enumTypeName)).Call(Id("decoder"))
-						forBody.If(Err().Op("!=").Nil()).Block(
-							Return(
-								Qual(PkgAnchorGoErrors, "NewField").Call(
-									Lit(exportedArgName),
-									Qual(PkgAnchorGoErrors, "NewIndex").Call(
-										Id("i"),
-										Err(),
-									),
-								),
-							),
-						)
-					})
-				})
-			case *idltype.Vec:
-				enumTypeName := field.Ty.(*idltype.Vec).Vec.(*idltype.Defined).Name
-				body.BlockFunc(func(argBody *Group) {
-					// Read the vector length:
-					argBody.List(Id("vecLen"), Err()).Op(":=").Id("decoder").Dot("ReadLength").Call()
-					argBody.If(Err().Op("!=").Nil()).Block(
-						Return(
-							Qual(PkgAnchorGoErrors, "NewField").Call(
-								Lit(exportedArgName),
-								Qual("fmt", "Errorf").Call(
-									Lit("error while reading vector length: %w"),
-									Err(),
-								),
-							),
-						),
-					)
-					// Create the vector:
-					argBody.Id("obj").Dot(exportedArgName).Op("=").Make(Index().Id(enumTypeName), Id("vecLen"))
-					// Read the vector items:
-					argBody.For(
-						Id("i").Op(":=").Lit(0),
-						Id("i").Op("<").Id("vecLen"),
-						Id("i").Op("++"),
-					).BlockFunc(func(forBody *Group) {
-						forBody.List(
-							Id("obj").Dot(exportedArgName).Index(Id("i")),
-							Err(),
-						).Op("=").Id(formatEnumParserName(enumTypeName)).Call(Id("decoder"))
-						forBody.If(Err().Op("!=").Nil()).Block(
-							Return(
-								Qual(PkgAnchorGoErrors, "NewField").Call(
-									Lit(exportedArgName),
-									Qual(PkgAnchorGoErrors, "NewIndex").Call(
-										Id("i"),
-										Err(),
-									),
-								),
-							),
-						)
-					})
-				})
+		switch {
+		case genericOptions && (IsOption(field.Ty) || IsCOption(field.Ty)):
+			gen_unmarshal_genericOption(body, field, nameFormatter)
+		case IsOption(field.Ty) || IsCOption(field.Ty):
+			var optionalityReaderName string
+			switch {
+			case IsOption(field.Ty):
+				optionalityReaderName = "ReadOption"
+			case IsCOption(field.Ty):
+				optionalityReaderName = "ReadCOption"
 			}
-		} else {
-			if IsOption(field.Ty) || IsCOption(field.Ty) {
-				var optionalityReaderName string
-				switch {
-				case IsOption(field.Ty):
-					optionalityReaderName = "ReadOption"
-				case IsCOption(field.Ty):
-					optionalityReaderName = "ReadCOption"
-				}
 
-				body.BlockFunc(func(optGroup *Group) {
-					// if nil:
-					optGroup.List(Id("ok"), Err()).Op(":=").Id("decoder").Dot(optionalityReaderName).Call()
-					optGroup.If(Err().Op("!=").Nil()).Block(
-						Return(
-							Qual(PkgAnchorGoErrors, "NewOption").Call(
-								Lit(exportedArgName),
+			body.BlockFunc(func(optGroup *Group) {
+				// if nil:
+				optGroup.List(Id("ok"), Err()).Op(":=").Id("decoder").Dot(optionalityReaderName).Call()
+				optGroup.If(Err().Op("!=").Nil()).Block(
+					Return(
+						Qual(PkgAnchorGoErrors, "NewOption").Call(
+							Lit(field.Name),
+							withDecoderOffset(
 								Qual("fmt", "Errorf").Call(
 									Lit("error while reading optionality: %w"),
 									Err(),
 								),
 							),
 						),
-					)
-					optGroup.If(Id("ok")).Block(
-						Err().Op("=").Id("decoder").Dot("Decode").Call(Op("&").Id("obj").Dot(exportedArgName)),
-						If(Err().Op("!=").Nil()).Block(
-							Return(
-								Qual(PkgAnchorGoErrors, "NewField").Call(
-									Lit(exportedArgName),
-									Err(),
-								),
-							),
-						),
-					)
-				})
-			} else {
-				body.Err().Op("=").Id("decoder").Dot("Decode").Call(Op("&").Id("obj").Dot(exportedArgName))
-				body.If(Err().Op("!=").Nil()).Block(
-					Return(
-						Qual(PkgAnchorGoErrors, "NewField").Call(
-							Lit(exportedArgName),
-							Err(),
-						),
 					),
 				)
-			}
+				inner := optionInnerType(field.Ty)
+				optGroup.If(Id("ok")).BlockFunc(func(someBody *Group) {
+					someBody.Var().Id("value").Add(genTypeName(inner))
+					gen_decodeValue(someBody, Id("value"), inner, 0, func(err Code) Code {
+						return Return(Qual(PkgAnchorGoErrors, "NewOption").Call(Lit(field.Name), err))
+					})
+					someBody.Add(nameFormatter(field)).Op("=").Op("&").Id("value")
+				})
+			})
+		default:
+			gen_decodeValue(body, nameFormatter(field), field.Ty, 0, func(err Code) Code {
+				return Return(Qual(PkgAnchorGoErrors, "NewField").Call(Lit(field.Name), err))
+			})
 		}
 	}
 }
+
+// withDecoderOffset wraps the given error with the current position of the decoder:
+//
+//	errors.NewOffset(int(decoder.Position()), err)
+func withDecoderOffset(err Code) Code {
+	return Qual(PkgAnchorGoErrors, "NewOffset").Call(
+		Int().Call(Id("decoder").Dot("Position").Call()),
+		err,
+	)
+}
+
+// decoderParams declares the parameters of the generated decoding methods and functions:
+//
+//	decoder *bin.Decoder, budget *limits.Budget
+func decoderParams(params *Group) {
+	params.Id("decoder").Op("*").Qual(PkgBinary, "Decoder")
+	params.Id("budget").Op("*").Qual(PkgAnchorGoLimits, "Budget")
+}
+
+// gen_UnmarshalWithDecoder generates the UnmarshalWithDecoder method of a type, which decodes
+// it without limits (the declared lengths are still checked against the input):
+//
+//	func (obj *<type>) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+//		return obj.UnmarshalWithLimits(decoder, nil)
+//	}
+func gen_UnmarshalWithDecoder(receiverName string, receiverTypeName string) Code {
+	return Func().Params(Id(receiverName).Op("*").Id(receiverTypeName)).Id("UnmarshalWithDecoder").
+		Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).
+		Params(Error()).
+		Block(
+			Return(Id(receiverName).Dot("UnmarshalWithLimits").Call(Id("decoder"), Nil())),
+		)
+}
@@ -0,0 +1,391 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	. "github.com/dave/jennifer/jen"
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/tools"
+)
+
+// idlVersion is one of the versions of the IDL of the program (see GeneratorOptions.PreviousIdls).
+type idlVersion struct {
+	idl *idl.Idl
+	// typeNames maps the name of each type of this version to the name of its layout:
+	// the same name for the layouts shared with the current version, or else
+	// the name suffixed with the newest version that has the layout (see formatVersionedTypeName).
+	typeNames map[string]string
+}
+
+func formatVersionedTypeName(name string, version int) string {
+	return fmt.Sprintf("%s_V%d", name, version)
+}
+
+func formatIdlVersionName(version int) string {
+	return fmt.Sprintf("IdlVersion_V%d", version)
+}
+
+// registerVersions compares the previous versions of the IDL with the current one,
+// and appends to the current IDL the types whose layout changed, with a version suffix;
+// the types that didn't change are shared across versions.
+// It must be called before the types of the current IDL are registered.
+func (g *Generator) registerVersions() error {
+	if len(g.options.PreviousIdls) == 0 {
+		return nil
+	}
+	idls := make([]*idl.Idl, 0, len(g.options.PreviousIdls)+1)
+	for i, previous := range g.options.PreviousIdls {
+		if err := previous.Validate(); err != nil {
+			return fmt.Errorf("invalid IDL of version %d: %w", i+1, err)
+		}
+		if err := monomorphizeGenerics(previous); err != nil {
+			return fmt.Errorf("error while instantiating generic types of version %d: %w", i+1, err)
+		}
+		idls = append(idls, previous)
+	}
+	idls = append(idls, g.idl)
+	current := len(idls) - 1
+
+	// owners[i][name] is the index of the newest version with the same layout
+	// of the type as version i:
+	owners := make([]map[string]int, len(idls))
+	owners[current] = make(map[string]int)
+	for _, def := range g.idl.Types {
+		owners[current][def.Name] = current
+	}
+	ownerOf := func(i int, name string) int {
+		if owner, ok := owners[i][name]; ok {
+			return owner
+		}
+		return -1
+	}
+	for i := current - 1; i >= 0; i-- {
+		nextDefs := make(map[string]idl.IdlTypeDef)
+		for _, def := range idls[i+1].Types {
+			nextDefs[def.Name] = def
+		}
+		owners[i] = make(map[string]int)
+		for _, def := range idls[i].Types {
+			owners[i][def.Name] = i
+			if next, ok := nextDefs[def.Name]; ok && sameTypeDefLayout(def, next) {
+				owners[i][def.Name] = owners[i+1][def.Name]
+			}
+		}
+		// A layout also changes when the layout of a type it contains changes:
+		for changed := true; changed; {
+			changed = false
+			for _, def := range idls[i].Types {
+				if owners[i][def.Name] == i {
+					continue
+				}
+				for _, ref := range typeDefRefs(def) {
+					if ownerOf(i, ref) != ownerOf(i+1, ref) {
+						owners[i][def.Name] = i
+						changed = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	goNames := make(map[string]string)
+	for _, def := range g.idl.Types {
+		goNames[tools.ToCamelUpper(def.Name)] = def.Name
+	}
+	g.versions = make([]idlVersion, len(idls))
+	for i, idlObj := range idls {
+		typeNames := make(map[string]string)
+		for _, def := range idlObj.Types {
+			typeNames[def.Name] = def.Name
+			if owner := owners[i][def.Name]; owner != current {
+				typeNames[def.Name] = formatVersionedTypeName(def.Name, owner+1)
+			}
+		}
+		g.versions[i] = idlVersion{idl: idlObj, typeNames: typeNames}
+		if i == current {
+			continue
+		}
+		for _, def := range idlObj.Types {
+			if owners[i][def.Name] != i {
+				continue
+			}
+			versioned := renameTypeDef(def, typeNames[def.Name], typeNames)
+			goName := tools.ToCamelUpper(versioned.Name)
+			if other, ok := goNames[goName]; ok {
+				return fmt.Errorf("type %q of version %d would be generated as %s, which is already the name of type %q", def.Name, i+1, goName, other)
+			}
+			goNames[goName] = versioned.Name
+			g.idl.Types = append(g.idl.Types, versioned)
+		}
+	}
+	return nil
+}
+
+// sameTypeDefLayout tells whether two type definitions have the same layout
+// (the docs aside); the types they contain are compared by name.
+func sameTypeDefLayout(a, b idl.IdlTypeDef) bool {
+	layout := func(def idl.IdlTypeDef) string {
+		data, err := json.Marshal(struct {
+			Serialization idl.IdlSerialization
+			Repr          idl.Option[idl.IdlRepr]
+			Ty            idl.IdlTypeDefTy
+		}{def.Serialization, def.Repr, def.Ty})
+		if err != nil {
+			panic(fmt.Errorf("error while encoding type %q: %w", def.Name, err))
+		}
+		return string(data)
+	}
+	return layout(a) == layout(b)
+}
+
+// typeDefRefs returns the names of the types contained in a type definition.
+func typeDefRefs(def idl.IdlTypeDef) []string {
+	var refs []string
+	mapIdlTypes(&idl.Idl{Types: []idl.IdlTypeDef{def}}, func(ty idltype.IdlType) idltype.IdlType {
+		if defined, ok := ty.(*idltype.Defined); ok {
+			refs = append(refs, defined.Name)
+		}
+		return ty
+	})
+	return refs
+}
+
+// renameTypeDef returns a copy of def named name, where the types it contains
+// are renamed according to typeNames.
+func renameTypeDef(def idl.IdlTypeDef, name string, typeNames map[string]string) idl.IdlTypeDef {
+	renamed := &idl.Idl{Types: []idl.IdlTypeDef{def}}
+	mapIdlTypes(renamed, func(ty idltype.IdlType) idltype.IdlType {
+		if defined, ok := ty.(*idltype.Defined); ok {
+			if typeName, ok := typeNames[defined.Name]; ok {
+				return &idltype.Defined{Name: typeName, Generics: defined.Generics}
+			}
+		}
+		return ty
+	})
+	renamed.Types[0].Name = name
+	return renamed.Types[0]
+}
+
+// versionedAccountLayout is the layout of an account in one or more versions of the IDL.
+type versionedAccountLayout struct {
+	typeName string
+	versions []int // Oldest first.
+}
+
+// genfile_versions generates the file `versions.go`, which contains the parser
+// of the accounts of all the versions of the IDL.
+func (g *Generator) genfile_versions() (*OutputFile, error) {
+	file := NewFile(g.options.Package)
+	file.HeaderComment("Code generated by https://github.com/gagliardetto/anchor-go. DO NOT EDIT.")
+	file.HeaderComment("This file contains the parser of the accounts of all the versions of the IDL.")
+
+	current := len(g.versions)
+	{
+		file.Comment("IdlVersion identifies a version of the IDL of the program,")
+		file.Comment("from the oldest (1) to the current one.")
+		file.Type().Id("IdlVersion").Int()
+		file.Line()
+		file.Const().DefsFunc(func(defs *Group) {
+			for i, version := range g.versions {
+				code := Id(formatIdlVersionName(i + 1)).Id("IdlVersion").Op("=").Lit(i + 1)
+				if version.idl.Metadata.Version != "" {
+					code.Comment("IDL version " + version.idl.Metadata.Version)
+				}
+				defs.Add(code)
+			}
+			defs.Comment("IdlVersion_Current is the current version of the IDL.")
+			defs.Id("IdlVersion_Current").Id("IdlVersion").Op("=").Id(formatIdlVersionName(current))
+		})
+		file.Line()
+	}
+	{
+		file.Comment("SlotRange is the range of slots [From, To) in which a version of the program")
+		file.Comment("was deployed; a zero To means that the range is open.")
+		file.Type().Id("SlotRange").Struct(
+			Id("From").Uint64(),
+			Id("To").Uint64(),
+		)
+		file.Line()
+		file.Comment("Contains tells whether slot is in the range.")
+		file.Func().Params(Id("r").Id("SlotRange")).Id("Contains").Params(Id("slot").Uint64()).Bool().Block(
+			Return(Id("slot").Op(">=").Id("r").Dot("From").Op("&&").Parens(Id("r").Dot("To").Op("==").Lit(0).Op("||").Id("slot").Op("<").Id("r").Dot("To"))),
+		)
+		file.Line()
+		file.Comment("VersionSelector picks the layout of the accounts parsed by ParseAnyAccountVersioned.")
+		file.Type().Id("VersionSelector").Struct(
+			Comment("Slot is the slot at which the account data was observed (zero if unknown)."),
+			Id("Slot").Uint64(),
+			Comment("SlotRanges are the (non-overlapping) ranges of slots in which the versions of the program were deployed."),
+			Id("SlotRanges").Map(Id("IdlVersion")).Id("SlotRange"),
+		)
+		file.Line()
+	}
+	{
+		file.Comment("accountLayout is the layout of an account in one or more versions of the IDL.")
+		file.Type().Id("accountLayout").Struct(
+			Id("versions").Index().Id("IdlVersion").Comment("Oldest first."),
+			Id("decode").Func().Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).Params(Any(), Error()),
+		)
+		file.Line()
+		file.Func().Id("decodeAccountLayout").
+			Types(
+				Id("T").Any(),
+				Id("PT").Interface(
+					Op("*").Id("T"),
+					Id("UnmarshalWithDecoder").Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).Error(),
+				),
+			).
+			Params(Id("decoder").Op("*").Qual(PkgBinary, "Decoder")).
+			Params(Any(), Error()).
+			Block(
+				Id("value").Op(":=").Id("PT").Call(New(Id("T"))),
+				If(Err().Op(":=").Id("value").Dot("UnmarshalWithDecoder").Call(Id("decoder")), Err().Op("!=").Nil()).Block(
+					Return(Nil(), Err()),
+				),
+				Return(Id("value"), Nil()),
+			)
+		file.Line()
+	}
+	{
+		// Group the layouts of the accounts by discriminator, newest first:
+		var discriminators [][8]byte
+		layouts := make(map[[8]byte][]*versionedAccountLayout)
+		for i := current - 1; i >= 0; i-- {
+			version := g.versions[i]
+			for _, acc := range version.idl.Accounts {
+				typeName, ok := version.typeNames[acc.Name]
+				if !ok || len(acc.Discriminator) != 8 {
+					continue
+				}
+				discriminator := [8]byte(acc.Discriminator)
+				if _, ok := layouts[discriminator]; !ok {
+					discriminators = append(discriminators, discriminator)
+				}
+				var layout *versionedAccountLayout
+				for _, other := range layouts[discriminator] {
+					if other.typeName == typeName {
+						layout = other
+					}
+				}
+				if layout == nil {
+					layout = &versionedAccountLayout{typeName: typeName}
+					layouts[discriminator] = append(layouts[discriminator], layout)
+				}
+				layout.versions = append([]int{i + 1}, layout.versions...)
+			}
+		}
+		currentAccounts := make(map[[8]byte]string)
+		for _, acc := range g.idl.Accounts {
+			if len(acc.Discriminator) == 8 {
+				currentAccounts[[8]byte(acc.Discriminator)] = acc.Name
+			}
+		}
+		file.Comment("accountLayouts are the layouts of the accounts of all the versions, by discriminator, newest first.")
+		file.Var().Id("accountLayouts").Op("=").Map(Index(Lit(8)).Byte()).Index().Id("accountLayout").Values(DictFunc(func(dict Dict) {
+			for _, discriminator := range discriminators {
+				var key Code
+				if name, ok := currentAccounts[discriminator]; ok {
+					key = Id(FormatAccountDiscriminatorName(name))
+				} else {
+					// The account was removed from the current version:
+					key = Values(ListFunc(func(bytes *Group) {
+						for _, b := range discriminator {
+							bytes.Lit(int(b))
+						}
+					}))
+				}
+				dict[key] = ValuesFunc(func(values *Group) {
+					for _, layout := range layouts[discriminator] {
+						values.Values(Dict{
+							Id("versions"): Index().Id("IdlVersion").ValuesFunc(func(versions *Group) {
+								for _, version := range layout.versions {
+									versions.Id(formatIdlVersionName(version))
+								}
+							}),
+							Id("decode"): Id("decodeAccountLayout").Index(Id(tools.ToCamelUpper(layout.typeName))),
+						})
+					}
+				})
+			}
+		}))
+		file.Line()
+	}
+	{
+		file.Comment("ParseAnyAccountVersioned parses an account of any version of the program, and returns the version of its layout.")
+		file.Comment("The layout is picked by discriminator, then (when the account has several layouts):")
+		file.Comment("  - by the slot ranges of the selector, if the slot is known;")
+		file.Comment("  - else by data size: the newest layout that decodes all the account data,")
+		file.Comment("    or else the newest layout that decodes it.")
+		file.Func().Id("ParseAnyAccountVersioned").
+			Params(Id("accountData").Index().Byte(), Id("selector").Id("VersionSelector")).
+			Params(Any(), Id("IdlVersion"), Error()).
+			BlockFunc(func(block *Group) {
+				block.Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("accountData"))
+				block.List(Id("discriminator"), Err()).Op(":=").Id("decoder").Dot("ReadDiscriminator").Call()
+				block.If(Err().Op("!=").Nil()).Block(
+					Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("failed to peek account discriminator: %w"), Err())),
+				)
+				block.List(Id("layouts"), Id("ok")).Op(":=").Id("accountLayouts").Index(Id("discriminator"))
+				block.If(Op("!").Id("ok")).Block(
+					Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("unknown discriminator: %s"), Qual(PkgBinary, "FormatDiscriminator").Call(Id("discriminator")))),
+				)
+				block.Id("decodeLayout").Op(":=").Func().Params(Id("layout").Id("accountLayout")).Params(Any(), Bool(), Error()).Block(
+					Id("decoder").Op(":=").Qual(PkgBinary, "NewBorshDecoder").Call(Id("accountData").Index(Lit(8), Empty())),
+					List(Id("value"), Err()).Op(":=").Id("layout").Dot("decode").Call(Id("decoder")),
+					Return(Id("value"), Id("decoder").Dot("Remaining").Call().Op("==").Lit(0), Err()),
+				)
+				block.Line()
+				block.If(Id("selector").Dot("Slot").Op("!=").Lit(0)).Block(
+					For(Id("version").Op(":=").Id("IdlVersion_Current"), Id("version").Op(">=").Id(formatIdlVersionName(1)), Id("version").Op("--")).Block(
+						List(Id("slotRange"), Id("ok")).Op(":=").Id("selector").Dot("SlotRanges").Index(Id("version")),
+						If(Op("!").Id("ok").Op("||").Op("!").Id("slotRange").Dot("Contains").Call(Id("selector").Dot("Slot"))).Block(
+							Continue(),
+						),
+						For(List(Id("_"), Id("layout")).Op(":=").Range().Id("layouts")).Block(
+							If(Qual("slices", "Contains").Call(Id("layout").Dot("versions"), Id("version"))).Block(
+								List(Id("value"), Id("_"), Err()).Op(":=").Id("decodeLayout").Call(Id("layout")),
+								If(Err().Op("!=").Nil()).Block(
+									Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("failed to unmarshal account of version %d: %w"), Id("version"), Err())),
+								),
+								Return(Id("value"), Id("version"), Nil()),
+							),
+						),
+						Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("no layout of account %s in version %d"), Qual(PkgBinary, "FormatDiscriminator").Call(Id("discriminator")), Id("version"))),
+					),
+				)
+				block.Line()
+				block.Comment("Pick the layout by data size:")
+				block.Var().Id("fallback").Any()
+				block.Var().Id("fallbackVersion").Id("IdlVersion")
+				block.Var().Id("firstErr").Error()
+				block.For(List(Id("_"), Id("layout")).Op(":=").Range().Id("layouts")).Block(
+					Id("version").Op(":=").Id("layout").Dot("versions").Index(Len(Id("layout").Dot("versions")).Op("-").Lit(1)),
+					List(Id("value"), Id("exact"), Err()).Op(":=").Id("decodeLayout").Call(Id("layout")),
+					Switch().Block(
+						Case(Err().Op("!=").Nil()).Block(
+							If(Id("firstErr").Op("==").Nil()).Block(
+								Id("firstErr").Op("=").Qual("fmt", "Errorf").Call(Lit("version %d: %w"), Id("version"), Err()),
+							),
+						),
+						Case(Id("exact")).Block(
+							Return(Id("value"), Id("version"), Nil()),
+						),
+						Case(Id("fallback").Op("==").Nil()).Block(
+							List(Id("fallback"), Id("fallbackVersion")).Op("=").List(Id("value"), Id("version")),
+						),
+					),
+				)
+				block.If(Id("fallback").Op("!=").Nil()).Block(
+					Return(Id("fallback"), Id("fallbackVersion"), Nil()),
+				)
+				block.Return(Nil(), Lit(0), Qual("fmt", "Errorf").Call(Lit("failed to unmarshal account with any layout: %w"), Id("firstErr")))
+			})
+	}
+	return &OutputFile{
+		Name: "versions.go",
+		File: file,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterVersions(t *testing.T) {
+	newIdl := func(version string, balance idltype.IdlType, legacy bool) *idl.Idl {
+		idlData := &idl.Idl{
+			Metadata: idl.IdlMetadata{Name: "versioned", Version: version, Spec: "0.1.0"},
+			Accounts: []idl.IdlAccount{{Name: "VsVault", Discriminator: idl.IdlDiscriminator{1, 2, 3, 4, 5, 6, 7, 8}}},
+			Types: []idl.IdlTypeDef{
+				{
+					Name: "VsVault",
+					Ty: &idl.IdlTypeDefTyStruct{
+						Kind: "struct",
+						Fields: idl.IdlDefinedFieldsNamed{
+							{Name: "owner", Ty: &idltype.Pubkey{}},
+							{Name: "balance", Ty: &idltype.Defined{Name: "VsBalance"}},
+						},
+					},
+				},
+				{
+					Name: "VsBalance",
+					Ty: &idl.IdlTypeDefTyStruct{
+						Kind:   "struct",
+						Fields: idl.IdlDefinedFieldsNamed{{Name: "amount", Ty: balance}},
+					},
+				},
+				{
+					Name: "VsOwner",
+					Ty: &idl.IdlTypeDefTyStruct{
+						Kind:   "struct",
+						Fields: idl.IdlDefinedFieldsNamed{{Name: "key", Ty: &idltype.Pubkey{}}},
+					},
+				},
+			},
+		}
+		if legacy {
+			idlData.Accounts = append(idlData.Accounts, idl.IdlAccount{Name: "VsLegacy", Discriminator: idl.IdlDiscriminator{9, 9, 9, 9, 9, 9, 9, 9}})
+			idlData.Types = append(idlData.Types, idl.IdlTypeDef{
+				Name: "VsLegacy",
+				Ty: &idl.IdlTypeDefTyStruct{
+					Kind:   "struct",
+					Fields: idl.IdlDefinedFieldsNamed{{Name: "x", Ty: &idltype.U32{}}},
+				},
+			})
+		}
+		return idlData
+	}
+	current := newIdl("0.3.0", &idltype.U64{}, false)
+	gen := &Generator{
+		idl: current,
+		options: &GeneratorOptions{
+			Package: "test",
+			PreviousIdls: []*idl.Idl{
+				newIdl("0.1.0", &idltype.U32{}, true),
+				newIdl("0.2.0", &idltype.U32{}, false),
+			},
+		},
+	}
+	require.NoError(t, gen.registerVersions())
+
+	// The balance changed after the 2nd version, and so did the vault that contains it;
+	// the owner never changed.
+	require.Len(t, gen.versions, 3)
+	assert.Equal(t, map[string]string{"VsVault": "VsVault_V2", "VsBalance": "VsBalance_V2", "VsOwner": "VsOwner", "VsLegacy": "VsLegacy_V1"}, gen.versions[0].typeNames)
+	assert.Equal(t, map[string]string{"VsVault": "VsVault_V2", "VsBalance": "VsBalance_V2", "VsOwner": "VsOwner"}, gen.versions[1].typeNames)
+	var names []string
+	for _, def := range current.Types {
+		names = append(names, def.Name)
+	}
+	assert.Equal(t, []string{"VsVault", "VsBalance", "VsOwner", "VsLegacy_V1", "VsVault_V2", "VsBalance_V2"}, names)
+	vaultV2 := current.Types[4].Ty.(*idl.IdlTypeDefTyStruct).Fields.(idl.IdlDefinedFieldsNamed)
+	assert.Equal(t, &idltype.Defined{Name: "VsBalance_V2"}, vaultV2[1].Ty)
+
+	file, err := gen.genfile_versions()
+	require.NoError(t, err)
+	generatedCode := file.File.GoString()
+	for _, expected := range []string{
+		"IdlVersion_V1 IdlVersion = 1 // IDL version 0.1.0",
+		"IdlVersion_Current IdlVersion = IdlVersion_V3",
+		"func ParseAnyAccountVersioned(accountData []byte, selector VersionSelector) (any, IdlVersion, error) {",
+		"decode:   decodeAccountLayout[VsVault],\n\t\tversions: []IdlVersion{IdlVersion_V3},",
+		"decode:   decodeAccountLayout[VsVaultV2],\n\t\tversions: []IdlVersion{IdlVersion_V1, IdlVersion_V2},",
+		"{9, 9, 9, 9, 9, 9, 9, 9}: {{",
+	} {
+		assert.Contains(t, generatedCode, expected)
+	}
+}
@@ -0,0 +1,574 @@
+package idl
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// The legacy IDL format is the one generated by anchor before v0.30.0.
+// ConvertLegacy converts it the way `anchor idl convert` does:
+//   - the program name, version and address move to the metadata (and address);
+//   - the discriminators are computed from the names (sighash);
+//   - the instruction, account and field names are converted to snake_case;
+//   - isMut/isSigner/isOptional become writable/signer/optional;
+//   - the layouts of the accounts and events move to the types;
+//   - publicKey becomes pubkey, and `"defined": "Name"` becomes `"defined": {"name": "Name"}`.
+
+type legacyIdl struct {
+	Version      string              `json:"version"`
+	Name         string              `json:"name"`
+	Docs         []string            `json:"docs"`
+	Constants    []legacyConst       `json:"constants"`
+	Instructions []legacyInstruction `json:"instructions"`
+	Accounts     []legacyTypeDef     `json:"accounts"`
+	Types        []legacyTypeDef     `json:"types"`
+	Events       []legacyEvent       `json:"events"`
+	Errors       []legacyErrorCode   `json:"errors"`
+	Metadata     struct {
+		Address string `json:"address"`
+	} `json:"metadata"`
+}
+
+type legacyConst struct {
+	Name  string          `json:"name"`
+	Type  json.RawMessage `json:"type"`
+	Value string          `json:"value"`
+}
+
+type legacyInstruction struct {
+	Name     string              `json:"name"`
+	Docs     []string            `json:"docs"`
+	Accounts []legacyAccountItem `json:"accounts"`
+	Args     []legacyField       `json:"args"`
+	Returns  json.RawMessage     `json:"returns"`
+}
+
+// legacyAccountItem is an account of an instruction, or a group of accounts if Accounts is set.
+type legacyAccountItem struct {
+	Name       string              `json:"name"`
+	Docs       []string            `json:"docs"`
+	IsMut      bool                `json:"isMut"`
+	IsSigner   bool                `json:"isSigner"`
+	IsOptional bool                `json:"isOptional"`
+	Pda        *legacyPda          `json:"pda"`
+	Relations  []string            `json:"relations"`
+	Accounts   []legacyAccountItem `json:"accounts"`
+}
+
+type legacyPda struct {
+	Seeds     []legacySeed `json:"seeds"`
+	ProgramID *legacySeed  `json:"programId"`
+}
+
+type legacySeed struct {
+	Kind    string          `json:"kind"`
+	Type    json.RawMessage `json:"type"`
+	Value   json.RawMessage `json:"value"`
+	Path    string          `json:"path"`
+	Account string          `json:"account"`
+}
+
+type legacyField struct {
+	Name string          `json:"name"`
+	Docs []string        `json:"docs"`
+	Type json.RawMessage `json:"type"`
+}
+
+type legacyTypeDef struct {
+	Name string   `json:"name"`
+	Docs []string `json:"docs"`
+	Type struct {
+		Kind     string              `json:"kind"`
+		Fields   []legacyField       `json:"fields"`
+		Variants []legacyEnumVariant `json:"variants"`
+		Value    json.RawMessage     `json:"value"`
+	} `json:"type"`
+}
+
+type legacyEnumVariant struct {
+	Name string `json:"name"`
+	// Fields are either named fields, or the types of tuple fields.
+	Fields []json.RawMessage `json:"fields"`
+}
+
+type legacyEvent struct {
+	Name   string        `json:"name"`
+	Fields []legacyField `json:"fields"`
+}
+
+type legacyErrorCode struct {
+	Code uint32  `json:"code"`
+	Name string  `json:"name"`
+	Msg  *string `json:"msg"`
+}
+
+// ConvertLegacy converts an IDL in the legacy format (see IsOldIdl) and parses it.
+func ConvertLegacy(data []byte) (*Idl, error) {
+	converted, err := convertLegacy(data)
+	if err != nil {
+		return nil, err
+	}
+	var idl Idl
+	if err := json.Unmarshal(converted, &idl); err != nil {
+		return nil, fmt.Errorf("failed to parse the converted IDL: %w", err)
+	}
+	return &idl, nil
+}
+
+// ConvertLegacyToJSON converts an IDL in the legacy format (see IsOldIdl) to the current format.
+// The fields are written in the order of the Idl types, which is the order of `anchor idl convert`.
+func ConvertLegacyToJSON(data []byte) ([]byte, error) {
+	idl, err := ConvertLegacy(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(idl, "", "  ")
+}
+
+// convertLegacy converts an IDL in the legacy format to the current format, as unordered JSON.
+func convertLegacy(data []byte) ([]byte, error) {
+	var legacy legacyIdl
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("failed to parse the legacy IDL: %w", err)
+	}
+	out := map[string]any{
+		"metadata": map[string]any{
+			"name":    legacy.Name,
+			"version": legacy.Version,
+			"spec":    "0.1.0",
+		},
+	}
+	if legacy.Metadata.Address != "" {
+		out["address"] = legacy.Metadata.Address
+	}
+	if len(legacy.Docs) > 0 {
+		out["docs"] = legacy.Docs
+	}
+
+	instructions := []any{}
+	for _, ix := range legacy.Instructions {
+		converted, err := convertLegacyInstruction(ix)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %s: %w", ix.Name, err)
+		}
+		instructions = append(instructions, converted)
+	}
+	out["instructions"] = instructions
+
+	// The layouts of the accounts and events are types of the same name.
+	var types []any
+	definedTypes := make(map[string]bool)
+	addType := func(def legacyTypeDef) error {
+		if definedTypes[def.Name] {
+			return nil
+		}
+		definedTypes[def.Name] = true
+		converted, err := convertLegacyTypeDef(def)
+		if err != nil {
+			return fmt.Errorf("type %s: %w", def.Name, err)
+		}
+		types = append(types, converted)
+		return nil
+	}
+	var accounts []any
+	for _, account := range legacy.Accounts {
+		accounts = append(accounts, map[string]any{
+			"name":          account.Name,
+			"discriminator": legacyDiscriminator(bin.Sighash("account", account.Name)),
+		})
+		if err := addType(account); err != nil {
+			return nil, err
+		}
+	}
+	for _, def := range legacy.Types {
+		if err := addType(def); err != nil {
+			return nil, err
+		}
+	}
+	var events []any
+	for _, event := range legacy.Events {
+		events = append(events, map[string]any{
+			"name":          event.Name,
+			"discriminator": legacyDiscriminator(bin.Sighash("event", event.Name)),
+		})
+		def := legacyTypeDef{Name: event.Name}
+		def.Type.Kind = "struct"
+		def.Type.Fields = event.Fields
+		if err := addType(def); err != nil {
+			return nil, err
+		}
+	}
+	if accounts != nil {
+		out["accounts"] = accounts
+	}
+	if events != nil {
+		out["events"] = events
+	}
+	if types != nil {
+		out["types"] = types
+	}
+
+	if len(legacy.Errors) > 0 {
+		errorCodes := make([]any, 0, len(legacy.Errors))
+		for _, errorCode := range legacy.Errors {
+			converted := map[string]any{
+				"code": errorCode.Code,
+				"name": errorCode.Name,
+			}
+			if errorCode.Msg != nil {
+				converted["msg"] = *errorCode.Msg
+			}
+			errorCodes = append(errorCodes, converted)
+		}
+		out["errors"] = errorCodes
+	}
+	if len(legacy.Constants) > 0 {
+		constants := make([]any, 0, len(legacy.Constants))
+		for _, constant := range legacy.Constants {
+			ty, err := convertLegacyType(constant.Type)
+			if err != nil {
+				return nil, fmt.Errorf("constant %s: %w", constant.Name, err)
+			}
+			constants = append(constants, map[string]any{
+				"name":  constant.Name,
+				"type":  ty,
+				"value": constant.Value,
+			})
+		}
+		out["constants"] = constants
+	}
+	return json.Marshal(out)
+}
+
+// legacyDiscriminator returns the discriminator of a sighash, as a JSON array of numbers.
+func legacyDiscriminator(sighash []byte) []uint {
+	discriminator := make([]uint, 8)
+	for i := range discriminator {
+		discriminator[i] = uint(sighash[i])
+	}
+	return discriminator
+}
+
+func convertLegacyInstruction(ix legacyInstruction) (map[string]any, error) {
+	accounts, err := convertLegacyAccountItems(ix.Accounts)
+	if err != nil {
+		return nil, err
+	}
+	args, err := convertLegacyFields(ix.Args)
+	if err != nil {
+		return nil, err
+	}
+	converted := map[string]any{
+		"name":          bin.ToSnakeForSighash(ix.Name),
+		"discriminator": legacyDiscriminator(bin.SighashInstruction(ix.Name)),
+		"accounts":      accounts,
+		"args":          args,
+	}
+	if len(ix.Docs) > 0 {
+		converted["docs"] = ix.Docs
+	}
+	if len(ix.Returns) > 0 && string(ix.Returns) != "null" {
+		returns, err := convertLegacyType(ix.Returns)
+		if err != nil {
+			return nil, fmt.Errorf("returns: %w", err)
+		}
+		converted["returns"] = returns
+	}
+	return converted, nil
+}
+
+func convertLegacyAccountItems(items []legacyAccountItem) ([]any, error) {
+	converted := make([]any, 0, len(items))
+	for _, item := range items {
+		account := map[string]any{
+			"name": bin.ToSnakeForSighash(item.Name),
+		}
+		if item.Accounts != nil {
+			accounts, err := convertLegacyAccountItems(item.Accounts)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", item.Name, err)
+			}
+			account["accounts"] = accounts
+			converted = append(converted, account)
+			continue
+		}
+		if len(item.Docs) > 0 {
+			account["docs"] = item.Docs
+		}
+		if item.IsMut {
+			account["writable"] = true
+		}
+		if item.IsSigner {
+			account["signer"] = true
+		}
+		if item.IsOptional {
+			account["optional"] = true
+		}
+		if len(item.Relations) > 0 {
+			relations := make([]string, 0, len(item.Relations))
+			for _, relation := range item.Relations {
+				relations = append(relations, bin.ToSnakeForSighash(relation))
+			}
+			account["relations"] = relations
+		}
+		if item.Pda != nil {
+			pda, err := convertLegacyPda(item.Pda)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", item.Name, err)
+			}
+			account["pda"] = pda
+		}
+		converted = append(converted, account)
+	}
+	return converted, nil
+}
+
+func convertLegacyPda(pda *legacyPda) (map[string]any, error) {
+	seeds := make([]any, 0, len(pda.Seeds))
+	for i, seed := range pda.Seeds {
+		converted, err := convertLegacySeed(seed)
+		if err != nil {
+			return nil, fmt.Errorf("seeds[%d]: %w", i, err)
+		}
+		seeds = append(seeds, converted)
+	}
+	converted := map[string]any{
+		"seeds": seeds,
+	}
+	if pda.ProgramID != nil {
+		program, err := convertLegacySeed(*pda.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("programId: %w", err)
+		}
+		converted["program"] = program
+	}
+	return converted, nil
+}
+
+func convertLegacySeed(seed legacySeed) (map[string]any, error) {
+	switch seed.Kind {
+	case "const":
+		value, err := legacyConstSeedBytes(seed.Type, seed.Value)
+		if err != nil {
+			return nil, err
+		}
+		// As numbers, not base64.
+		numbers := make([]uint, len(value))
+		for i, b := range value {
+			numbers[i] = uint(b)
+		}
+		return map[string]any{"kind": "const", "value": numbers}, nil
+	case "arg":
+		return map[string]any{"kind": "arg", "path": legacySeedPath(seed.Path)}, nil
+	case "account":
+		converted := map[string]any{"kind": "account", "path": legacySeedPath(seed.Path)}
+		if seed.Account != "" {
+			converted["account"] = seed.Account
+		}
+		return converted, nil
+	}
+	return nil, fmt.Errorf("unknown seed kind %q", seed.Kind)
+}
+
+// legacySeedPath converts the path of a seed (e.g. `vault.ownerKey`) to snake_case.
+func legacySeedPath(path string) string {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		segments[i] = bin.ToSnakeForSighash(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
+// legacyConstSeedBytes returns the bytes of a const seed, whose value is typed in the legacy format.
+func legacyConstSeedBytes(rawType json.RawMessage, rawValue json.RawMessage) ([]byte, error) {
+	var ty any
+	if err := json.Unmarshal(rawType, &ty); err != nil {
+		return nil, fmt.Errorf("invalid seed type: %w", err)
+	}
+	name, _ := ty.(string)
+	switch name {
+	case "string":
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return nil, fmt.Errorf("invalid string seed: %w", err)
+		}
+		return []byte(value), nil
+	case "publicKey":
+		var value string
+		if err := json.Unmarshal(rawValue, &value); err != nil {
+			return nil, fmt.Errorf("invalid public key seed: %w", err)
+		}
+		key, err := solana.PublicKeyFromBase58(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key seed: %w", err)
+		}
+		return key.Bytes(), nil
+	case "u8", "i8", "u16", "i16", "u32", "i32", "u64", "i64":
+		bits, _ := strconv.Atoi(name[1:])
+		text := strings.TrimSpace(string(rawValue))
+		var value uint64
+		var err error
+		if name[0] == 'u' {
+			value, err = strconv.ParseUint(text, 10, bits)
+		} else {
+			var signed int64
+			signed, err = strconv.ParseInt(text, 10, bits)
+			value = uint64(signed)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s seed: %w", name, err)
+		}
+		return binary.LittleEndian.AppendUint64(nil, value)[:bits/8], nil
+	}
+	// bytes, or arrays of u8.
+	var numbers []uint8
+	if err := json.Unmarshal(rawValue, &numbers); err != nil {
+		return nil, fmt.Errorf("unsupported const seed of type %s: %w", rawType, err)
+	}
+	return numbers, nil
+}
+
+func convertLegacyFields(fields []legacyField) ([]any, error) {
+	converted := make([]any, 0, len(fields))
+	for _, field := range fields {
+		ty, err := convertLegacyType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		convertedField := map[string]any{
+			"name": bin.ToSnakeForSighash(field.Name),
+			"type": ty,
+		}
+		if len(field.Docs) > 0 {
+			convertedField["docs"] = field.Docs
+		}
+		converted = append(converted, convertedField)
+	}
+	return converted, nil
+}
+
+func convertLegacyTypeDef(def legacyTypeDef) (map[string]any, error) {
+	var ty map[string]any
+	switch def.Type.Kind {
+	case "struct":
+		fields, err := convertLegacyFields(def.Type.Fields)
+		if err != nil {
+			return nil, err
+		}
+		ty = map[string]any{"kind": "struct", "fields": fields}
+	case "enum":
+		variants := make([]any, 0, len(def.Type.Variants))
+		for _, variant := range def.Type.Variants {
+			converted := map[string]any{"name": variant.Name}
+			if len(variant.Fields) > 0 {
+				fields, err := convertLegacyVariantFields(variant.Fields)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", variant.Name, err)
+				}
+				converted["fields"] = fields
+			}
+			variants = append(variants, converted)
+		}
+		ty = map[string]any{"kind": "enum", "variants": variants}
+	case "alias":
+		alias, err := convertLegacyType(def.Type.Value)
+		if err != nil {
+			return nil, err
+		}
+		ty = map[string]any{"kind": "type", "alias": alias}
+	default:
+		return nil, fmt.Errorf("unknown kind %q", def.Type.Kind)
+	}
+	converted := map[string]any{
+		"name": def.Name,
+		"type": ty,
+	}
+	if len(def.Docs) > 0 {
+		converted["docs"] = def.Docs
+	}
+	return converted, nil
+}
+
+// convertLegacyVariantFields converts the fields of an enum variant: named fields
+// are objects with a name and a type, and tuple fields are types.
+func convertLegacyVariantFields(fields []json.RawMessage) ([]any, error) {
+	var named struct {
+		Name string          `json:"name"`
+		Type json.RawMessage `json:"type"`
+	}
+	if err := json.Unmarshal(fields[0], &named); err == nil && named.Name != "" && len(named.Type) > 0 {
+		namedFields := make([]legacyField, len(fields))
+		for i, field := range fields {
+			if err := json.Unmarshal(field, &namedFields[i]); err != nil {
+				return nil, err
+			}
+		}
+		return convertLegacyFields(namedFields)
+	}
+	converted := make([]any, 0, len(fields))
+	for i, field := range fields {
+		ty, err := convertLegacyType(field)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		converted = append(converted, ty)
+	}
+	return converted, nil
+}
+
+// convertLegacyType converts a type from the legacy format.
+func convertLegacyType(raw json.RawMessage) (any, error) {
+	var ty any
+	if err := json.Unmarshal(raw, &ty); err != nil {
+		return nil, fmt.Errorf("invalid type: %w", err)
+	}
+	return convertLegacyTypeValue(ty)
+}
+
+func convertLegacyTypeValue(ty any) (any, error) {
+	switch ty := ty.(type) {
+	case string:
+		if ty == "publicKey" {
+			return "pubkey", nil
+		}
+		return ty, nil
+	case map[string]any:
+		if len(ty) != 1 {
+			break
+		}
+		for key, value := range ty {
+			switch key {
+			case "defined":
+				if name, ok := value.(string); ok {
+					return map[string]any{"defined": map[string]any{"name": name}}, nil
+				}
+				return ty, nil
+			case "vec", "option", "coption":
+				inner, err := convertLegacyTypeValue(value)
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{key: inner}, nil
+			case "array":
+				array, ok := value.([]any)
+				if !ok || len(array) != 2 {
+					return nil, fmt.Errorf("invalid array type: %v", value)
+				}
+				inner, err := convertLegacyTypeValue(array[0])
+				if err != nil {
+					return nil, err
+				}
+				return map[string]any{"array": []any{inner, array[1]}}, nil
+			case "generic":
+				return ty, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown type: %v", ty)
+}
@@ -0,0 +1,261 @@
+package idl
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+const testLegacyIdl = `{
+  "version": "0.1.0",
+  "name": "legacy_demo",
+  "docs": ["A legacy program."],
+  "constants": [{"name": "SEED", "type": "bytes", "value": "[118, 97, 117, 108, 116]"}],
+  "instructions": [
+    {
+      "name": "initializeVault",
+      "docs": ["Initializes a vault."],
+      "accounts": [
+        {
+          "name": "vaultAccount",
+          "isMut": true,
+          "isSigner": false,
+          "pda": {
+            "seeds": [
+              {"kind": "const", "type": "string", "value": "vault"},
+              {"kind": "account", "type": "publicKey", "account": "Vault", "path": "authority.ownerKey"},
+              {"kind": "arg", "type": "u64", "path": "vaultId"},
+              {"kind": "const", "type": "u16", "value": 258},
+              {"kind": "const", "type": {"array": ["u8", 2]}, "value": [1, 2]}
+            ],
+            "programId": {"kind": "const", "type": "publicKey", "value": "11111111111111111111111111111111"}
+          }
+        },
+        {"name": "authority", "isMut": false, "isSigner": true},
+        {"name": "rent", "isMut": false, "isSigner": false, "isOptional": true},
+        {
+          "name": "common",
+          "accounts": [
+            {"name": "systemProgram", "isMut": false, "isSigner": false}
+          ]
+        }
+      ],
+      "args": [
+        {"name": "vaultId", "type": "u64"},
+        {"name": "newOwner", "type": {"option": "publicKey"}},
+        {"name": "shape", "type": {"defined": "Shape"}}
+      ],
+      "returns": {"vec": {"defined": "Shape"}}
+    }
+  ],
+  "accounts": [
+    {
+      "name": "Vault",
+      "type": {
+        "kind": "struct",
+        "fields": [
+          {"name": "authority", "type": "publicKey"},
+          {"name": "balances", "type": {"array": [{"defined": "Balance"}, 2]}}
+        ]
+      }
+    }
+  ],
+  "types": [
+    {"name": "Balance", "type": {"kind": "struct", "fields": [{"name": "amountLocked", "type": "u64"}]}},
+    {
+      "name": "Shape",
+      "type": {
+        "kind": "enum",
+        "variants": [
+          {"name": "Empty"},
+          {"name": "Circle", "fields": [{"name": "radius", "type": "u32"}]},
+          {"name": "Rect", "fields": ["u32", {"defined": "Balance"}]}
+        ]
+      }
+    },
+    {"name": "Amount", "type": {"kind": "alias", "value": "u64"}}
+  ],
+  "events": [
+    {"name": "Deposited", "fields": [{"name": "user", "type": "publicKey", "index": false}]}
+  ],
+  "errors": [{"code": 6000, "name": "Unauthorized", "msg": "Not allowed"}],
+  "metadata": {"address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"}
+}`
+
+func TestConvertLegacy(t *testing.T) {
+	require.True(t, IsOldIdl([]byte(testLegacyIdl)))
+
+	idl, err := Parse([]byte(testLegacyIdl))
+	require.NoError(t, err)
+	require.Nil(t, idl.Validate())
+
+	require.Equal(t, solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"), *idl.Address)
+	require.Equal(t, "legacy_demo", idl.Metadata.Name)
+	require.Equal(t, "0.1.0", idl.Metadata.Version)
+	require.Equal(t, []string{"A legacy program."}, idl.Docs)
+
+	t.Run("instructions", func(t *testing.T) {
+		require.Len(t, idl.Instructions, 1)
+		ix := idl.Instructions[0]
+		require.Equal(t, "initialize_vault", ix.Name)
+		computed := ix.ComputeDiscriminator()
+		require.Equal(t, IdlDiscriminator(computed[:]), ix.Discriminator)
+		require.Equal(t, []string{"Initializes a vault."}, ix.Docs)
+
+		require.Len(t, ix.Accounts, 4)
+		vault := ix.Accounts[0].(*IdlInstructionAccount)
+		require.Equal(t, "vault_account", vault.Name)
+		require.True(t, vault.Writable)
+		require.False(t, vault.Signer)
+		require.True(t, vault.Pda.IsSome())
+		pda := vault.Pda.Unwrap()
+		require.Equal(t, []IdlSeed{
+			&IdlSeedConst{Value: []byte("vault")},
+			&IdlSeedAccount{Path: "authority.owner_key", Account: Some("Vault")},
+			&IdlSeedArg{Path: "vault_id"},
+			&IdlSeedConst{Value: []byte{2, 1}},
+			&IdlSeedConst{Value: []byte{1, 2}},
+		}, pda.Seeds)
+		require.Equal(t, &IdlSeedConst{Value: make([]byte, 32)}, pda.Program.Unwrap())
+
+		authority := ix.Accounts[1].(*IdlInstructionAccount)
+		require.True(t, authority.Signer)
+		require.False(t, authority.Writable)
+		require.True(t, ix.Accounts[2].(*IdlInstructionAccount).Optional)
+
+		group := ix.Accounts[3].(*IdlInstructionAccounts)
+		require.Equal(t, "common", group.Name)
+		require.Equal(t, "system_program", group.Accounts[0].(*IdlInstructionAccount).Name)
+
+		require.Equal(t, []string{"vault_id", "new_owner", "shape"}, []string{ix.Args[0].Name, ix.Args[1].Name, ix.Args[2].Name})
+		require.Equal(t, &idltype.Option{Option: &idltype.Pubkey{}}, ix.Args[1].Ty)
+		require.Equal(t, &idltype.Defined{Name: "Shape"}, ix.Args[2].Ty)
+		require.Equal(t, &idltype.Vec{Vec: &idltype.Defined{Name: "Shape"}}, ix.Returns.Unwrap())
+	})
+
+	t.Run("accounts and events", func(t *testing.T) {
+		require.Equal(t, []IdlAccount{
+			{Name: "Vault", Discriminator: IdlDiscriminator{211, 8, 232, 43, 2, 152, 117, 119}},
+		}, idl.Accounts)
+		require.Len(t, idl.Events, 1)
+		require.Equal(t, "Deposited", idl.Events[0].Name)
+		require.Len(t, idl.Events[0].Discriminator, 8)
+	})
+
+	t.Run("types", func(t *testing.T) {
+		var names []string
+		for _, def := range idl.Types {
+			names = append(names, def.Name)
+		}
+		// The layouts of the accounts first, then the types, then the layouts of the events.
+		require.Equal(t, []string{"Vault", "Balance", "Shape", "Amount", "Deposited"}, names)
+
+		vault := idl.Types.ByName("Vault").Ty.(*IdlTypeDefTyStruct).Fields.(IdlDefinedFieldsNamed)
+		require.Equal(t, &idltype.Pubkey{}, vault[0].Ty)
+		require.Equal(t, &idltype.Array{Type: &idltype.Defined{Name: "Balance"}, Size: &idltype.IdlArrayLenValue{Value: 2}}, vault[1].Ty)
+
+		balance := idl.Types.ByName("Balance").Ty.(*IdlTypeDefTyStruct).Fields.(IdlDefinedFieldsNamed)
+		require.Equal(t, "amount_locked", balance[0].Name)
+
+		shape := idl.Types.ByName("Shape").Ty.(*IdlTypeDefTyEnum)
+		require.False(t, shape.Variants[0].Fields.IsSome())
+		require.Equal(t, "radius", shape.Variants[1].Fields.Unwrap().(IdlDefinedFieldsNamed)[0].Name)
+		require.Equal(t, IdlDefinedFieldsTuple{&idltype.U32{}, &idltype.Defined{Name: "Balance"}}, shape.Variants[2].Fields.Unwrap())
+
+		require.Equal(t, &idltype.U64{}, idl.Types.ByName("Amount").Ty.(*IdlTypeDefTyType).Alias)
+
+		deposited := idl.Types.ByName("Deposited").Ty.(*IdlTypeDefTyStruct).Fields.(IdlDefinedFieldsNamed)
+		require.Equal(t, "user", deposited[0].Name)
+	})
+
+	t.Run("errors and constants", func(t *testing.T) {
+		require.Equal(t, []IdlErrorCode{{Code: 6000, Name: "Unauthorized", Msg: Some("Not allowed")}}, idl.Errors)
+		require.Len(t, idl.Constants, 1)
+		require.Equal(t, "SEED", idl.Constants[0].Name)
+		require.Equal(t, &idltype.Bytes{}, idl.Constants[0].Ty)
+	})
+}
+
+// objectKeys returns the keys of a JSON object, in order.
+func objectKeys(t *testing.T, data []byte) []string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	_, err := decoder.Token()
+	require.NoError(t, err)
+	var keys []string
+	for decoder.More() {
+		key, err := decoder.Token()
+		require.NoError(t, err)
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		require.NoError(t, decoder.Decode(&value))
+	}
+	return keys
+}
+
+func TestConvertLegacyToJSON(t *testing.T) {
+	converted, err := ConvertLegacyToJSON([]byte(testLegacyIdl))
+	require.NoError(t, err)
+
+	// The fields are in the order of `anchor idl convert`, not alphabetical.
+	require.Equal(t, []string{"address", "metadata", "docs", "instructions", "accounts", "events", "errors", "types", "constants"}, objectKeys(t, converted))
+	var idl struct {
+		Metadata     json.RawMessage   `json:"metadata"`
+		Instructions []json.RawMessage `json:"instructions"`
+		Types        []json.RawMessage `json:"types"`
+	}
+	require.NoError(t, json.Unmarshal(converted, &idl))
+	require.Equal(t, []string{"name", "version", "spec"}, objectKeys(t, idl.Metadata))
+	require.Equal(t, []string{"name", "docs", "discriminator", "accounts", "args", "returns"}, objectKeys(t, idl.Instructions[0]))
+	require.Equal(t, []string{"name", "type"}, objectKeys(t, idl.Types[0]))
+}
+
+func TestConvertLegacyErrors(t *testing.T) {
+	for name, legacy := range map[string]string{
+		"unknown type":      `{"version": "0.1.0", "name": "x", "instructions": [{"name": "a", "accounts": [], "args": [{"name": "b", "type": "nope", "isMut": true}]}], "types": [{"name": "T", "type": {"kind": "struct", "fields": [{"name": "f", "type": {"tuple": []}}]}}]}`,
+		"unknown seed kind": `{"version": "0.1.0", "name": "x", "instructions": [{"name": "a", "accounts": [{"name": "b", "isMut": true, "isSigner": false, "pda": {"seeds": [{"kind": "magic"}]}}], "args": []}]}`,
+		"seed out of range": `{"version": "0.1.0", "name": "x", "instructions": [{"name": "a", "accounts": [{"name": "b", "isMut": true, "isSigner": false, "pda": {"seeds": [{"kind": "const", "type": "u8", "value": 256}]}}], "args": []}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(legacy))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestIsOldIdl(t *testing.T) {
+	// The words of the legacy format in the docs of a current IDL don't make it legacy.
+	current := `{
+  "address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS",
+  "metadata": {"name": "vaults", "version": "0.1.0", "spec": "0.1.0", "description": "Replaces isSigner with signer"},
+  "instructions": [
+    {
+      "name": "deposit",
+      "docs": ["The vault must be isMut, and the owner isSigner."],
+      "discriminator": [1, 2, 3, 4, 5, 6, 7, 8],
+      "accounts": [
+        {"name": "vault", "docs": ["isMut"], "writable": true},
+        {"name": "owner", "signer": true}
+      ],
+      "args": []
+    }
+  ]
+}`
+	require.False(t, IsOldIdl([]byte(current)))
+	idl, err := Parse([]byte(current))
+	require.NoError(t, err)
+	require.Equal(t, "vaults", idl.Metadata.Name)
+	require.NotNil(t, idl.Address)
+	require.Equal(t, IdlDiscriminator{1, 2, 3, 4, 5, 6, 7, 8}, idl.Instructions[0].Discriminator)
+	require.True(t, idl.Instructions[0].Accounts[0].(*IdlInstructionAccount).Writable)
+	require.True(t, idl.Instructions[0].Accounts[1].(*IdlInstructionAccount).Signer)
+
+	// Legacy IDLs have a top-level name and version, or isMut/isSigner accounts.
+	require.True(t, IsOldIdl([]byte(`{"version": "0.1.0", "name": "x", "instructions": []}`)))
+	require.True(t, IsOldIdl([]byte(`{"instructions": [{"name": "a", "accounts": [{"name": "b", "isMut": true, "isSigner": false}], "args": []}]}`)))
+	require.False(t, IsOldIdl([]byte(`{"metadata": {"spec": "0.1.0"}, "instructions": []}`)))
+	require.False(t, IsOldIdl([]byte(`not json isMut`)))
+}
@@ -0,0 +1,123 @@
+package idl
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// The IDL that `anchor idl init` publishes is stored, zlib-compressed, in an account
+// of the program at an address derived from the program ID (see OnChainIdlAddress),
+// after a header:
+//
+//	discriminator [8]byte // sighash("account", "IdlAccount")
+//	authority     solana.PublicKey
+//	dataLen       uint32 // the length of the compressed IDL
+//	data          [dataLen]byte
+
+// OnChainIdlSeed is the seed of the address of the IDL account, derived from the program ID.
+const OnChainIdlSeed = "anchor:idl"
+
+// MaxOnChainIdlSize is the maximum size of a decompressed on-chain IDL.
+const MaxOnChainIdlSize = 64 << 20
+
+var onChainIdlDiscriminator = bin.Sighash("account", "IdlAccount")[:8]
+
+// AccountInfoGetter reads accounts; *rpc.Client implements it.
+type AccountInfoGetter interface {
+	GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error)
+}
+
+// OnChainIdlAddress returns the address of the IDL account of a program:
+// createWithSeed(findProgramAddress([], programID), "anchor:idl", programID).
+func OnChainIdlAddress(programID solana.PublicKey) (solana.PublicKey, error) {
+	base, _, err := solana.FindProgramAddress([][]byte{}, programID)
+	if err != nil {
+		return solana.PublicKey{}, fmt.Errorf("failed to derive the base address of the IDL account: %w", err)
+	}
+	return solana.CreateWithSeed(base, OnChainIdlSeed, programID)
+}
+
+// OnChainIdl is the content of an IDL account.
+type OnChainIdl struct {
+	// Authority can update the IDL.
+	Authority solana.PublicKey
+	// Data is the decompressed IDL (JSON).
+	Data []byte
+}
+
+// ParseOnChainIdlAccount parses the data of an IDL account, and decompresses the IDL.
+func ParseOnChainIdlAccount(data []byte) (*OnChainIdl, error) {
+	const headerSize = 8 + solana.PublicKeyLength + 4
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("the IDL account is too short: %d bytes, expected at least %d", len(data), headerSize)
+	}
+	if !bytes.Equal(data[:8], onChainIdlDiscriminator) {
+		return nil, fmt.Errorf("invalid IDL account discriminator: %x, expected %x", data[:8], onChainIdlDiscriminator)
+	}
+	authority := solana.PublicKeyFromBytes(data[8 : 8+solana.PublicKeyLength])
+	dataLen := binary.LittleEndian.Uint32(data[8+solana.PublicKeyLength:])
+	compressed := data[headerSize:]
+	if uint64(dataLen) > uint64(len(compressed)) {
+		return nil, fmt.Errorf("the IDL account declares %d bytes of data, but %d bytes remain", dataLen, len(compressed))
+	}
+	reader, err := zlib.NewReader(bytes.NewReader(compressed[:dataLen]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the IDL: %w", err)
+	}
+	defer reader.Close()
+	decompressed, err := io.ReadAll(io.LimitReader(reader, MaxOnChainIdlSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress the IDL: %w", err)
+	}
+	if len(decompressed) > MaxOnChainIdlSize {
+		return nil, fmt.Errorf("the decompressed IDL exceeds %d bytes", MaxOnChainIdlSize)
+	}
+	return &OnChainIdl{
+		Authority: authority,
+		Data:      decompressed,
+	}, nil
+}
+
+// FetchOnChainIdl reads the IDL account of a program, and returns its content.
+func FetchOnChainIdl(ctx context.Context, client AccountInfoGetter, programID solana.PublicKey) (*OnChainIdl, error) {
+	address, err := OnChainIdlAddress(programID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := client.GetAccountInfo(ctx, address)
+	if err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return nil, fmt.Errorf("program %s has no IDL account at %s: %w", programID, address, err)
+		}
+		return nil, fmt.Errorf("failed to get the IDL account %s: %w", address, err)
+	}
+	if account == nil || account.Value == nil {
+		return nil, fmt.Errorf("program %s has no IDL account at %s: %w", programID, address, rpc.ErrNotFound)
+	}
+	if !account.Value.Owner.Equals(programID) {
+		return nil, fmt.Errorf("the IDL account %s is owned by %s, not by the program %s", address, account.Value.Owner, programID)
+	}
+	return ParseOnChainIdlAccount(account.GetBinary())
+}
+
+// FetchIdl reads the IDL account of a program, and parses the IDL (see Parse).
+func FetchIdl(ctx context.Context, client AccountInfoGetter, programID solana.PublicKey) (*Idl, error) {
+	onChainIdl, err := FetchOnChainIdl(ctx, client, programID)
+	if err != nil {
+		return nil, err
+	}
+	idl, err := Parse(onChainIdl.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the IDL of program %s: %w", programID, err)
+	}
+	return idl, nil
+}
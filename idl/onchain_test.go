@@ -0,0 +1,130 @@
+package idl
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// cannedAccounts is an AccountInfoGetter with canned accounts.
+type cannedAccounts map[solana.PublicKey]*rpc.Account
+
+func (c cannedAccounts) GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	value, ok := c[account]
+	if !ok {
+		return nil, rpc.ErrNotFound
+	}
+	return &rpc.GetAccountInfoResult{Value: value}, nil
+}
+
+func newOnChainIdlAccountData(t *testing.T, authority solana.PublicKey, idl []byte) []byte {
+	compressed := new(bytes.Buffer)
+	writer := zlib.NewWriter(compressed)
+	_, err := writer.Write(idl)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	data := append([]byte{}, onChainIdlDiscriminator...)
+	data = append(data, authority.Bytes()...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(compressed.Len()))
+	data = append(data, compressed.Bytes()...)
+	// IDL accounts are allocated with spare space.
+	return append(data, make([]byte, 100)...)
+}
+
+func TestOnChainIdlAddress(t *testing.T) {
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	address, err := OnChainIdlAddress(programID)
+	require.NoError(t, err)
+
+	base, _, err := solana.FindProgramAddress(nil, programID)
+	require.NoError(t, err)
+	// createWithSeed: sha256(base || seed || owner).
+	want := sha256.Sum256(append(append(base.Bytes(), "anchor:idl"...), programID.Bytes()...))
+	require.Equal(t, solana.PublicKeyFromBytes(want[:]), address)
+}
+
+func TestFetchIdl(t *testing.T) {
+	programID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	authority := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	address, err := OnChainIdlAddress(programID)
+	require.NoError(t, err)
+	idlJSON := []byte(`{
+  "address": "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS",
+  "metadata": {"name": "demo", "version": "0.1.0", "spec": "0.1.0"},
+  "instructions": [{"name": "ping", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [], "args": []}]
+}`)
+
+	t.Run("current format", func(t *testing.T) {
+		client := cannedAccounts{address: {
+			Owner: programID,
+			Data:  rpc.DataBytesOrJSONFromBytes(newOnChainIdlAccountData(t, authority, idlJSON)),
+		}}
+		onChainIdl, err := FetchOnChainIdl(context.Background(), client, programID)
+		require.NoError(t, err)
+		require.Equal(t, authority, onChainIdl.Authority)
+		require.Equal(t, idlJSON, onChainIdl.Data)
+
+		idl, err := FetchIdl(context.Background(), client, programID)
+		require.NoError(t, err)
+		require.Equal(t, "demo", idl.Metadata.Name)
+		require.Equal(t, "ping", idl.Instructions[0].Name)
+	})
+	t.Run("legacy format", func(t *testing.T) {
+		client := cannedAccounts{address: {
+			Owner: programID,
+			Data:  rpc.DataBytesOrJSONFromBytes(newOnChainIdlAccountData(t, authority, []byte(testLegacyIdl))),
+		}}
+		idl, err := FetchIdl(context.Background(), client, programID)
+		require.NoError(t, err)
+		require.Equal(t, "initialize_vault", idl.Instructions[0].Name)
+	})
+	t.Run("no IDL account", func(t *testing.T) {
+		_, err := FetchIdl(context.Background(), cannedAccounts{}, programID)
+		require.ErrorIs(t, err, rpc.ErrNotFound)
+	})
+	t.Run("not owned by the program", func(t *testing.T) {
+		client := cannedAccounts{address: {
+			Owner: authority,
+			Data:  rpc.DataBytesOrJSONFromBytes(newOnChainIdlAccountData(t, authority, idlJSON)),
+		}}
+		_, err := FetchIdl(context.Background(), client, programID)
+		require.Error(t, err)
+	})
+}
+
+func TestParseOnChainIdlAccount(t *testing.T) {
+	authority := solana.MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+	data := newOnChainIdlAccountData(t, authority, []byte(`{}`))
+
+	t.Run("too short", func(t *testing.T) {
+		_, err := ParseOnChainIdlAccount(data[:20])
+		require.Error(t, err)
+	})
+	t.Run("invalid discriminator", func(t *testing.T) {
+		invalid := append([]byte{}, data...)
+		invalid[0]++
+		_, err := ParseOnChainIdlAccount(invalid)
+		require.Error(t, err)
+	})
+	t.Run("data length exceeds the account", func(t *testing.T) {
+		invalid := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(invalid[40:], uint32(len(invalid)))
+		_, err := ParseOnChainIdlAccount(invalid)
+		require.Error(t, err)
+	})
+	t.Run("not compressed", func(t *testing.T) {
+		invalid := append([]byte{}, data[:44]...)
+		invalid = append(invalid, make([]byte, 100)...)
+		binary.LittleEndian.PutUint32(invalid[40:], 100)
+		_, err := ParseOnChainIdlAccount(invalid)
+		require.Error(t, err)
+	})
+}
@@ -1,7 +1,6 @@
 package idl
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -20,13 +19,14 @@ func ParseFromFilepath(path string) (*Idl, error) {
 	if err != nil {
 		return nil, err
 	}
-	if IsOldIdl(data) {
-		return nil, fmt.Errorf("the IDL at '%s' is an old IDL format, please use the anchor cli to convert the old idl to the new format with `anchor idl convert <PATH_TO_IDL_JSON>`, or use the latest version of the Anchor CLI to generate a new IDL", path)
-	}
 	return Parse(data)
 }
 
+// Parse parses an IDL; IDLs in the old format (see IsOldIdl) are converted with ConvertLegacy.
 func Parse(data []byte) (*Idl, error) {
+	if IsOldIdl(data) {
+		return ConvertLegacy(data)
+	}
 	var idl Idl
 	err := json.Unmarshal(data, &idl)
 	if err != nil {
@@ -84,10 +84,29 @@ func (slice IdTypeDef_slice) ByName(name string) *IdlTypeDef {
 	return nil
 }
 
+// IsOldIdl tells whether raw is an IDL in the format generated by anchor before v0.30.0,
+// judging from its structure: it has no metadata.spec, and it has a top-level name and version,
+// or instruction accounts with isMut/isSigner.
 func IsOldIdl(raw []byte) bool {
-	// if contains "isMut", "isSigner"
-	return bytes.Contains(raw, []byte("isMut")) ||
-		bytes.Contains(raw, []byte("isSigner")) || bytes.HasPrefix(raw, []byte("{\"version\":"))
+	if !gjson.ValidBytes(raw) {
+		return false
+	}
+	root := gjson.ParseBytes(raw)
+	if root.Get("metadata.spec").Exists() {
+		return false
+	}
+	if root.Get("name").Type == gjson.String && root.Get("version").Type == gjson.String {
+		return true
+	}
+	old := false
+	root.Get("instructions").ForEach(func(_, instruction gjson.Result) bool {
+		instruction.Get("accounts").ForEach(func(_, account gjson.Result) bool {
+			old = account.Get("isMut").Exists() || account.Get("isSigner").Exists()
+			return !old
+		})
+		return !old
+	})
+	return old
 }
 
 // Idl.UnmarshalJSON
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
 	"github.com/gagliardetto/anchor-go/internal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -110,3 +112,35 @@ func mustAnyToJSON(t require.TestingT, raw any) []byte {
 	}
 	return out
 }
+
+func TestIdlParseTypeAlias(t *testing.T) {
+	src := `{
+		"address": "11111111111111111111111111111111",
+		"metadata": {"name": "demo", "version": "0.1.0", "spec": "0.1.0"},
+		"instructions": [],
+		"types": [
+			{"name": "Amount", "docs": ["Amount in lamports."], "type": {"kind": "type", "alias": "u64"}},
+			{"name": "Keys", "type": {"kind": "type", "alias": {"vec": {"option": "pubkey"}}}},
+			{"name": "Wrapper", "type": {"kind": "struct", "fields": [{"name": "amount", "type": {"defined": {"name": "Amount"}}}]}}
+		]
+	}`
+	schema, err := Parse([]byte(src))
+	require.NoError(t, err)
+	require.Nil(t, schema.Validate())
+
+	amount, ok := schema.Types[0].Ty.(*IdlTypeDefTyType)
+	require.True(t, ok, "got %T", schema.Types[0].Ty)
+	assert.Equal(t, &idltype.U64{}, amount.Alias)
+
+	keys, ok := schema.Types[1].Ty.(*IdlTypeDefTyType)
+	require.True(t, ok, "got %T", schema.Types[1].Ty)
+	assert.Equal(t, &idltype.Vec{Vec: &idltype.Option{Option: &idltype.Pubkey{}}}, keys.Alias)
+
+	require.JSONEq(t,
+		`{"name": "Amount", "docs": ["Amount in lamports."], "type": {"kind": "type", "alias": "u64"}}`,
+		string(mustAnyToJSON(t, schema.Types[0])),
+	)
+
+	_, err = Parse([]byte(strings.Replace(src, `"alias": "u64"`, `"alias": "u1024"`, 1)))
+	require.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/gagliardetto/anchor-go/idl/idltype"
 	"github.com/gagliardetto/anchor-go/tools"
 )
 
@@ -154,6 +155,57 @@ func (et *IdlTypeDefTyEnum) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+type IdlTypeDefTyType struct {
+	//	    pub kind: String,
+	Kind string `json:"kind"`
+	//	    pub alias: IdlType,
+	Alias idltype.IdlType `json:"alias"`
+}
+
+func (IdlTypeDefTyType) _is_IdlTypeDefTy() {}
+
+func (tt IdlTypeDefTyType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string          `json:"kind"`
+		Alias idltype.IdlType `json:"alias"`
+	}{
+		Kind:  "type",
+		Alias: tt.Alias,
+	})
+}
+
+func (tt *IdlTypeDefTyType) UnmarshalJSON(data []byte) error {
+	err := tools.RequireFields(
+		data,
+		"kind",
+		"alias",
+	)
+	if err != nil {
+		return err
+	}
+	type Alias struct {
+		Kind  string          `json:"kind"`
+		Alias json.RawMessage `json:"alias"`
+	}
+	var alias Alias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	if alias.Kind != "type" {
+		return fmt.Errorf("expected kind 'type', got %s", alias.Kind)
+	}
+	tt.Kind = "type"
+	{
+		var ty idltype.IdlType
+		err := idltype.Into(&ty, alias.Alias)
+		if err != nil {
+			return err
+		}
+		tt.Alias = ty
+	}
+	return nil
+}
+
 func into_IdlTypeDefTy(
 	dst *IdlTypeDefTy,
 	data []byte,
@@ -163,6 +215,7 @@ func into_IdlTypeDefTy(
 		data,
 		tryUnmarshal_IdlTypeDefTy[*IdlTypeDefTyStruct],
 		tryUnmarshal_IdlTypeDefTy[*IdlTypeDefTyEnum],
+		tryUnmarshal_IdlTypeDefTy[*IdlTypeDefTyType],
 	)
 }
 
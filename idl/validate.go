@@ -1,6 +1,7 @@
 package idl
 
 import (
+	"encoding/json"
 	"fmt"
 	"slices"
 	"sort"
@@ -199,6 +200,31 @@ func (v *ValidationErrors) Error() string {
 	return result
 }
 
+// MarshalJSON encodes the validation errors as a JSON object (the other errors as strings).
+func (v *ValidationErrors) MarshalJSON() ([]byte, error) {
+	otherErrors := make([]string, 0, len(v.OtherErrors))
+	for _, err := range v.OtherErrors {
+		otherErrors = append(otherErrors, err.Error())
+	}
+	nonNil := func(s Strings) Strings {
+		if s == nil {
+			return Strings{}
+		}
+		return s
+	}
+	return json.Marshal(struct {
+		NotResolvedTypes          Strings  `json:"notResolvedTypes"`
+		InvalidTypeNames          Strings  `json:"invalidTypeNames"`
+		DuplicateDefinedTypeNames Strings  `json:"duplicateDefinedTypeNames"`
+		OtherErrors               []string `json:"otherErrors"`
+	}{
+		NotResolvedTypes:          nonNil(v.NotResolvedTypes),
+		InvalidTypeNames:          nonNil(v.InvalidTypeNames),
+		DuplicateDefinedTypeNames: nonNil(v.DuplicateDefinedTypeNames),
+		OtherErrors:               otherErrors,
+	})
+}
+
 func (v ValidationErrors) HasErrors() bool {
 	return !v.IsNil()
 }
@@ -470,6 +496,17 @@ func walk_IdlTypeDef(path pathElements, typ IdlTypeDef, callback IdlTypeCallback
 		if !more {
 			return false
 		}
+	case *IdlTypeDefTyType:
+		more := callback(
+			append(path,
+				"type",
+				"alias",
+			),
+			vv.Alias,
+		)
+		if !more {
+			return false
+		}
 	default:
 		panic(fmt.Errorf("unknown IDLTypeDef.Type: %T", typ.Ty))
 	}
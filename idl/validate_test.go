@@ -45,3 +45,16 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidationErrorsMarshalJSON(t *testing.T) {
+	errs := &ValidationErrors{}
+	errs.AddNotResolvedType("types[0].Missing")
+	errs.AddOtherError(fmt.Errorf("something is wrong"))
+
+	data, err := json.Marshal(errs)
+	require.NoError(t, err)
+	require.JSONEq(t,
+		`{"notResolvedTypes":["types[0].Missing"],"invalidTypeNames":[],"duplicateDefinedTypeNames":[],"otherErrors":["something is wrong"]}`,
+		string(data),
+	)
+}
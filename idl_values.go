@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+	"github.com/gagliardetto/anchor-go/limits"
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// The inspect and decode commands work on the types of an IDL without generated code:
+// idlTypes computes the encoded sizes of the types and decodes borsh values as JSON.
+
+// maxIdlValueDepth bounds the nesting of the decoded values (and of the types whose size is computed).
+const maxIdlValueDepth = 64
+
+type idlTypes struct {
+	defs map[string]*idl.IdlTypeDef
+}
+
+func newIdlTypes(idlObj *idl.Idl) *idlTypes {
+	types := &idlTypes{defs: make(map[string]*idl.IdlTypeDef, len(idlObj.Types))}
+	for i := range idlObj.Types {
+		types.defs[idlObj.Types[i].Name] = &idlObj.Types[i]
+	}
+	return types
+}
+
+// genericArgs are the arguments of the generic parameters of a defined type.
+type genericArgs struct {
+	types  map[string]idltype.IdlType
+	consts map[string]int
+}
+
+// def returns the definition of a defined type, and the arguments of its generic parameters.
+func (t *idlTypes) def(ty *idltype.Defined, args genericArgs) (*idl.IdlTypeDef, genericArgs, error) {
+	def, ok := t.defs[ty.Name]
+	if !ok {
+		return nil, genericArgs{}, fmt.Errorf("type %q is not defined in the IDL", ty.Name)
+	}
+	if len(def.Generics) != len(ty.Generics) {
+		return nil, genericArgs{}, fmt.Errorf("type %q has %d generic parameters, got %d", ty.Name, len(def.Generics), len(ty.Generics))
+	}
+	defArgs := genericArgs{types: map[string]idltype.IdlType{}, consts: map[string]int{}}
+	for i, param := range def.Generics {
+		switch param := param.(type) {
+		case *idl.IdlTypeDefGenericType:
+			arg, ok := ty.Generics[i].(*idltype.IdlGenericArgType)
+			if !ok {
+				return nil, genericArgs{}, fmt.Errorf("generic parameter %s of %q: expected a type", param.Name, ty.Name)
+			}
+			// The argument can itself refer to the generic parameters of the enclosing type.
+			defArgs.types[param.Name] = t.resolve(arg.Ty, args)
+		case *idl.IdlTypeDefGenericConst:
+			arg, ok := ty.Generics[i].(*idltype.IdlGenericArgConst)
+			if !ok {
+				return nil, genericArgs{}, fmt.Errorf("generic parameter %s of %q: expected a constant", param.Name, ty.Name)
+			}
+			value, err := strconv.Atoi(arg.Value)
+			if err != nil {
+				if outer, ok := args.consts[arg.Value]; ok {
+					value = outer
+				} else {
+					return nil, genericArgs{}, fmt.Errorf("generic parameter %s of %q: invalid constant %q", param.Name, ty.Name, arg.Value)
+				}
+			}
+			defArgs.consts[param.Name] = value
+		}
+	}
+	return def, defArgs, nil
+}
+
+// resolve replaces the generic parameters in ty with their arguments.
+func (t *idlTypes) resolve(ty idltype.IdlType, args genericArgs) idltype.IdlType {
+	if generic, ok := ty.(*idltype.Generic); ok {
+		if arg, ok := args.types[generic.Generic]; ok {
+			return arg
+		}
+	}
+	return ty
+}
+
+// arrayLen returns the length of an array type.
+func arrayLen(ty *idltype.Array, args genericArgs) (int, error) {
+	switch size := ty.Size.(type) {
+	case *idltype.IdlArrayLenValue:
+		return size.Value, nil
+	case *idltype.IdlArrayLenGeneric:
+		if value, ok := args.consts[size.Generic]; ok {
+			return value, nil
+		}
+		return 0, fmt.Errorf("unknown array length %q", size.Generic)
+	}
+	return 0, fmt.Errorf("unknown array length %T", ty.Size)
+}
+
+// encodedSize returns the minimum size of the borsh encoding of a value of type ty,
+// and whether all the values of the type have that size.
+func (t *idlTypes) encodedSize(ty idltype.IdlType, args genericArgs, depth int) (size int, fixed bool, err error) {
+	if depth > maxIdlValueDepth {
+		return 0, false, fmt.Errorf("type nested too deeply")
+	}
+	switch ty := t.resolve(ty, args).(type) {
+	case *idltype.Bool, *idltype.U8, *idltype.I8:
+		return 1, true, nil
+	case *idltype.U16, *idltype.I16:
+		return 2, true, nil
+	case *idltype.U32, *idltype.I32, *idltype.F32:
+		return 4, true, nil
+	case *idltype.U64, *idltype.I64, *idltype.F64:
+		return 8, true, nil
+	case *idltype.U128, *idltype.I128:
+		return 16, true, nil
+	case *idltype.U256, *idltype.I256, *idltype.Pubkey:
+		return 32, true, nil
+	case *idltype.String, *idltype.Bytes, *idltype.Vec:
+		return 4, false, nil
+	case *idltype.Option:
+		return 1, false, nil
+	case *idltype.COption:
+		return 4, false, nil
+	case *idltype.Array:
+		length, err := arrayLen(ty, args)
+		if err != nil {
+			return 0, false, err
+		}
+		itemSize, fixed, err := t.encodedSize(ty.Type, args, depth+1)
+		return length * itemSize, fixed, err
+	case *idltype.Defined:
+		def, defArgs, err := t.def(ty, args)
+		if err != nil {
+			return 0, false, err
+		}
+		switch defTy := def.Ty.(type) {
+		case *idl.IdlTypeDefTyStruct:
+			return t.fieldsEncodedSize(defTy.Fields, defArgs, depth)
+		case *idl.IdlTypeDefTyType:
+			return t.encodedSize(defTy.Alias, defArgs, depth+1)
+		case *idl.IdlTypeDefTyEnum:
+			// The variant index, followed by the fields of the variant.
+			minSize, fixed := math.MaxInt, true
+			for i, variant := range defTy.Variants {
+				size, variantFixed := 0, true
+				if variant.Fields.IsSome() {
+					if size, variantFixed, err = t.fieldsEncodedSize(variant.Fields.Unwrap(), defArgs, depth); err != nil {
+						return 0, false, err
+					}
+				}
+				fixed = fixed && variantFixed && (i == 0 || size == minSize)
+				minSize = min(minSize, size)
+			}
+			if len(defTy.Variants) == 0 {
+				minSize = 0
+			}
+			return 1 + minSize, fixed, nil
+		}
+		return 0, false, fmt.Errorf("unknown kind of type %q", def.Name)
+	}
+	return 0, false, fmt.Errorf("unsupported type %T", ty)
+}
+
+func (t *idlTypes) fieldsEncodedSize(fields idl.IdlDefinedFields, args genericArgs, depth int) (size int, fixed bool, err error) {
+	var types []idltype.IdlType
+	switch fields := fields.(type) {
+	case idl.IdlDefinedFieldsNamed:
+		for _, field := range fields {
+			types = append(types, field.Ty)
+		}
+	case idl.IdlDefinedFieldsTuple:
+		types = fields
+	}
+	fixed = true
+	for _, ty := range types {
+		fieldSize, fieldFixed, err := t.encodedSize(ty, args, depth+1)
+		if err != nil {
+			return 0, false, err
+		}
+		size += fieldSize
+		fixed = fixed && fieldFixed
+	}
+	return size, fixed, nil
+}
+
+// jsonObject is a JSON object whose keys keep the order of the fields.
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value any
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeFields decodes borsh-encoded fields as a JSON object, like the generated MarshalJSON methods:
+// tuple fields are named v0, v1, ...
+func (t *idlTypes) decodeFields(decoder *bin.Decoder, fields idl.IdlDefinedFields, args genericArgs, depth int) (any, error) {
+	var named idl.IdlDefinedFieldsNamed
+	switch fields := fields.(type) {
+	case idl.IdlDefinedFieldsNamed:
+		named = fields
+	case idl.IdlDefinedFieldsTuple:
+		for i, ty := range fields {
+			named = append(named, idl.IdlField{Name: fmt.Sprintf("v%d", i), Ty: ty})
+		}
+	}
+	object := make(jsonObject, 0, len(named))
+	for _, field := range named {
+		value, err := t.decode(decoder, field.Ty, args, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.Name, err)
+		}
+		object = append(object, jsonField{key: field.Name, value: value})
+	}
+	return object, nil
+}
+
+// decode decodes a borsh-encoded value of type ty as a JSON value: 128-bit and 256-bit
+// integers are decimal strings, bytes are base64 strings, public keys are base58 strings,
+// unit enum variants are their name, and the other variants are objects with one key.
+func (t *idlTypes) decode(decoder *bin.Decoder, ty idltype.IdlType, args genericArgs, depth int) (any, error) {
+	if depth > maxIdlValueDepth {
+		return nil, fmt.Errorf("value nested too deeply")
+	}
+	switch ty := t.resolve(ty, args).(type) {
+	case *idltype.Bool:
+		return decoder.ReadBool()
+	case *idltype.U8:
+		return decoder.ReadUint8()
+	case *idltype.I8:
+		return decoder.ReadInt8()
+	case *idltype.U16:
+		return decoder.ReadUint16(bin.LE)
+	case *idltype.I16:
+		return decoder.ReadInt16(bin.LE)
+	case *idltype.U32:
+		return decoder.ReadUint32(bin.LE)
+	case *idltype.I32:
+		return decoder.ReadInt32(bin.LE)
+	case *idltype.U64:
+		return decoder.ReadUint64(bin.LE)
+	case *idltype.I64:
+		return decoder.ReadInt64(bin.LE)
+	case *idltype.F32:
+		value, err := decoder.ReadFloat32(bin.LE)
+		if err != nil {
+			return nil, err
+		}
+		return jsonFloat(float64(value)), nil
+	case *idltype.F64:
+		value, err := decoder.ReadFloat64(bin.LE)
+		if err != nil {
+			return nil, err
+		}
+		return jsonFloat(value), nil
+	case *idltype.U128, *idltype.I128, *idltype.U256, *idltype.I256:
+		size, signed := 16, false
+		switch ty.(type) {
+		case *idltype.I128:
+			signed = true
+		case *idltype.U256:
+			size = 32
+		case *idltype.I256:
+			size, signed = 32, true
+		}
+		buf, err := decoder.ReadNBytes(size)
+		if err != nil {
+			return nil, err
+		}
+		return littleEndianInteger(buf, signed).String(), nil
+	case *idltype.String:
+		buf, err := t.readBytes(decoder)
+		return string(buf), err
+	case *idltype.Bytes:
+		return t.readBytes(decoder)
+	case *idltype.Pubkey:
+		buf, err := decoder.ReadNBytes(solana.PublicKeyLength)
+		if err != nil {
+			return nil, err
+		}
+		return solana.PublicKeyFromBytes(buf), nil
+	case *idltype.Option, *idltype.COption:
+		var some bool
+		var err error
+		var inner idltype.IdlType
+		if option, ok := ty.(*idltype.Option); ok {
+			some, err = decoder.ReadOption()
+			inner = option.Option
+		} else {
+			some, err = decoder.ReadCOption()
+			inner = ty.(*idltype.COption).COption
+		}
+		if err != nil || !some {
+			return nil, err
+		}
+		return t.decode(decoder, inner, args, depth+1)
+	case *idltype.Vec:
+		if _, ok := t.resolve(ty.Vec, args).(*idltype.U8); ok {
+			// Like the generated []uint8 fields.
+			return t.readBytes(decoder)
+		}
+		length, err := decoder.ReadUint32(bin.LE)
+		if err != nil {
+			return nil, err
+		}
+		minItemSize, _, err := t.encodedSize(ty.Vec, args, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if err := limits.CheckInput(decoder, int(length), minItemSize); err != nil {
+			return nil, err
+		}
+		return t.decodeItems(decoder, ty.Vec, int(length), args, depth)
+	case *idltype.Array:
+		length, err := arrayLen(ty, args)
+		if err != nil {
+			return nil, err
+		}
+		return t.decodeItems(decoder, ty.Type, length, args, depth)
+	case *idltype.Defined:
+		def, defArgs, err := t.def(ty, args)
+		if err != nil {
+			return nil, err
+		}
+		switch defTy := def.Ty.(type) {
+		case *idl.IdlTypeDefTyStruct:
+			return t.decodeFields(decoder, defTy.Fields, defArgs, depth)
+		case *idl.IdlTypeDefTyType:
+			return t.decode(decoder, defTy.Alias, defArgs, depth+1)
+		case *idl.IdlTypeDefTyEnum:
+			index, err := decoder.ReadUint8()
+			if err != nil {
+				return nil, err
+			}
+			if int(index) >= len(defTy.Variants) {
+				return nil, fmt.Errorf("invalid variant index %d of %s", index, def.Name)
+			}
+			variant := defTy.Variants[index]
+			if !variant.Fields.IsSome() {
+				return variant.Name, nil
+			}
+			value, err := t.decodeFields(decoder, variant.Fields.Unwrap(), defArgs, depth)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", variant.Name, err)
+			}
+			return jsonObject{{key: variant.Name, value: value}}, nil
+		}
+		return nil, fmt.Errorf("unknown kind of type %q", def.Name)
+	}
+	return nil, fmt.Errorf("unsupported type %T", ty)
+}
+
+func (t *idlTypes) decodeItems(decoder *bin.Decoder, itemType idltype.IdlType, length int, args genericArgs, depth int) ([]any, error) {
+	// Zero-size items take no input, so their declared length doesn't bound the capacity.
+	items := make([]any, 0, min(length, decoder.Remaining()))
+	for i := range length {
+		item, err := t.decode(decoder, itemType, args, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (t *idlTypes) readBytes(decoder *bin.Decoder) ([]byte, error) {
+	length, err := decoder.ReadUint32(bin.LE)
+	if err != nil {
+		return nil, err
+	}
+	if err := limits.CheckInput(decoder, int(length), 1); err != nil {
+		return nil, err
+	}
+	return decoder.ReadNBytes(int(length))
+}
+
+// littleEndianInteger converts a little-endian (two's complement if signed) integer.
+func littleEndianInteger(buf []byte, signed bool) *big.Int {
+	bigEndian := make([]byte, len(buf))
+	for i, b := range buf {
+		bigEndian[len(buf)-1-i] = b
+	}
+	n := new(big.Int).SetBytes(bigEndian)
+	if signed && len(buf) > 0 && buf[len(buf)-1]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(buf)*8)))
+	}
+	return n
+}
+
+// jsonFloat returns the JSON value of a float: NaN and infinities aren't numbers in JSON.
+func jsonFloat(value float64) any {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return value
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/gagliardetto/anchor-go/idl"
+	"github.com/gagliardetto/anchor-go/idl/idltype"
+)
+
+type inspectReport struct {
+	Name         string               `json:"name"`
+	Version      string               `json:"version"`
+	Address      string               `json:"address,omitempty"`
+	Instructions []inspectInstruction `json:"instructions"`
+	Accounts     []inspectLayout      `json:"accounts"`
+	Events       []inspectLayout      `json:"events"`
+}
+
+type inspectInstruction struct {
+	Name          string `json:"name"`
+	Discriminator string `json:"discriminator"`
+	Args          int    `json:"args"`
+	Accounts      int    `json:"accounts"`
+}
+
+// inspectLayout is an account or event: its size includes the discriminator,
+// and is the minimum size if the layout has variable-size fields.
+type inspectLayout struct {
+	Name          string `json:"name"`
+	Discriminator string `json:"discriminator"`
+	Size          int    `json:"size"`
+	FixedSize     bool   `json:"fixedSize"`
+	Error         string `json:"error,omitempty"`
+}
+
+func cmdInspect(args []string) error {
+	fs := newFlagSet("inspect")
+	var idlFlags idlFlags
+	idlFlags.register(fs)
+	var format formatFlag
+	format.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	parsedIdl, err := idlFlags.load()
+	if err != nil {
+		return err
+	}
+	report := inspect(parsedIdl)
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Program:\t%s\n", report.Name)
+	fmt.Fprintf(w, "Version:\t%s\n", report.Version)
+	if report.Address != "" {
+		fmt.Fprintf(w, "Address:\t%s\n", report.Address)
+	}
+	fmt.Fprintf(w, "\nINSTRUCTION\tDISCRIMINATOR\tARGS\tACCOUNTS\n")
+	for _, ix := range report.Instructions {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", ix.Name, ix.Discriminator, ix.Args, ix.Accounts)
+	}
+	for _, section := range []struct {
+		title   string
+		layouts []inspectLayout
+	}{
+		{"ACCOUNT", report.Accounts},
+		{"EVENT", report.Events},
+	} {
+		fmt.Fprintf(w, "\n%s\tDISCRIMINATOR\tSIZE\n", section.title)
+		for _, layout := range section.layouts {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", layout.Name, layout.Discriminator, layout.formatSize())
+		}
+	}
+	return w.Flush()
+}
+
+func (l inspectLayout) formatSize() string {
+	switch {
+	case l.Error != "":
+		return "? (" + l.Error + ")"
+	case l.FixedSize:
+		return fmt.Sprint(l.Size)
+	}
+	return fmt.Sprintf(">= %d", l.Size)
+}
+
+func inspect(idlObj *idl.Idl) *inspectReport {
+	report := &inspectReport{
+		Name:         idlObj.Metadata.Name,
+		Version:      idlObj.Metadata.Version,
+		Instructions: []inspectInstruction{},
+		Accounts:     []inspectLayout{},
+		Events:       []inspectLayout{},
+	}
+	if idlObj.Address != nil && !idlObj.Address.IsZero() {
+		report.Address = idlObj.Address.String()
+	}
+	for _, ix := range idlObj.Instructions {
+		report.Instructions = append(report.Instructions, inspectInstruction{
+			Name:          ix.Name,
+			Discriminator: hex.EncodeToString(ix.Discriminator),
+			Args:          len(ix.Args),
+			Accounts:      countInstructionAccounts(ix.Accounts),
+		})
+	}
+	types := newIdlTypes(idlObj)
+	layout := func(name string, discriminator idl.IdlDiscriminator) inspectLayout {
+		layout := inspectLayout{
+			Name:          name,
+			Discriminator: hex.EncodeToString(discriminator),
+		}
+		// The layout of an account or event is the type of the same name.
+		size, fixed, err := types.encodedSize(&idltype.Defined{Name: name}, genericArgs{}, 0)
+		if err != nil {
+			layout.Error = err.Error()
+			return layout
+		}
+		layout.Size, layout.FixedSize = len(discriminator)+size, fixed
+		return layout
+	}
+	for _, account := range idlObj.Accounts {
+		report.Accounts = append(report.Accounts, layout(account.Name, account.Discriminator))
+	}
+	for _, event := range idlObj.Events {
+		report.Events = append(report.Events, layout(event.Name, event.Discriminator))
+	}
+	return report
+}
+
+// countInstructionAccounts counts the accounts of an instruction, including those of the nested groups.
+func countInstructionAccounts(accounts []idl.IdlInstructionAccountItem) int {
+	count := 0
+	for _, account := range accounts {
+		switch account := account.(type) {
+		case *idl.IdlInstructionAccount:
+			count++
+		case *idl.IdlInstructionAccounts:
+			count += countInstructionAccounts(account.Accounts)
+		}
+	}
+	return count
+}
@@ -0,0 +1,98 @@
+// Package limits bounds the memory that the generated decoders allocate while
+// decoding untrusted data (e.g. a corrupted or malicious account declaring a
+// vector of 2^31 items).
+//
+// The declared length of every vector, string and byte slice is always checked
+// against the bytes remaining in the decoder; the Limits of a decode (e.g. the
+// decode.Options of the account parsers) can further cap the length of the
+// collections and the total allocation of the decode.
+package limits
+
+import (
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+var (
+	// ErrLengthExceedsInput is returned when a declared length can't fit in the remaining bytes.
+	ErrLengthExceedsInput = errors.New("declared length exceeds the remaining input")
+	// ErrLimitExceeded is returned when a declared length exceeds the configured Limits.
+	ErrLimitExceeded = errors.New("decode limit exceeded")
+)
+
+// Limits bounds the allocations of a decode; zero values mean "no limit".
+type Limits struct {
+	// MaxCollectionLength is the maximum number of items of a decoded vector,
+	// and the maximum length of a decoded string or byte slice.
+	MaxCollectionLength int
+	// MaxAllocation is the maximum number of bytes allocated for the vectors,
+	// strings and byte slices of a decode.
+	MaxAllocation int
+}
+
+// Budget accounts the allocations of a single decode against its Limits.
+// A nil *Budget has no limits: it only checks the declared lengths against the input.
+type Budget struct {
+	limits    Limits
+	allocated int
+}
+
+// NewBudget returns the budget of a decode with the given limits.
+func NewBudget(limits Limits) *Budget {
+	return &Budget{limits: limits}
+}
+
+// CheckInput checks that length items, of at least minItemSize bytes each, fit in
+// the bytes remaining in decoder. Zero-size items take no input, and always fit.
+func CheckInput(decoder *bin.Decoder, length int, minItemSize int) error {
+	if minItemSize <= 0 {
+		return nil
+	}
+	if remaining := decoder.Remaining(); length > remaining/minItemSize {
+		return fmt.Errorf("%w: %d items of at least %d bytes, but %d bytes remain", ErrLengthExceedsInput, length, minItemSize, remaining)
+	}
+	return nil
+}
+
+// CheckLength checks the declared length of a vector, before it's allocated:
+// each item takes at least minItemSize bytes of the input, and itemSize bytes of memory.
+func (b *Budget) CheckLength(decoder *bin.Decoder, length int, minItemSize int, itemSize int) error {
+	if err := CheckInput(decoder, length, minItemSize); err != nil {
+		return err
+	}
+	if b == nil {
+		return nil
+	}
+	if b.limits.MaxCollectionLength > 0 && length > b.limits.MaxCollectionLength {
+		return fmt.Errorf("%w: %d items, but the maximum collection length is %d", ErrLimitExceeded, length, b.limits.MaxCollectionLength)
+	}
+	if b.limits.MaxAllocation > 0 {
+		b.allocated += length * itemSize
+		if b.allocated > b.limits.MaxAllocation {
+			return fmt.Errorf("%w: %d bytes allocated, but the maximum allocation is %d", ErrLimitExceeded, b.allocated, b.limits.MaxAllocation)
+		}
+	}
+	return nil
+}
+
+// ReadByteSlice reads a length-prefixed byte slice, like decoder.ReadByteSlice,
+// after checking its declared length.
+func (b *Budget) ReadByteSlice(decoder *bin.Decoder) ([]byte, error) {
+	length, err := decoder.ReadLength()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.CheckLength(decoder, length, 1, 1); err != nil {
+		return nil, err
+	}
+	return decoder.ReadNBytes(length)
+}
+
+// ReadString reads a length-prefixed string, like decoder.ReadString,
+// after checking its declared length.
+func (b *Budget) ReadString(decoder *bin.Decoder) (string, error) {
+	data, err := b.ReadByteSlice(decoder)
+	return string(data), err
+}
@@ -0,0 +1,55 @@
+package limits
+
+import (
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLength(t *testing.T) {
+	decoder := bin.NewBorshDecoder(make([]byte, 100))
+
+	// Without limits, only the input bounds the lengths:
+	var unlimited *Budget
+	require.NoError(t, unlimited.CheckLength(decoder, 100, 1, 8))
+	require.NoError(t, unlimited.CheckLength(decoder, 25, 4, 8))
+	require.ErrorIs(t, unlimited.CheckLength(decoder, 26, 4, 8), ErrLengthExceedsInput)
+	require.ErrorIs(t, unlimited.CheckLength(decoder, 1<<31-1, 1, 8), ErrLengthExceedsInput)
+	// Zero-size items take no input:
+	require.NoError(t, unlimited.CheckLength(decoder, 1000, 0, 0))
+
+	budget := NewBudget(Limits{MaxCollectionLength: 10})
+	require.NoError(t, budget.CheckLength(decoder, 10, 1, 8))
+	require.ErrorIs(t, budget.CheckLength(decoder, 11, 1, 8), ErrLimitExceeded)
+	require.ErrorIs(t, budget.CheckLength(decoder, 11, 0, 0), ErrLimitExceeded)
+	require.ErrorIs(t, budget.CheckLength(decoder, 101, 1, 8), ErrLengthExceedsInput)
+
+	// The allocation limit caps the whole decode:
+	budget = NewBudget(Limits{MaxAllocation: 80})
+	require.NoError(t, budget.CheckLength(decoder, 5, 1, 8))
+	require.NoError(t, budget.CheckLength(decoder, 5, 1, 8))
+	require.ErrorIs(t, budget.CheckLength(decoder, 1, 1, 8), ErrLimitExceeded)
+	// Each decode has its own budget:
+	require.NoError(t, NewBudget(Limits{MaxAllocation: 80}).CheckLength(decoder, 10, 1, 8))
+}
+
+func TestReadByteSlice(t *testing.T) {
+	data := []byte{5, 0, 0, 0, 'h', 'e', 'l', 'l', 'o'}
+
+	var unlimited *Budget
+	str, err := unlimited.ReadString(bin.NewBorshDecoder(data))
+	require.NoError(t, err)
+	require.Equal(t, "hello", str)
+
+	buf, err := NewBudget(Limits{MaxCollectionLength: 5, MaxAllocation: 5}).ReadByteSlice(bin.NewBorshDecoder(data))
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), buf)
+
+	_, err = NewBudget(Limits{MaxCollectionLength: 4}).ReadString(bin.NewBorshDecoder(data))
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	_, err = NewBudget(Limits{MaxAllocation: 4}).ReadByteSlice(bin.NewBorshDecoder(data))
+	require.ErrorIs(t, err, ErrLimitExceeded)
+	_, err = unlimited.ReadString(bin.NewBorshDecoder([]byte{6, 0, 0, 0, 'h', 'e', 'l', 'l', 'o'}))
+	require.ErrorIs(t, err, ErrLengthExceedsInput)
+}
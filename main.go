@@ -1,216 +1,175 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
-	"go/token"
-	"log/slog"
 	"os"
 	"os/exec"
-	"path"
+	"strings"
 
-	"github.com/gagliardetto/anchor-go/generator"
 	"github.com/gagliardetto/anchor-go/idl"
-	"github.com/gagliardetto/anchor-go/tools"
-	bin "github.com/gagliardetto/binary"
-	"github.com/gagliardetto/solana-go"
 )
 
 const defaultProgramName = "myprogram"
 
+// command is a subcommand of the CLI.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"generate", "Generate the Go client of a program from its IDL", cmdGenerate},
+	{"validate", "Validate an IDL", cmdValidate},
+	{"inspect", "List the instructions, accounts and events of an IDL", cmdInspect},
+	{"decode", "Decode the data of an account, event or instruction as JSON", cmdDecode},
+	{"convert", "Convert a legacy (pre-0.30) IDL to the current format", cmdConvert},
+	{"fetch-idl", "Fetch the IDL of a program from its on-chain IDL account", cmdFetchIdl},
+}
+
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
 	if askingForVersion() {
 		printVersion()
-		return
+		return 0
 	}
-	if !hasCommand("go") {
-		panic("Please install Go (https://go.dev/doc/install) and ensure it is in your PATH.\n" +
-			"Go is required to format the generated code, and tidy up the go.mod/go.sum files correctly.\n")
+	// Without a command (e.g. `anchor-go -idl idl.json -output ./generated`), generate.
+	name := "generate"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-	var outputDir string
-	var programName string
-	var modPath string
-	var pathToIdl string
-	var programIDOverride solana.PublicKey
-	flag.Var(&programIDOverride, "program-id", "Program ID to use in the generated code (optional; must be a valid Solana public key). If not provided, it will be derived from the IDL metadata.address field if available.")
-	flag.StringVar(&outputDir, "output", "", "Directory to write the generated code to")
-	flag.StringVar(&programName, "name", defaultProgramName, "Name of the program for the generated code (optional; example: myprogram). If not provided, it will be derived from the IDL metadata.name field.")
-	flag.StringVar(&modPath, "mod-path", "", "Module path for the generated code (optional; example: github.com/gagliardetto/mysolana-program-go)")
-	flag.StringVar(&pathToIdl, "idl", "", "Path to the IDL file (required)")
-	var skipGoMod bool
-	flag.BoolVar(&skipGoMod, "no-go-mod", false, "Skip generating the go.mod file (useful for testing)")
-	flag.Parse()
-	if pathToIdl == "" {
-		panic("Please provide the path to the IDL file using the -idl flag")
+	if name == "help" {
+		printUsage()
+		return 0
 	}
-	if outputDir == "" {
-		panic("Please provide the output directory using the -output flag")
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		err := cmd.run(args)
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		case errors.Is(err, errInvalidIdl):
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
 	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q.\n\n", name)
+	printUsage()
+	return 2
+}
 
-	if modPath == "" {
-		modPath = path.Join("github.com", "gagliardetto", "anchor-go", "generated")
-		slog.Info("Using default module path", "modPath", modPath)
-	} else {
-		slog.Info("Using provided module path", "modPath", modPath)
-	}
-	if err := os.MkdirAll(outputDir, 0o777); err != nil {
-		panic(fmt.Errorf("Failed to create output directory: %w", err))
-	}
-	slog.Info("Starting code generation",
-		"outputDir", outputDir,
-		"modPath", modPath,
-		"pathToIdl", pathToIdl,
-		"programID", func() string {
-			if programIDOverride.IsZero() {
-				return "not provided"
-			}
-			return programIDOverride.String()
-		}(),
-	)
-
-	options := generator.GeneratorOptions{
-		OutputDir:   outputDir,
-		Package:     programName,
-		ProgramName: programName,
-		ModPath:     modPath,
-		SkipGoMod:   skipGoMod,
-	}
-	if !programIDOverride.IsZero() {
-		options.ProgramId = &programIDOverride
-		slog.Info("Using provided program ID", "programID", programIDOverride.String())
-	}
-	parsedIdl, err := idl.ParseFromFilepath(pathToIdl)
-	if err != nil {
-		panic(err)
-	}
-	if parsedIdl == nil {
-		panic("Parsed IDL is nil, please check the IDL file path and format.")
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: anchor-go <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run `anchor-go <command> -h` for the flags of a command.")
+}
+
+// errUsage is returned for invalid flags (after the flag set printed the usage).
+var errUsage = errors.New("invalid usage")
+
+// newFlagSet returns the flag set of a command.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("anchor-go "+name, flag.ContinueOnError)
+}
+
+// parseFlags parses the flags of a command; positional arguments are not accepted.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
 	}
-	if err := parsedIdl.Validate(); err != nil {
-		panic(fmt.Errorf("Invalid IDL: %w", err))
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		fs.Usage()
+		return errUsage
 	}
-	{
-		{
-			if parsedIdl.Address != nil && !parsedIdl.Address.IsZero() && options.ProgramId == nil {
-				// If the IDL has an address, use it as the program ID:
-				slog.Info("Using IDL address as program ID", "address", parsedIdl.Address.String())
-				options.ProgramId = parsedIdl.Address
-			}
-		}
-		parsedIdl.Metadata.Name = bin.ToSnakeForSighash(parsedIdl.Metadata.Name)
-		{
-			// check that the name is not a reserved keyword:
-			if parsedIdl.Metadata.Name != "" {
-				if tools.IsReservedKeyword(parsedIdl.Metadata.Name) {
-					slog.Warn("The IDL metadata.name is a reserved Go keyword: adding a suffix to avoid conflicts.",
-						"name", parsedIdl.Metadata.Name,
-						"reservedKeyword", token.Lookup(parsedIdl.Metadata.Name).String(),
-					)
-					// Add a suffix to the name to avoid conflicts with Go reserved keywords:
-					parsedIdl.Metadata.Name += "_program"
-				}
-				if !tools.IsValidIdent(parsedIdl.Metadata.Name) {
-					// add a prefix to the name to avoid conflicts with Go reserved keywords:
-					parsedIdl.Metadata.Name = "my_" + parsedIdl.Metadata.Name
-				}
-			}
-			// if begins with
-		}
-		if programName == "" && parsedIdl.Metadata.Name != "" {
-			panic("Please provide a package name using the -name flag, or ensure the IDL has a valid metadata.name field.")
-		}
-		if programName == defaultProgramName && parsedIdl.Metadata.Name != "" {
-			cleanedName := bin.ToSnakeForSighash(parsedIdl.Metadata.Name)
-			options.Package = cleanedName
-			options.ProgramName = cleanedName
-			slog.Info("Using IDL metadata.name as package name", "packageName", cleanedName)
-		}
+	return nil
+}
+
+// idlFlags are the flags that select the IDL, shared by all the commands.
+type idlFlags struct {
+	path string
+}
 
-		slog.Info("Parsed IDL successfully",
-			"version", parsedIdl.Metadata.Version,
-			"name", parsedIdl.Metadata.Name,
-			"address", parsedIdl.Address,
-			"programId", func() string {
-				if parsedIdl.Address.IsZero() {
-					return "not provided"
-				}
-				return parsedIdl.Address.String()
-			}(),
-			"instructionsCount", len(parsedIdl.Instructions),
-			"accountsCount", len(parsedIdl.Accounts),
-			"eventsCount", len(parsedIdl.Events),
-			"typesCount", len(parsedIdl.Types),
-			"constantsCount", len(parsedIdl.Constants),
-			"errorsCount", len(parsedIdl.Errors),
-		)
+func (f *idlFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.path, "idl", "", "Path to the IDL file (required)")
+}
+
+// load parses the IDL.
+func (f *idlFlags) load() (*idl.Idl, error) {
+	if f.path == "" {
+		return nil, errors.New("please provide the path to the IDL file using the -idl flag")
 	}
-	gen := generator.NewGenerator(parsedIdl, &options)
-	generatedFiles, err := gen.Generate()
+	parsedIdl, err := idl.ParseFromFilepath(f.path)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed to parse the IDL %s: %w", f.path, err)
 	}
+	return parsedIdl, nil
+}
 
-	if !skipGoMod {
-		goModFilepath := path.Join(options.OutputDir, "go.mod")
-		slog.Info("Writing go.mod file",
-			"filepath", goModFilepath,
-			"modPath", options.ModPath,
-		)
+// formatFlag is the -format flag of the commands that print text or JSON.
+type formatFlag string
 
-		err = os.WriteFile(goModFilepath, []byte(generatedFiles.GoMod), 0o777)
-		if err != nil {
-			panic(err)
-		}
-	}
-	{
-		for _, file := range generatedFiles.Files {
-			{
-				// Save assets:
-				assetFilename := file.Name
-				assetFilepath := path.Join(options.OutputDir, assetFilename)
-
-				// Create file:
-				goFile, err := os.Create(assetFilepath)
-				if err != nil {
-					panic(err)
-				}
-				defer goFile.Close()
-
-				slog.Info("Writing file",
-					"filepath", assetFilepath,
-					"name", file.Name,
-					"modPath", options.ModPath,
-				)
-				err = file.File.Render(goFile)
-				if err != nil {
-					panic(err)
-				}
-			}
-		}
-		executeCmd(outputDir, "go", "mod", "tidy")
-		executeCmd(outputDir, "go", "fmt")
-		executeCmd(outputDir, "go", "build", "-o", "/dev/null") // Just to ensure everything compiles.
-		slog.Info("Generation completed successfully",
-			"outputDir", options.OutputDir,
-			"modPath", options.ModPath,
-			"package", options.Package,
-			"programName", options.ProgramName,
-		)
+func (f *formatFlag) register(fs *flag.FlagSet) {
+	*f = "text"
+	fs.Var(f, "format", "Output format: text or json")
+}
+
+func (f *formatFlag) String() string {
+	return string(*f)
+}
+
+func (f *formatFlag) Set(value string) error {
+	if value != "text" && value != "json" {
+		return fmt.Errorf("unknown format %q (expected text or json)", value)
 	}
+	*f = formatFlag(value)
+	return nil
 }
 
-func executeCmd(dir string, name string, arg ...string) {
+func executeCmd(dir string, name string, arg ...string) error {
 	cmd := exec.Command(name, arg...)
 	cmd.Dir = dir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
-		panic(err)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s %s: %w", name, strings.Join(arg, " "), err)
 	}
+	return nil
 }
 
 func hasCommand(name string) bool {
 	_, err := exec.LookPath(name)
 	return err == nil
 }
+
+// stringSliceFlag is a flag that can be repeated.
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
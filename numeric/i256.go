@@ -0,0 +1,110 @@
+package numeric
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// Int256 is a signed 256-bit integer (the IDL "i256" type),
+// stored in two's complement as four 64-bit limbs, least significant first.
+// It's Borsh-encoded as 32 little-endian bytes.
+type Int256 [4]uint64
+
+// NewInt256 returns the Int256 with the given value.
+func NewInt256(value int64) Int256 {
+	i, _ := Int256FromBigInt(big.NewInt(value))
+	return i
+}
+
+// Int256FromBigInt converts v to an Int256.
+// It returns an error if v doesn't fit in 256 bits.
+func Int256FromBigInt(v *big.Int) (Int256, error) {
+	if v.Cmp(minInt256) < 0 || v.Cmp(maxInt256) > 0 {
+		return Int256{}, fmt.Errorf("value %s out of range for i256", v)
+	}
+	return Int256(uint256FromBits(v)), nil
+}
+
+// ParseInt256 parses a decimal (or 0x, 0o or 0b-prefixed) string,
+// optionally signed; underscores are allowed as digit separators.
+func ParseInt256(s string) (Int256, error) {
+	v, err := parseBigInt(s)
+	if err != nil {
+		return Int256{}, err
+	}
+	return Int256FromBigInt(v)
+}
+
+// MustParseInt256 is like ParseInt256 but panics on error.
+func MustParseInt256(s string) Int256 {
+	v, err := ParseInt256(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (i Int256) IsNegative() bool {
+	return i[3]>>63 == 1
+}
+
+func (i Int256) BigInt() *big.Int {
+	v := Uint256(i).BigInt()
+	if i.IsNegative() {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return v
+}
+
+func (i Int256) IsZero() bool {
+	return i == Int256{}
+}
+
+// Bytes returns the 32-byte little-endian two's complement representation of i.
+func (i Int256) Bytes() []byte {
+	return Uint256(i).Bytes()
+}
+
+// String returns the decimal representation of i.
+func (i Int256) String() string {
+	return i.BigInt().String()
+}
+
+func (i Int256) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+func (i *Int256) UnmarshalText(text []byte) error {
+	v, err := ParseInt256(string(text))
+	if err != nil {
+		return err
+	}
+	*i = v
+	return nil
+}
+
+// MarshalJSON encodes i as a decimal string, because JSON numbers
+// can't represent 256-bit integers without losing precision.
+func (i Int256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
+}
+
+// UnmarshalJSON accepts both a string and a plain JSON number;
+// like for the built-in types, `null` leaves i unchanged.
+func (i *Int256) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return i.UnmarshalText(unquoteJSONNumber(data))
+}
+
+func (i Int256) MarshalWithEncoder(encoder *bin.Encoder) error {
+	return Uint256(i).MarshalWithEncoder(encoder)
+}
+
+func (i *Int256) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	return (*Uint256)(i).UnmarshalWithDecoder(decoder)
+}
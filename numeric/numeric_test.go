@@ -0,0 +1,123 @@
+package numeric
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUint256(t *testing.T) {
+	max := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+	for _, s := range []string{"0", "1", "18446744073709551616", max} {
+		u, err := ParseUint256(s)
+		require.NoError(t, err)
+		require.Equal(t, s, u.String())
+
+		v, ok := new(big.Int).SetString(s, 10)
+		require.True(t, ok)
+		require.Equal(t, 0, v.Cmp(u.BigInt()))
+	}
+
+	require.Equal(t, Uint256{0, 1, 0, 0}, MustParseUint256("18_446_744_073_709_551_616"))
+	require.Equal(t, NewUint256(255), MustParseUint256("0xff"))
+	require.Equal(t, NewUint256(8), MustParseUint256("0o10"))
+	require.Equal(t, NewUint256(5), MustParseUint256("0b101"))
+	// A leading zero doesn't make it octal.
+	require.Equal(t, NewUint256(10), MustParseUint256("010"))
+	require.Equal(t, NewUint256(9), MustParseUint256("09"))
+
+	_, err := ParseUint256("-1")
+	require.Error(t, err)
+	for _, s := range []string{"0x", "0x-1", "0b2", "--1", ""} {
+		_, err = ParseUint256(s)
+		require.Error(t, err, s)
+	}
+	_, err = ParseUint256(max + "0")
+	require.Error(t, err)
+	_, err = ParseUint256("abc")
+	require.Error(t, err)
+}
+
+func TestInt256(t *testing.T) {
+	min := "-57896044618658097711785492504343953926634992332820282019728792003956564819968"
+	max := "57896044618658097711785492504343953926634992332820282019728792003956564819967"
+	for _, s := range []string{"0", "1", "-1", "-18446744073709551616", min, max} {
+		i, err := ParseInt256(s)
+		require.NoError(t, err)
+		require.Equal(t, s, i.String())
+	}
+
+	require.Equal(t, NewInt256(-16), MustParseInt256("-0x10"))
+	require.Equal(t, NewInt256(-10), MustParseInt256("-010"))
+	require.Equal(t, Int256{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}, NewInt256(-1))
+	require.True(t, NewInt256(-5).IsNegative())
+	require.False(t, NewInt256(5).IsNegative())
+
+	_, err := ParseInt256(max[:len(max)-1] + "8")
+	require.Error(t, err)
+	_, err = ParseInt256(min[:len(min)-1] + "9")
+	require.Error(t, err)
+}
+
+func TestBorsh(t *testing.T) {
+	type container struct {
+		U Uint256
+		I Int256
+	}
+	value := container{
+		U: MustParseUint256("340282366920938463463374607431768211457"), // 2^128 + 1
+		I: NewInt256(-2),
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, bin.NewBorshEncoder(buf).Encode(value))
+	require.Equal(t, 64, buf.Len())
+
+	expected := make([]byte, 64)
+	expected[0] = 1
+	expected[16] = 1
+	expected[32] = 0xfe
+	for i := 33; i < 64; i++ {
+		expected[i] = 0xff
+	}
+	require.Equal(t, expected, buf.Bytes())
+
+	var decoded container
+	require.NoError(t, bin.NewBorshDecoder(buf.Bytes()).Decode(&decoded))
+	require.Equal(t, value, decoded)
+
+	require.Error(t, new(Uint256).UnmarshalWithDecoder(bin.NewBorshDecoder(expected[:20])))
+}
+
+func TestJSON(t *testing.T) {
+	type container struct {
+		U Uint256 `json:"u"`
+		I Int256  `json:"i"`
+	}
+	value := container{
+		U: MustParseUint256("100000000000000000000000000000"),
+		I: MustParseInt256("-100000000000000000000000000000"),
+	}
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"u":"100000000000000000000000000000","i":"-100000000000000000000000000000"}`, string(data))
+
+	var decoded container
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, value, decoded)
+
+	// Plain JSON numbers are accepted too.
+	require.NoError(t, json.Unmarshal([]byte(`{"u":42,"i":-42}`), &decoded))
+	require.Equal(t, container{U: NewUint256(42), I: NewInt256(-42)}, decoded)
+
+	require.Error(t, json.Unmarshal([]byte(`{"u":"-1"}`), &decoded))
+
+	// null leaves the values unchanged.
+	decoded = value
+	require.NoError(t, json.Unmarshal([]byte(`{"u":null,"i":null}`), &decoded))
+	require.Equal(t, value, decoded)
+}
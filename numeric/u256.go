@@ -0,0 +1,181 @@
+// Package numeric contains the runtime types used by the generated code
+// for the integer types of the IDL that have no Go (or solana-go) equivalent.
+package numeric
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// Uint256 is an unsigned 256-bit integer (the IDL "u256" type),
+// stored as four 64-bit limbs, least significant first.
+// It's Borsh-encoded as 32 little-endian bytes.
+type Uint256 [4]uint64
+
+var (
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	minInt256  = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 255))
+	maxInt256  = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1))
+)
+
+// NewUint256 returns the Uint256 with the given value.
+func NewUint256(value uint64) Uint256 {
+	return Uint256{value}
+}
+
+// Uint256FromBigInt converts v to a Uint256.
+// It returns an error if v is negative or doesn't fit in 256 bits.
+func Uint256FromBigInt(v *big.Int) (Uint256, error) {
+	if v.Sign() < 0 || v.Cmp(maxUint256) > 0 {
+		return Uint256{}, fmt.Errorf("value %s out of range for u256", v)
+	}
+	return uint256FromBits(v), nil
+}
+
+// ParseUint256 parses a decimal (or 0x, 0o or 0b-prefixed) string;
+// underscores are allowed as digit separators, like in Rust.
+func ParseUint256(s string) (Uint256, error) {
+	v, err := parseBigInt(s)
+	if err != nil {
+		return Uint256{}, err
+	}
+	return Uint256FromBigInt(v)
+}
+
+// MustParseUint256 is like ParseUint256 but panics on error.
+func MustParseUint256(s string) Uint256 {
+	v, err := ParseUint256(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (u Uint256) BigInt() *big.Int {
+	return new(big.Int).SetBytes(u.bigEndianBytes())
+}
+
+func (u Uint256) IsZero() bool {
+	return u == Uint256{}
+}
+
+// Bytes returns the 32-byte little-endian representation of u.
+func (u Uint256) Bytes() []byte {
+	buf := make([]byte, 32)
+	for i, limb := range u {
+		binary.LittleEndian.PutUint64(buf[i*8:], limb)
+	}
+	return buf
+}
+
+// String returns the decimal representation of u.
+func (u Uint256) String() string {
+	return u.BigInt().String()
+}
+
+func (u Uint256) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *Uint256) UnmarshalText(text []byte) error {
+	v, err := ParseUint256(string(text))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// MarshalJSON encodes u as a decimal string, because JSON numbers
+// can't represent 256-bit integers without losing precision.
+func (u Uint256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON accepts both a string and a plain JSON number;
+// like for the built-in types, `null` leaves u unchanged.
+func (u *Uint256) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	return u.UnmarshalText(unquoteJSONNumber(data))
+}
+
+func (u Uint256) MarshalWithEncoder(encoder *bin.Encoder) error {
+	return encoder.WriteBytes(u.Bytes(), false)
+}
+
+func (u *Uint256) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	buf, err := decoder.ReadNBytes(32)
+	if err != nil {
+		return err
+	}
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint64(buf[i*8:])
+	}
+	return nil
+}
+
+func (u Uint256) bigEndianBytes() []byte {
+	buf := make([]byte, 32)
+	for i, limb := range u {
+		binary.BigEndian.PutUint64(buf[(3-i)*8:], limb)
+	}
+	return buf
+}
+
+// uint256FromBits returns the lowest 256 bits of v
+// (of its two's complement representation, if v is negative).
+func uint256FromBits(v *big.Int) Uint256 {
+	var buf [32]byte
+	new(big.Int).And(v, maxUint256).FillBytes(buf[:])
+	var u Uint256
+	for i := range u {
+		u[i] = binary.BigEndian.Uint64(buf[(3-i)*8:])
+	}
+	return u
+}
+
+// parseBigInt parses a decimal integer, or a hexadecimal, octal or binary one with
+// a 0x, 0o or 0b prefix. Unlike in Go, a leading zero doesn't make it octal.
+func parseBigInt(s string) (*big.Int, error) {
+	clean := strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	sign := ""
+	if strings.HasPrefix(clean, "-") || strings.HasPrefix(clean, "+") {
+		sign, clean = clean[:1], clean[1:]
+	}
+	base := 10
+	if len(clean) > 2 && clean[0] == '0' {
+		switch clean[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			clean = clean[2:]
+		}
+	}
+	if clean == "" || clean[0] == '-' || clean[0] == '+' {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	v, ok := new(big.Int).SetString(sign+clean, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return v, nil
+}
+
+func unquoteJSONNumber(data []byte) []byte {
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		return data[1 : len(data)-1]
+	}
+	return data
+}
@@ -0,0 +1,200 @@
+// Package option contains the runtime types used by the generated code for the
+// IDL option and coption types, when generic options are enabled
+// (see generator.GeneratorOptions.GenericOptions).
+//
+// Unlike pointers, they can't confuse "Some(zero value)" with "None".
+package option
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// Option is an optional value (the IDL "option" type).
+// The zero value is None. It's Borsh-encoded as a 1-byte flag followed by the value, if any,
+// and JSON-encoded as the value, or null.
+type Option[T any] struct {
+	value T
+	some  bool
+}
+
+// Some returns an Option that holds value.
+func Some[T any](value T) Option[T] {
+	return Option[T]{value: value, some: true}
+}
+
+// None returns an empty Option.
+func None[T any]() Option[T] {
+	return Option[T]{}
+}
+
+// Get returns the value and true, or the zero value and false if o is None.
+func (o Option[T]) Get() (T, bool) {
+	return o.value, o.some
+}
+
+// GetOr returns the value, or fallback if o is None.
+func (o Option[T]) GetOr(fallback T) T {
+	if !o.some {
+		return fallback
+	}
+	return o.value
+}
+
+func (o Option[T]) IsSome() bool {
+	return o.some
+}
+
+func (o Option[T]) IsNone() bool {
+	return !o.some
+}
+
+// String returns "Some(<value>)", or "None".
+func (o Option[T]) String() string {
+	return formatString(o.value, o.some)
+}
+
+func (o Option[T]) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if err := encoder.WriteOption(o.some); err != nil {
+		return err
+	}
+	if !o.some {
+		return nil
+	}
+	return encoder.Encode(o.value)
+}
+
+func (o *Option[T]) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	ok, err := decoder.ReadOption()
+	if err != nil {
+		return err
+	}
+	*o = Option[T]{}
+	if !ok {
+		return nil
+	}
+	if err := decoder.Decode(&o.value); err != nil {
+		return err
+	}
+	o.some = true
+	return nil
+}
+
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	return marshalJSON(o.value, o.some)
+}
+
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	*o = Option[T]{}
+	var err error
+	o.some, err = unmarshalJSON(data, &o.value)
+	return err
+}
+
+// COption is an optional value (the IDL "coption" type).
+// The zero value is None. It's Borsh-encoded as a 4-byte flag followed by the value, if any,
+// and JSON-encoded as the value, or null.
+type COption[T any] struct {
+	value T
+	some  bool
+}
+
+// SomeC returns a COption that holds value.
+func SomeC[T any](value T) COption[T] {
+	return COption[T]{value: value, some: true}
+}
+
+// NoneC returns an empty COption.
+func NoneC[T any]() COption[T] {
+	return COption[T]{}
+}
+
+// Get returns the value and true, or the zero value and false if o is None.
+func (o COption[T]) Get() (T, bool) {
+	return o.value, o.some
+}
+
+// GetOr returns the value, or fallback if o is None.
+func (o COption[T]) GetOr(fallback T) T {
+	if !o.some {
+		return fallback
+	}
+	return o.value
+}
+
+func (o COption[T]) IsSome() bool {
+	return o.some
+}
+
+func (o COption[T]) IsNone() bool {
+	return !o.some
+}
+
+// String returns "Some(<value>)", or "None".
+func (o COption[T]) String() string {
+	return formatString(o.value, o.some)
+}
+
+func (o COption[T]) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if err := encoder.WriteCOption(o.some); err != nil {
+		return err
+	}
+	if !o.some {
+		return nil
+	}
+	return encoder.Encode(o.value)
+}
+
+func (o *COption[T]) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	ok, err := decoder.ReadCOption()
+	if err != nil {
+		return err
+	}
+	*o = COption[T]{}
+	if !ok {
+		return nil
+	}
+	if err := decoder.Decode(&o.value); err != nil {
+		return err
+	}
+	o.some = true
+	return nil
+}
+
+func (o COption[T]) MarshalJSON() ([]byte, error) {
+	return marshalJSON(o.value, o.some)
+}
+
+func (o *COption[T]) UnmarshalJSON(data []byte) error {
+	*o = COption[T]{}
+	var err error
+	o.some, err = unmarshalJSON(data, &o.value)
+	return err
+}
+
+func formatString(value any, some bool) string {
+	if !some {
+		return "None"
+	}
+	return fmt.Sprintf("Some(%v)", value)
+}
+
+func marshalJSON(value any, some bool) ([]byte, error) {
+	if !some {
+		return []byte("null"), nil
+	}
+	return json.Marshal(value)
+}
+
+func unmarshalJSON(data []byte, value any) (bool, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package option
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOption(t *testing.T) {
+	value, ok := Some(uint64(0)).Get()
+	require.True(t, ok)
+	require.Equal(t, uint64(0), value)
+
+	value, ok = None[uint64]().Get()
+	require.False(t, ok)
+	require.Equal(t, uint64(0), value)
+
+	require.Equal(t, None[uint64](), Option[uint64]{})
+	require.True(t, Some("").IsSome())
+	require.True(t, None[string]().IsNone())
+	require.Equal(t, "x", None[string]().GetOr("x"))
+	require.Equal(t, "y", Some("y").GetOr("x"))
+
+	require.Equal(t, "Some(0)", Some(uint64(0)).String())
+	require.Equal(t, "None", None[uint64]().String())
+	require.Equal(t, "Some(7)", SomeC(uint32(7)).String())
+	require.Equal(t, "None", NoneC[uint32]().String())
+}
+
+func TestBorsh(t *testing.T) {
+	type container struct {
+		A Option[uint16]
+		B Option[uint16]
+		C COption[uint16]
+		D COption[uint16]
+	}
+	value := container{
+		A: Some(uint16(0)),
+		B: None[uint16](),
+		C: SomeC(uint16(0x0102)),
+		D: NoneC[uint16](),
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, bin.NewBorshEncoder(buf).Encode(value))
+	require.Equal(t, []byte{
+		1, 0, 0, // A
+		0,          // B
+		1, 0, 0, 0, // C
+		2, 1,
+		0, 0, 0, 0, // D
+	}, buf.Bytes())
+
+	var decoded container
+	require.NoError(t, bin.NewBorshDecoder(buf.Bytes()).Decode(&decoded))
+	require.Equal(t, value, decoded)
+
+	// A vector of options:
+	list := []Option[uint8]{Some(uint8(7)), None[uint8]()}
+	buf.Reset()
+	require.NoError(t, bin.NewBorshEncoder(buf).Encode(list))
+	require.Equal(t, []byte{2, 0, 0, 0, 1, 7, 0}, buf.Bytes())
+	var decodedList []Option[uint8]
+	require.NoError(t, bin.NewBorshDecoder(buf.Bytes()).Decode(&decodedList))
+	require.Equal(t, list, decodedList)
+
+	require.Error(t, new(COption[uint8]).UnmarshalWithDecoder(bin.NewBorshDecoder([]byte{2, 0, 0, 0})))
+}
+
+func TestJSON(t *testing.T) {
+	type container struct {
+		A Option[uint64]  `json:"a"`
+		B Option[uint64]  `json:"b"`
+		C COption[string] `json:"c"`
+	}
+	value := container{
+		A: Some(uint64(0)),
+		B: None[uint64](),
+		C: SomeC("x"),
+	}
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":0,"b":null,"c":"x"}`, string(data))
+
+	var decoded container
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, value, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`{}`), &decoded))
+	require.Equal(t, value, decoded)
+	require.Error(t, json.Unmarshal([]byte(`{"a":"x"}`), &decoded))
+}
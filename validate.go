@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// errInvalidIdl is returned by the validate command for invalid IDLs, after printing the errors.
+var errInvalidIdl = errors.New("invalid IDL")
+
+func cmdValidate(args []string) error {
+	fs := newFlagSet("validate")
+	var idlFlags idlFlags
+	idlFlags.register(fs)
+	var format formatFlag
+	format.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	parsedIdl, err := idlFlags.load()
+	if err != nil {
+		return err
+	}
+	validationErrs := parsedIdl.Validate()
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(struct {
+			Valid  bool `json:"valid"`
+			Errors any  `json:"errors,omitempty"`
+		}{
+			Valid: validationErrs == nil,
+			Errors: func() any {
+				if validationErrs == nil {
+					return nil
+				}
+				return validationErrs
+			}(),
+		}); err != nil {
+			return err
+		}
+	} else if validationErrs != nil {
+		fmt.Print(validationErrs.Error())
+	} else {
+		fmt.Println("The IDL is valid.")
+	}
+	if validationErrs != nil {
+		return errInvalidIdl
+	}
+	return nil
+}
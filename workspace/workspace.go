@@ -0,0 +1,228 @@
+// Package workspace reads Anchor workspaces: the programs listed in Anchor.toml,
+// their program IDs per cluster, and the IDLs built by `anchor build`.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gagliardetto/solana-go"
+)
+
+// ManifestFilename is the name of the manifest of an Anchor workspace.
+const ManifestFilename = "Anchor.toml"
+
+// defaultMembers are the members of a workspace whose manifest doesn't list them.
+var defaultMembers = []string{"programs/*"}
+
+// Workspace is an Anchor workspace.
+type Workspace struct {
+	Dir      string    // Directory of the Anchor.toml file.
+	Programs []Program // Programs of the workspace members, sorted by name.
+}
+
+// Program is a program of an Anchor workspace.
+type Program struct {
+	// Name is the name of the library crate of the program (e.g. "my_program"),
+	// which is the name of its IDL file.
+	Name string
+	// Dir is the directory of the crate of the program.
+	Dir string
+	// IdlPath is the path of the IDL built by `anchor build`, which may not exist yet.
+	IdlPath string
+	// IDs are the program IDs of the [programs.<cluster>] tables of Anchor.toml,
+	// by cluster (e.g. "localnet", "devnet", "mainnet").
+	IDs map[string]solana.PublicKey
+}
+
+// anchorManifest is the part of Anchor.toml that is read.
+type anchorManifest struct {
+	Workspace struct {
+		Members []string `toml:"members"`
+		Exclude []string `toml:"exclude"`
+	} `toml:"workspace"`
+	// Programs are the addresses of the programs, by cluster and program name;
+	// the tables may be written with headers ([programs.localnet]), dotted keys or inline.
+	Programs map[string]map[string]programAddress `toml:"programs"`
+}
+
+// programAddress is the address of a program in Anchor.toml: either `name = "<address>"`,
+// or `name = { address = "<address>", ... }`.
+type programAddress string
+
+func (a *programAddress) UnmarshalTOML(value any) error {
+	if table, ok := value.(map[string]any); ok {
+		value = table["address"]
+	}
+	address, ok := value.(string)
+	if !ok {
+		return errors.New("missing address")
+	}
+	*a = programAddress(address)
+	return nil
+}
+
+// cargoManifest is the part of Cargo.toml that is read.
+type cargoManifest struct {
+	Lib struct {
+		Name string `toml:"name"`
+	} `toml:"lib"`
+	Package struct {
+		Name string `toml:"name"`
+	} `toml:"package"`
+}
+
+// Load reads the Anchor workspace whose Anchor.toml file is in dir.
+func Load(dir string) (*Workspace, error) {
+	manifestPath := filepath.Join(dir, ManifestFilename)
+	var manifest anchorManifest
+	meta, err := readToml(manifestPath, &manifest)
+	if err != nil {
+		return nil, err
+	}
+	members := manifest.Workspace.Members
+	if !meta.IsDefined("workspace", "members") {
+		members = defaultMembers
+	}
+	programDirs, err := resolveMembers(dir, members, manifest.Workspace.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := clusterProgramIDs(manifest.Programs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestPath, err)
+	}
+
+	workspace := &Workspace{Dir: dir}
+	for _, programDir := range programDirs {
+		name, err := libName(programDir)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(workspace.Programs, func(program Program) bool { return program.Name == name }) {
+			return nil, fmt.Errorf("%s: two members are named %q", manifestPath, name)
+		}
+		program := Program{
+			Name:    name,
+			Dir:     programDir,
+			IdlPath: filepath.Join(dir, "target", "idl", name+".json"),
+			IDs:     make(map[string]solana.PublicKey),
+		}
+		for cluster, clusterIDs := range ids {
+			if id, ok := clusterIDs[name]; ok {
+				program.IDs[cluster] = id
+			}
+		}
+		workspace.Programs = append(workspace.Programs, program)
+	}
+	sort.Slice(workspace.Programs, func(i, j int) bool {
+		return workspace.Programs[i].Name < workspace.Programs[j].Name
+	})
+	return workspace, nil
+}
+
+// resolveMembers returns the directories matching the members (which are glob
+// patterns relative to dir), except those matching exclude.
+func resolveMembers(dir string, members []string, exclude []string) ([]string, error) {
+	var dirs []string
+	for _, member := range members {
+		matches, err := filepath.Glob(filepath.Join(dir, member))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace member %q: %w", member, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(filepath.Join(match, "Cargo.toml")); err != nil || info.IsDir() {
+				// Not a crate (e.g. a file matched by programs/*).
+				continue
+			}
+			excluded := false
+			for _, pattern := range exclude {
+				rel, err := filepath.Rel(dir, match)
+				if err != nil {
+					return nil, err
+				}
+				if ok, err := filepath.Match(filepath.Clean(pattern), rel); err != nil {
+					return nil, fmt.Errorf("invalid workspace exclude %q: %w", pattern, err)
+				} else if ok {
+					excluded = true
+					break
+				}
+			}
+			if !excluded && !slices.Contains(dirs, match) {
+				dirs = append(dirs, match)
+			}
+		}
+	}
+	return dirs, nil
+}
+
+// clusterProgramIDs returns the program IDs of the [programs.<cluster>] tables, by cluster and program name.
+func clusterProgramIDs(programs map[string]map[string]programAddress) (map[string]map[string]solana.PublicKey, error) {
+	ids := make(map[string]map[string]solana.PublicKey)
+	for cluster, addresses := range programs {
+		normalized := NormalizeCluster(cluster)
+		if ids[normalized] == nil {
+			ids[normalized] = make(map[string]solana.PublicKey)
+		}
+		for name, address := range addresses {
+			id, err := solana.PublicKeyFromBase58(string(address))
+			if err != nil {
+				return nil, fmt.Errorf("programs.%s: invalid address of program %q: %w", cluster, name, err)
+			}
+			ids[normalized][name] = id
+		}
+	}
+	return ids, nil
+}
+
+// NormalizeCluster returns the name of a cluster as Anchor parses it (e.g. "mainnet-beta" is "mainnet"),
+// which is a key of Program.IDs.
+func NormalizeCluster(cluster string) string {
+	cluster = strings.ToLower(cluster)
+	switch cluster {
+	case "m", "mainnet-beta":
+		return "mainnet"
+	case "d":
+		return "devnet"
+	case "t":
+		return "testnet"
+	case "l":
+		return "localnet"
+	}
+	return cluster
+}
+
+// libName returns the name of the library crate in dir: the [lib] name of its
+// Cargo.toml, else the name of its package with dashes replaced by underscores.
+func libName(dir string) (string, error) {
+	manifestPath := filepath.Join(dir, "Cargo.toml")
+	var manifest cargoManifest
+	if _, err := readToml(manifestPath, &manifest); err != nil {
+		return "", err
+	}
+	for _, name := range []string{manifest.Lib.Name, manifest.Package.Name} {
+		if name != "" {
+			return strings.ReplaceAll(name, "-", "_"), nil
+		}
+	}
+	return "", fmt.Errorf("%s: missing package name", manifestPath)
+}
+
+// readToml decodes the TOML file at path into v; the keys that v doesn't have are ignored.
+func readToml(path string, v any) (toml.MetaData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return toml.MetaData{}, err
+	}
+	meta, err := toml.Decode(string(data), v)
+	if err != nil {
+		return toml.MetaData{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return meta, nil
+}
@@ -0,0 +1,141 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	counterID := solana.MustPublicKeyFromBase58("Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS")
+	vaultID := solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
+
+	t.Run("Members", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"Anchor.toml": `
+[programs.localnet]
+counter = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+vault = "11111111111111111111111111111111"
+
+[programs.mainnet-beta]
+vault = { address = "11111111111111111111111111111111" }
+
+[workspace]
+members = ["programs/*", "extra/vault"]
+exclude = ["programs/legacy"]
+`,
+			"programs/counter/Cargo.toml": "[package]\nname = \"counter\"\n",
+			"programs/legacy/Cargo.toml":  "[package]\nname = \"legacy\"\n",
+			"programs/README.md":          "not a crate",
+			"extra/vault/Cargo.toml":      "[package]\nname = \"vault-program\"\n\n[lib]\nname = \"vault\"\n",
+		})
+		ws, err := Load(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []Program{
+			{
+				Name:    "counter",
+				Dir:     filepath.Join(dir, "programs/counter"),
+				IdlPath: filepath.Join(dir, "target/idl/counter.json"),
+				IDs:     map[string]solana.PublicKey{"localnet": counterID},
+			},
+			{
+				Name:    "vault",
+				Dir:     filepath.Join(dir, "extra/vault"),
+				IdlPath: filepath.Join(dir, "target/idl/vault.json"),
+				IDs:     map[string]solana.PublicKey{"localnet": vaultID, "mainnet": vaultID},
+			},
+		}, ws.Programs)
+	})
+
+	t.Run("ProgramsForms", func(t *testing.T) {
+		// The [programs] table may be written with dotted keys, or with inline tables.
+		for name, anchorToml := range map[string]string{
+			"dotted keys": `
+[programs]
+localnet.counter = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+devnet.counter = { address = "11111111111111111111111111111111" }
+`,
+			"inline tables": `
+programs = { localnet = { counter = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS" }, devnet = { counter = { address = "11111111111111111111111111111111" } } }
+`,
+		} {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				writeFiles(t, dir, map[string]string{
+					"Anchor.toml":                 anchorToml,
+					"programs/counter/Cargo.toml": "[package]\nname = \"counter\"\n",
+				})
+				ws, err := Load(dir)
+				require.NoError(t, err)
+				require.Len(t, ws.Programs, 1)
+				assert.Equal(t, map[string]solana.PublicKey{"localnet": counterID, "devnet": vaultID}, ws.Programs[0].IDs)
+			})
+		}
+	})
+
+	t.Run("DefaultMembers", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"Anchor.toml":                       "[provider]\ncluster = \"Localnet\"\n",
+			"programs/my-program/Cargo.toml":    "[package]\nname = \"my-program\"\n",
+			"programs/other-program/Cargo.toml": "[package]\nname = \"other-program\"\n",
+		})
+		ws, err := Load(dir)
+		require.NoError(t, err)
+		require.Len(t, ws.Programs, 2)
+		assert.Equal(t, "my_program", ws.Programs[0].Name)
+		assert.Equal(t, "other_program", ws.Programs[1].Name)
+		assert.Empty(t, ws.Programs[0].IDs)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := Load(t.TempDir())
+		assert.Error(t, err)
+
+		for name, files := range map[string]map[string]string{
+			"invalid address": {
+				"Anchor.toml": "[programs.devnet]\ncounter = \"nope\"\n",
+			},
+			"invalid members": {
+				"Anchor.toml": "[workspace]\nmembers = \"programs/*\"\n",
+			},
+			"missing address": {
+				"Anchor.toml": "[programs.devnet]\ncounter = { url = \"x\" }\n",
+			},
+			"duplicate names": {
+				"Anchor.toml":           "",
+				"programs/a/Cargo.toml": "[package]\nname = \"same\"\n",
+				"programs/b/Cargo.toml": "[package]\nname = \"same\"\n",
+			},
+			"missing name": {
+				"Anchor.toml":           "",
+				"programs/a/Cargo.toml": "[dependencies]\n",
+			},
+		} {
+			dir := t.TempDir()
+			writeFiles(t, dir, files)
+			_, err := Load(dir)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestNormalizeCluster(t *testing.T) {
+	assert.Equal(t, "mainnet", NormalizeCluster("mainnet-beta"))
+	assert.Equal(t, "mainnet", NormalizeCluster("Mainnet"))
+	assert.Equal(t, "devnet", NormalizeCluster("devnet"))
+	assert.Equal(t, "localnet", NormalizeCluster("l"))
+}